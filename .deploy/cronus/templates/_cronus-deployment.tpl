@@ -42,6 +42,7 @@ spec:
 {{ toYaml . | indent 8}}
       {{- end }}
       {{- end }}
+      terminationGracePeriodSeconds: 30
       volumes:
       - name: boltdb-store
         persistentVolumeClaim:
@@ -99,7 +100,7 @@ spec:
             failureThreshold: 5
           readinessProbe:
             httpGet:
-              path: /ping
+              path: /ready
               port: {{ .Values.service.internalPort }}
             initialDelaySeconds: 5
             timeoutSeconds: 3
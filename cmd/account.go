@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+
+	"github.com/codefresh-io/cronus/pkg/types"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+var deleteAccountCommand = cli.Command{
+	Name:      "delete-account",
+	Usage:     "remove all cron events of deleted account from cronus server",
+	ArgsUsage: "ACCOUNT",
+	Flags: append([]cli.Flag{
+		cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "list account events, do not remove them",
+		},
+	}, clientFlags...),
+	Action: deleteAccount,
+}
+
+func deleteAccount(c *cli.Context) error {
+	if c.NArg() != 1 || c.Args().First() == "" {
+		return cli.NewExitError("account is required", 1)
+	}
+	result, err := newClient(c).DeleteAccountEvents(c.Args().First(), c.Bool("dry-run"))
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
+}
+
+func deleteAccountEvents(c *gin.Context) {
+	account := getParam(c, "account")
+	dryRun := c.Query("dry-run") == "true"
+	log.WithFields(log.Fields{
+		"account": account,
+		"dry-run": dryRun,
+	}).Debug("delete account events")
+	removed, err := runner.RemoveAccountJobs(account, dryRun)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	result := types.AccountEvents{Account: account, DryRun: dryRun, Events: make([]types.Event, 0, len(removed))}
+	for _, e := range removed {
+		result.Events = append(result.Events, eventResponse(c, e))
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"github.com/codefresh-io/cronus/pkg/auth"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// authFlags API authentication flags; authentication is disabled, if none is set
+var authFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:   "auth-tokens-file",
+		Usage:  "API bearer tokens file: one '{name} {scopes} {token}' per line",
+		EnvVar: "AUTH_TOKENS_FILE",
+	},
+	cli.StringFlag{
+		Name:   "auth-hmac-keys-file",
+		Usage:  "API HMAC keys file: one '{key-id} {scopes} {base64-secret}' per line",
+		EnvVar: "AUTH_HMAC_KEYS_FILE",
+	},
+	cli.StringFlag{
+		Name:   "auth-jwks-file",
+		Usage:  "JWKS file with public keys for API JWT bearer token validation",
+		EnvVar: "AUTH_JWKS_FILE",
+	},
+	cli.StringFlag{
+		Name:   "auth-jwt-issuer",
+		Usage:  "required JWT issuer ('iss' claim)",
+		EnvVar: "AUTH_JWT_ISSUER",
+	},
+	cli.StringFlag{
+		Name:   "auth-jwt-audience",
+		Usage:  "required JWT audience ('aud' claim)",
+		EnvVar: "AUTH_JWT_AUDIENCE",
+	},
+}
+
+// newAuthenticator create API authenticator from flags; nil if authentication is not configured
+func newAuthenticator(c *cli.Context) (auth.Authenticator, error) {
+	var authenticators []auth.Authenticator
+	if file := c.String("auth-tokens-file"); file != "" {
+		a, err := auth.NewTokenAuthenticator(file)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, a)
+	}
+	if file := c.String("auth-hmac-keys-file"); file != "" {
+		a, err := auth.NewHMACAuthenticator(file)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, a)
+	}
+	if file := c.String("auth-jwks-file"); file != "" {
+		a, err := auth.NewJWTAuthenticator(file, c.String("auth-jwt-issuer"), c.String("auth-jwt-audience"))
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, a)
+	}
+	authenticator := auth.Chain(authenticators...)
+	if authenticator == nil {
+		log.Warn("API authentication is not configured: all API requests are allowed")
+	}
+	return authenticator, nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/codefresh-io/cronus/pkg/backup"
+	"github.com/codefresh-io/cronus/pkg/types"
+	"github.com/gin-gonic/gin"
+	"github.com/urfave/cli"
+)
+
+var backups *backup.Manager
+
+// backupFlags scheduled backup flags
+var backupFlags = []cli.Flag{
+	cli.DurationFlag{
+		Name:   "backup-interval",
+		Usage:  "take event store backup every interval (0 - disabled)",
+		EnvVar: "BACKUP_INTERVAL",
+	},
+	cli.IntFlag{
+		Name:   "backup-keep",
+		Usage:  "number of backups to keep (0 - keep all)",
+		Value:  7,
+		EnvVar: "BACKUP_KEEP",
+	},
+	cli.StringFlag{
+		Name:   "backup-dir",
+		Usage:  "store backups in local directory",
+		EnvVar: "BACKUP_DIR",
+	},
+	cli.StringFlag{
+		Name:   "backup-s3-bucket",
+		Usage:  "store backups in S3 bucket",
+		EnvVar: "S3_BUCKET",
+	},
+	cli.StringFlag{
+		Name:   "backup-s3-prefix",
+		Usage:  "S3 backup key prefix",
+		Value:  "cronus",
+		EnvVar: "S3_PREFIX",
+	},
+	cli.StringFlag{
+		Name:   "backup-s3-endpoint",
+		Usage:  "S3 compatible storage URL (default: AWS S3 endpoint of region)",
+		EnvVar: "S3_ENDPOINT",
+	},
+	cli.StringFlag{
+		Name:   "backup-s3-region",
+		Usage:  "S3 bucket region",
+		Value:  "us-east-1",
+		EnvVar: "AWS_REGION",
+	},
+	cli.StringFlag{
+		Name:   "backup-s3-access-key",
+		Usage:  "S3 access key ID",
+		EnvVar: "AWS_ACCESS_KEY_ID",
+	},
+	cli.StringFlag{
+		Name:   "backup-s3-secret-key",
+		Usage:  "S3 secret access key",
+		EnvVar: "AWS_SECRET_ACCESS_KEY",
+	},
+}
+
+// newBackupManager create backup manager from flags; nil if backup sink is not configured
+func newBackupManager(c *cli.Context, s types.EventStore) (*backup.Manager, error) {
+	var sink backup.Sink
+	var err error
+	if dir := c.String("backup-dir"); dir != "" {
+		sink, err = backup.NewLocalSink(dir)
+	} else if bucket := c.String("backup-s3-bucket"); bucket != "" {
+		sink, err = backup.NewS3Sink(backup.S3Config{
+			Endpoint:  c.String("backup-s3-endpoint"),
+			Region:    c.String("backup-s3-region"),
+			Bucket:    bucket,
+			Prefix:    c.String("backup-s3-prefix"),
+			AccessKey: c.String("backup-s3-access-key"),
+			SecretKey: c.String("backup-s3-secret-key"),
+		})
+	}
+	if err != nil || sink == nil {
+		return nil, err
+	}
+	return backup.NewManager(s, sink, c.Int("backup-keep")), nil
+}
+
+// runBackups take scheduled backups, if enabled
+func runBackups(interval time.Duration, stop <-chan struct{}) {
+	if backups != nil && interval > 0 {
+		go backups.Run(interval, stop)
+	}
+}
+
+func listBackups(c *gin.Context) {
+	if backups == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "backup storage is not configured"})
+		return
+	}
+	all, err := backups.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"backups": all, "last": backups.Last()})
+}
+
+func createBackup(c *gin.Context) {
+	if backups == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "backup storage is not configured"})
+		return
+	}
+	obj, err := backups.Backup()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, obj)
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/codefresh-io/cronus/pkg/client"
+	"github.com/codefresh-io/cronus/pkg/types"
+	"github.com/urfave/cli"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// output formats of client commands
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+type (
+	// actionResult result of event action without response body (unsubscribe, trigger, pause, resume)
+	actionResult struct {
+		URI    string `json:"uri"`
+		Result string `json:"result"`
+	}
+)
+
+var outputFlag = cli.StringFlag{
+	Name:  "output, o",
+	Usage: "output format: table, json or yaml",
+	Value: outputTable,
+}
+
+var clientCommands = []cli.Command{
+	{
+		Name:  "list",
+		Usage: "list events",
+		Flags: withClientFlags(
+			cli.StringFlag{
+				Name:  "account",
+				Usage: "list events of account",
+			},
+			cli.StringFlag{
+				Name:  "status",
+				Usage: "list events with status",
+			},
+			cli.DurationFlag{
+				Name:  "due",
+				Usage: "list events that fire within duration (like 1h), ordered by next fire time",
+			},
+		),
+		Action: clientAction(listCommand),
+	},
+	{
+		Name:      "get",
+		Usage:     "get event details",
+		ArgsUsage: "EVENT_URI",
+		Flags:     withClientFlags(),
+		Action:    clientAction(getCommand),
+	},
+	{
+		Name:      "subscribe",
+		Usage:     "subscribe to event",
+		ArgsUsage: "EVENT_URI SECRET",
+		Flags: withClientFlags(
+			cli.StringFlag{
+				Name:  "jitter",
+				Usage: "random delay window (like 5m)",
+			},
+			cli.StringFlag{
+				Name:  "concurrency",
+				Usage: "concurrency policy: Allow, Forbid or Replace",
+			},
+			cli.StringFlag{
+				Name:  "until",
+				Usage: "event end time (RFC3339)",
+			},
+			cli.StringFlag{
+				Name:  "dialect",
+				Usage: "cron expression dialect: crontab (5 fields) or seconds (6 fields)",
+			},
+		),
+		Action: clientAction(subscribeCommand),
+	},
+	{
+		Name:      "unsubscribe",
+		Usage:     "unsubscribe from event",
+		ArgsUsage: "EVENT_URI",
+		Flags:     withClientFlags(),
+		Action:    clientAction(eventAction("unsubscribed", (*client.Client).Unsubscribe)),
+	},
+	{
+		Name:      "trigger",
+		Usage:     "trigger event now",
+		ArgsUsage: "EVENT_URI",
+		Flags:     withClientFlags(),
+		Action:    clientAction(eventAction(types.ResultTriggered, (*client.Client).Trigger)),
+	},
+	{
+		Name:      "pause",
+		Usage:     "pause event",
+		ArgsUsage: "EVENT_URI",
+		Flags:     withClientFlags(),
+		Action:    clientAction(eventAction(types.StatusPaused, (*client.Client).Pause)),
+	},
+	{
+		Name:      "resume",
+		Usage:     "resume paused event",
+		ArgsUsage: "EVENT_URI",
+		Flags:     withClientFlags(),
+		Action:    clientAction(eventAction("resumed", (*client.Client).Resume)),
+	},
+	{
+		Name:      "history",
+		Usage:     "show last event fire records",
+		ArgsUsage: "EVENT_URI",
+		Flags: withClientFlags(
+			cli.IntFlag{
+				Name:  "limit",
+				Usage: "max number of records",
+				Value: 20,
+			},
+		),
+		Action: clientAction(historyCommand),
+	},
+}
+
+// withClientFlags command flags, followed by output and client flags
+func withClientFlags(flags ...cli.Flag) []cli.Flag {
+	return append(append(flags, outputFlag), clientFlags...)
+}
+
+// clientAction validate output format and run client command; errors are returned as exit errors
+func clientAction(action func(c *cli.Context, cl *client.Client) error) func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		switch c.String("output") {
+		case outputTable, outputJSON, outputYAML:
+		default:
+			return cli.NewExitError(fmt.Sprintf("unknown output format '%s'", c.String("output")), 1)
+		}
+		if err := action(c, newClient(c)); err != nil {
+			if _, ok := err.(*cli.ExitError); ok {
+				return err
+			}
+			return cli.NewExitError(err.Error(), 1)
+		}
+		return nil
+	}
+}
+
+// eventURI get event URI argument; URL encoded URI is decoded first, so it is not encoded twice
+func eventURI(c *cli.Context) (string, error) {
+	uri := c.Args().First()
+	if uri == "" {
+		return "", cli.NewExitError("event URI is required", 1)
+	}
+	if !strings.HasPrefix(uri, "cron:") {
+		if decoded, err := url.PathUnescape(uri); err == nil {
+			uri = decoded
+		}
+	}
+	return uri, nil
+}
+
+// printOutput print value in JSON or YAML format, or as table
+func printOutput(c *cli.Context, v interface{}, table func(w io.Writer)) error {
+	switch c.String("output") {
+	case outputJSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case outputYAML:
+		// convert through JSON, to keep JSON field names
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		var doc interface{}
+		if err = json.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+		if data, err = yaml.Marshal(doc); err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(data)
+		return err
+	default:
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		table(w)
+		return w.Flush()
+	}
+}
+
+func listCommand(c *cli.Context, cl *client.Client) error {
+	events, err := cl.ListEvents(client.ListOptions{
+		Account: c.String("account"),
+		Status:  c.String("status"),
+		Due:     c.Duration("due"),
+	})
+	if err != nil {
+		return err
+	}
+	return printOutput(c, events, func(w io.Writer) {
+		fmt.Fprintln(w, "URI\tSTATUS\tFAILURES\tDESCRIPTION")
+		for _, e := range events {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", types.GetURI(e), e.Status, e.FailureCount, e.Description)
+		}
+	})
+}
+
+// printEvent print event details table
+func printEvent(c *cli.Context, e *types.Event) error {
+	return printOutput(c, e, func(w io.Writer) {
+		for _, field := range [][2]string{
+			{"URI", types.GetURI(*e)},
+			{"Expression", e.Expression},
+			{"Message", e.Message},
+			{"Account", e.Account},
+			{"Secret", e.Secret},
+			{"Description", e.Description},
+			{"Status", e.Status},
+			{"Jitter", e.Jitter},
+			{"Concurrency", e.ConcurrencyPolicy},
+			{"Until", e.Until},
+			{"Dialect", e.Dialect},
+			{"Failures", fmt.Sprint(e.FailureCount)},
+			{"Last error", e.LastError},
+		} {
+			fmt.Fprintf(w, "%s:\t%s\n", field[0], field[1])
+		}
+	})
+}
+
+func getCommand(c *cli.Context, cl *client.Client) error {
+	uri, err := eventURI(c)
+	if err != nil {
+		return err
+	}
+	event, err := cl.GetEvent(uri)
+	if err != nil {
+		return err
+	}
+	return printEvent(c, event)
+}
+
+func subscribeCommand(c *cli.Context, cl *client.Client) error {
+	uri, err := eventURI(c)
+	if err != nil {
+		return err
+	}
+	if c.NArg() != 2 {
+		return cli.NewExitError("event secret is required", 1)
+	}
+	event, err := cl.Subscribe(uri, c.Args().Get(1), client.SubscribeOptions{
+		Jitter:      c.String("jitter"),
+		Concurrency: c.String("concurrency"),
+		Until:       c.String("until"),
+		Dialect:     c.String("dialect"),
+	})
+	if err != nil {
+		return err
+	}
+	return printEvent(c, event)
+}
+
+// eventAction command calling client action with event URI argument
+func eventAction(result string, action func(cl *client.Client, uri string) error) func(c *cli.Context, cl *client.Client) error {
+	return func(c *cli.Context, cl *client.Client) error {
+		uri, err := eventURI(c)
+		if err != nil {
+			return err
+		}
+		if err = action(cl, uri); err != nil {
+			return err
+		}
+		res := actionResult{URI: uri, Result: result}
+		return printOutput(c, res, func(w io.Writer) {
+			fmt.Fprintf(w, "%s: %s\n", res.URI, res.Result)
+		})
+	}
+}
+
+func historyCommand(c *cli.Context, cl *client.Client) error {
+	uri, err := eventURI(c)
+	if err != nil {
+		return err
+	}
+	records, err := cl.History(uri, c.Int("limit"))
+	if err != nil {
+		return err
+	}
+	return printOutput(c, records, func(w io.Writer) {
+		fmt.Fprintln(w, "TIME\tRESULT\tDURATION\tREASON")
+		for _, r := range records {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Time.Format(time.RFC3339), r.Result, r.Duration, r.Reason)
+		}
+	})
+}
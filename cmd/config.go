@@ -0,0 +1,210 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/codefresh-io/cronus/pkg/config"
+	"github.com/codefresh-io/cronus/pkg/cron"
+	"github.com/codefresh-io/cronus/pkg/hermes"
+	"github.com/codefresh-io/go-infra/pkg/logger"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// configFlags server config file flags
+var configFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:   "config",
+		Usage:  "YAML config file; flags and environment variables set explicitly override config file values",
+		EnvVar: "CRONUS_CONFIG",
+	},
+	cli.DurationFlag{
+		Name:   "config-poll-interval",
+		Usage:  "how often to check config file for changes (0 - reload only on SIGHUP)",
+		Value:  30 * time.Second,
+		EnvVar: "CONFIG_POLL_INTERVAL",
+	},
+}
+
+// serverSettings server settings resolved from flags, environment variables and config file
+type serverSettings struct {
+	hermesURL string
+	token     string
+	limit     time.Duration
+	workers   int
+	rate      float64
+	burst     int
+	queueSize int
+	logLevel  string
+	logJSON   bool
+}
+
+// loadConfig load config file, if set
+func loadConfig(c *cli.Context) (*config.Config, error) {
+	file := c.String("config")
+	if file == "" {
+		return nil, nil
+	}
+	log.WithField("config", file).Debug("loading config file")
+	return config.Load(file)
+}
+
+// resolveSettings resolve server settings: flag or environment variable set explicitly wins over config file value,
+// config file value wins over flag default
+func resolveSettings(c *cli.Context, cfg *config.Config) serverSettings {
+	s := serverSettings{
+		hermesURL: c.String("hermes"),
+		token:     c.String("token"),
+		limit:     time.Duration(c.Int64("limit")) * time.Second,
+		workers:   c.Int("workers"),
+		rate:      c.Float64("rate"),
+		burst:     c.Int("burst"),
+		queueSize: c.Int("queue-size"),
+		logLevel:  c.GlobalString("log-level"),
+		logJSON:   c.GlobalBool("json"),
+	}
+	if cfg == nil {
+		return s
+	}
+	if cfg.Hermes.URL != "" && !c.IsSet("hermes") {
+		s.hermesURL = cfg.Hermes.URL
+	}
+	if cfg.Hermes.Token != "" && !c.IsSet("token") {
+		s.token = cfg.Hermes.Token
+	}
+	l := cfg.Limits
+	if l.MinInterval != nil && !c.IsSet("limit") {
+		s.limit = *l.MinInterval
+	}
+	if l.Workers != nil && !c.IsSet("workers") {
+		s.workers = *l.Workers
+	}
+	if l.Rate != nil && !c.IsSet("rate") {
+		s.rate = *l.Rate
+	}
+	if l.Burst != nil && !c.IsSet("burst") {
+		s.burst = *l.Burst
+	}
+	if l.QueueSize != nil && !c.IsSet("queue-size") {
+		s.queueSize = *l.QueueSize
+	}
+	if cfg.Log.Level != "" && !c.GlobalIsSet("log-level") {
+		s.logLevel = cfg.Log.Level
+	}
+	if cfg.Log.JSON != nil && !c.GlobalIsSet("json") {
+		s.logJSON = *cfg.Log.JSON
+	}
+	return s
+}
+
+// hermesURL add protocol, if missing: https, if TLS client options are set, http otherwise
+func hermesURL(name string, tls bool) string {
+	if strings.HasPrefix(name, "http://") || strings.HasPrefix(name, "https://") {
+		if tls && strings.HasPrefix(name, "http://") {
+			log.WithField("hermes", name).Warn("Hermes TLS options are ignored for plain http URL")
+		}
+		return name
+	}
+	if tls {
+		return "https://" + name
+	}
+	return "http://" + name
+}
+
+// newHermesCredentials Hermes API token provider: token file, environment variable or static token
+func newHermesCredentials(c *cli.Context, s serverSettings) (hermes.Credentials, error) {
+	if file := c.String("token-file"); file != "" {
+		return hermes.NewFileCredentials(file)
+	}
+	if name := c.String("token-env"); name != "" {
+		return hermes.NewEnvCredentials(name), nil
+	}
+	return hermes.NewStaticCredentials(s.token), nil
+}
+
+// setLogLevel set log level by name; unknown level is treated as warning
+func setLogLevel(level string) {
+	switch strings.ToLower(level) {
+	case "debug":
+		log.SetLevel(log.DebugLevel)
+	case "info":
+		log.SetLevel(log.InfoLevel)
+	case "warning", "warn":
+		log.SetLevel(log.WarnLevel)
+	case "error":
+		log.SetLevel(log.ErrorLevel)
+	case "fatal":
+		log.SetLevel(log.FatalLevel)
+	case "panic":
+		log.SetLevel(log.PanicLevel)
+	default:
+		log.SetLevel(log.WarnLevel)
+	}
+}
+
+// applyLogSettings apply log level and format
+func applyLogSettings(s serverSettings) {
+	setLogLevel(s.logLevel)
+	if s.logJSON {
+		log.SetFormatter(&logger.CFFormatter{})
+	}
+}
+
+// settingsReloader config change handler: applies limit, log level, trigger rate and Hermes token to running server;
+// other changes require restart
+func settingsReloader(c *cli.Context, current serverSettings, hermesSvc hermes.Service, dispatcher *cron.Dispatcher) func(*config.Config) {
+	return func(cfg *config.Config) {
+		s := resolveSettings(c, cfg)
+		if s.limit != current.limit {
+			log.WithField("limit", s.limit).Info("changing minimal allowed cron interval")
+			runner.SetLimit(s.limit)
+		}
+		if s.logLevel != current.logLevel {
+			setLogLevel(s.logLevel)
+			log.WithField("level", s.logLevel).Info("changed log level")
+		}
+		if s.rate != current.rate || s.burst != current.burst {
+			dispatcher.SetRate(s.rate, s.burst)
+		}
+		if s.token != current.token {
+			if c.String("token-file") != "" || c.String("token-env") != "" {
+				log.Warn("Hermes API token is read from token file or environment variable, config file token ignored")
+			} else if svc, ok := hermesSvc.(interface{ SetToken(string) }); ok {
+				svc.SetToken(s.token)
+				log.WithField("fingerprint", hermes.Fingerprint(s.token)).Info("changed Hermes API token")
+			}
+		}
+		for name, changed := range map[string]bool{
+			"hermes.url":        s.hermesURL != current.hermesURL,
+			"limits.workers":    s.workers != current.workers,
+			"limits.queue_size": s.queueSize != current.queueSize,
+			"log.json":          s.logJSON != current.logJSON,
+		} {
+			if changed {
+				log.WithField("setting", name).Warn("config change requires restart, ignored")
+			}
+		}
+		// keep values that were not applied, to warn on every reload
+		current.limit, current.logLevel = s.limit, s.logLevel
+		current.rate, current.burst, current.token = s.rate, s.burst, s.token
+	}
+}
+
+// watchConfig reload config file on SIGHUP or file change, until stopped
+func watchConfig(c *cli.Context, settings serverSettings, hermesSvc hermes.Service, dispatcher *cron.Dispatcher, stop <-chan struct{}) {
+	file := c.String("config")
+	if file == "" {
+		return
+	}
+	hupc := make(chan os.Signal, 1)
+	signal.Notify(hupc, syscall.SIGHUP)
+	watcher := config.NewWatcher(file, c.Duration("config-poll-interval"), settingsReloader(c, settings, hermesSvc, dispatcher))
+	go func() {
+		watcher.Run(hupc, stop)
+		signal.Stop(hupc)
+	}()
+}
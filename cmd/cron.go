@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codefresh-io/cronus/pkg/cron"
+	"github.com/gin-gonic/gin"
+	"github.com/urfave/cli"
+)
+
+// default and max number of next fire times in expression explanation
+const (
+	defaultExplainCount = 5
+	maxExplainCount     = 100
+)
+
+var cronCommand = cli.Command{
+	Name:  "cron",
+	Usage: "cron expression tools",
+	Subcommands: []cli.Command{
+		{
+			Name:      "explain",
+			Usage:     "explain cron expression: wrong field, description, next fire times, interval and suggested fixes",
+			ArgsUsage: "EXPRESSION",
+			Description: `Parse cron expression the same way cronus server validates event subscription. Quote expression,
+   so the shell does not expand '*'. Exit code is 1, if expression is rejected.`,
+			Flags: []cli.Flag{
+				outputFlag,
+				cli.IntFlag{
+					Name:   "limit",
+					Usage:  "minimal allowed cron interval (seconds)",
+					Value:  60,
+					EnvVar: "LIMIT",
+				},
+				cli.StringFlag{
+					Name:  "dialect",
+					Usage: "cron expression dialect: crontab (5 fields) or seconds (6 fields); default - by number of fields",
+				},
+				cli.StringFlag{
+					Name:  "uri",
+					Usage: "event URI, to expand H tokens",
+				},
+				cli.IntFlag{
+					Name:  "count",
+					Usage: "number of next fire times",
+					Value: defaultExplainCount,
+				},
+			},
+			Action: explainCommand,
+		},
+	},
+}
+
+func explainCommand(c *cli.Context) error {
+	switch c.String("output") {
+	case outputTable, outputJSON, outputYAML:
+	default:
+		return cli.NewExitError(fmt.Sprintf("unknown output format '%s'", c.String("output")), 1)
+	}
+	expression := strings.Join(c.Args(), " ")
+	if expression == "" {
+		return cli.NewExitError("cron expression is required", 1)
+	}
+	count := c.Int("count")
+	if count < 0 || count > maxExplainCount {
+		return cli.NewExitError(fmt.Sprintf("count should be between 0 and %d", maxExplainCount), 1)
+	}
+	ex := cron.Explain(expression, c.String("dialect"), c.String("uri"), time.Duration(c.Int("limit"))*time.Second, count)
+	err := printOutput(c, ex, func(w io.Writer) {
+		printExplanation(w, ex)
+	})
+	if err != nil {
+		return err
+	}
+	if !ex.Valid {
+		return cli.NewExitError("", 1)
+	}
+	return nil
+}
+
+// printExplanation print expression explanation table
+func printExplanation(w io.Writer, ex cron.Explanation) {
+	fmt.Fprintf(w, "Expression:\t%s\n", ex.Expression)
+	if ex.Spec != "" {
+		fmt.Fprintf(w, "Spec:\t%s\n", ex.Spec)
+	}
+	fmt.Fprintf(w, "Valid:\t%t\n", ex.Valid)
+	if ex.Error != "" {
+		fmt.Fprintf(w, "Error:\t%s\n", ex.Error)
+	}
+	if ex.Field != nil {
+		fmt.Fprintf(w, "Field:\t%s (position %d): '%s' - %s\n", ex.Field.Field, ex.Field.Position, ex.Field.Value, ex.Field.Reason)
+	}
+	if ex.Description != "" {
+		fmt.Fprintf(w, "Description:\t%s\n", ex.Description)
+	}
+	if ex.Interval != "" {
+		fmt.Fprintf(w, "Interval:\t%s (shortest %s, limit %s)\n", ex.Interval, ex.MinInterval, ex.Limit)
+	}
+	for i, t := range ex.Next {
+		label := ""
+		if i == 0 {
+			label = "Next:"
+		}
+		fmt.Fprintf(w, "%s\t%s\n", label, t.Format(time.RFC3339))
+	}
+	for i, note := range ex.Notes {
+		label := ""
+		if i == 0 {
+			label = "Notes:"
+		}
+		fmt.Fprintf(w, "%s\t%s\n", label, note)
+	}
+	for i, s := range ex.Suggestions {
+		label := ""
+		if i == 0 {
+			label = "Suggestions:"
+		}
+		fmt.Fprintf(w, "%s\t%s\n", label, s)
+	}
+}
+
+// explainExpression explain cron expression against server minimal interval limit
+func explainExpression(c *gin.Context) {
+	expression := c.Query("expression")
+	if expression == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing expression"})
+		return
+	}
+	count, err := strconv.Atoi(c.DefaultQuery("count", strconv.Itoa(defaultExplainCount)))
+	if err != nil || count < 0 || count > maxExplainCount {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad count value"})
+		return
+	}
+	c.JSON(http.StatusOK, runner.Explain(expression, c.Query("dialect"), c.Query("uri"), count))
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/codefresh-io/cronus/pkg/backend"
+	"github.com/codefresh-io/cronus/pkg/cron"
+	"github.com/codefresh-io/cronus/pkg/redact"
+	"github.com/codefresh-io/cronus/pkg/types"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+var dbCommand = cli.Command{
+	Name:  "db",
+	Usage: "inspect and repair BoltDB event store file (stop cronus server first)",
+	Subcommands: []cli.Command{
+		{
+			Name:  "dump",
+			Usage: "dump event records as JSON lines; unparsable records are dumped raw",
+			Flags: []cli.Flag{
+				storeFlags[0],
+				cli.BoolFlag{
+					Name:  "history",
+					Usage: "include event fire history",
+				},
+				cli.BoolFlag{
+					Name:  "show-secrets",
+					Usage: "do not mask event secrets",
+				},
+			},
+			Action: dumpDB,
+		},
+		{
+			Name:   "stats",
+			Usage:  "show schema version, number of events and bucket sizes",
+			Flags:  []cli.Flag{storeFlags[0], outputFlag},
+			Action: dbStats,
+		},
+		{
+			Name:  "validate",
+			Usage: "validate every event record against current parser and limits",
+			Description: `Report records that are not valid events, events stored under wrong key, unknown statuses, invalid schedules and options,
+   history of missing events and out of date indexes. With --fix problems are fixed in single transaction: unparsable records
+   are deleted, events are moved to their URI, unknown statuses are reset to pending, invalid events are marked invalid
+   (or deleted, with --delete) and indexes are rebuilt. Exit code is 1, if problems were found and not fixed.`,
+			Flags: []cli.Flag{
+				storeFlags[0],
+				outputFlag,
+				cli.IntFlag{
+					Name:   "limit",
+					Usage:  "minimal allowed cron interval (seconds)",
+					Value:  60,
+					EnvVar: "LIMIT",
+				},
+				cli.BoolFlag{
+					Name:  "fix",
+					Usage: "fix found problems",
+				},
+				cli.BoolFlag{
+					Name:  "delete",
+					Usage: "delete invalid events, instead of marking them invalid",
+				},
+			},
+			Action: validateDB,
+		},
+		{
+			Name:   "compact",
+			Usage:  "rewrite store file without free pages; previous file is kept with '.pre-compact' suffix",
+			Flags:  []cli.Flag{storeFlags[0]},
+			Action: compactDB,
+		},
+	},
+}
+
+// boltFile get BoltDB file from store flag
+func boltFile(c *cli.Context) (string, error) {
+	file, ok := backend.BoltFile(c.String("store"))
+	if !ok {
+		return "", cli.NewExitError("db commands are supported only for BoltDB store", 1)
+	}
+	return file, nil
+}
+
+func dumpDB(c *cli.Context) error {
+	file, err := boltFile(c)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	err = backend.DumpBoltFile(file, c.Bool("history"), func(r backend.BoltRecord) error {
+		if r.Event != nil && !c.Bool("show-secrets") {
+			masked := redact.Event(*r.Event)
+			r.Event = &masked
+		}
+		return enc.Encode(r)
+	})
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	return nil
+}
+
+func dbStats(c *cli.Context) error {
+	file, err := boltFile(c)
+	if err != nil {
+		return err
+	}
+	stats, err := backend.BoltFileStats(file)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	return printOutput(c, stats, func(w io.Writer) {
+		fmt.Fprintf(w, "File:\t%s\nSize:\t%d\nSchema version:\t%d\nEvents:\t%d\n\n", stats.File, stats.Size, stats.Version, stats.Events)
+		fmt.Fprintln(w, "BUCKET\tKEYS\tBUCKETS\tBYTES")
+		for _, b := range stats.Buckets {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\n", b.Name, b.Keys, b.Buckets, b.Bytes)
+		}
+	})
+}
+
+func validateDB(c *cli.Context) error {
+	file, err := boltFile(c)
+	if err != nil {
+		return err
+	}
+	limit := time.Duration(c.Int("limit")) * time.Second
+	// problems are reported in output; keep validation logs, only if asked for
+	if !c.GlobalIsSet("log-level") {
+		log.SetLevel(log.FatalLevel)
+	}
+	report, err := backend.CheckBoltFile(file, backend.CheckOptions{
+		Validate: func(e types.Event) error {
+			// finished events are not scheduled; event end time is checked by server
+			if e.Status == types.StatusExpired || e.Status == types.StatusCompleted {
+				return nil
+			}
+			if _, err := cron.ValidateEvent(e, limit); err != nil && err != cron.ErrExpired {
+				return err
+			}
+			return nil
+		},
+		Fix:    c.Bool("fix"),
+		Delete: c.Bool("delete"),
+	})
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	err = printOutput(c, report, func(w io.Writer) {
+		if len(report.Problems) > 0 {
+			fmt.Fprintln(w, "BUCKET\tKEY\tPROBLEM\tFIX")
+			for _, p := range report.Problems {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", p.Bucket, p.Key, p.Problem, p.Fix)
+			}
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%d events, %d problems", report.Events, len(report.Problems))
+		if report.Fixed && len(report.Problems) > 0 {
+			fmt.Fprint(w, " (fixed)")
+		}
+		fmt.Fprintln(w)
+	})
+	if err != nil {
+		return err
+	}
+	if len(report.Problems) > 0 && !report.Fixed {
+		return cli.NewExitError("", 1)
+	}
+	return nil
+}
+
+func compactDB(c *cli.Context) error {
+	file, err := boltFile(c)
+	if err != nil {
+		return err
+	}
+	before, after, err := backend.CompactBoltFile(file)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	fmt.Printf("compacted %s: %d -> %d bytes\n", file, before, after)
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"time"
+
+	"github.com/codefresh-io/cronus/pkg/hermes"
+	"github.com/urfave/cli"
+)
+
+// hermesClientFlags Hermes HTTP client flags
+var hermesClientFlags = []cli.Flag{
+	cli.DurationFlag{
+		Name:   "hermes-connect-timeout",
+		Usage:  "Hermes TCP connect and TLS handshake timeout (0 - no timeout)",
+		Value:  10 * time.Second,
+		EnvVar: "HERMES_CONNECT_TIMEOUT",
+	},
+	cli.DurationFlag{
+		Name:   "hermes-request-timeout",
+		Usage:  "Hermes API request timeout (0 - no timeout)",
+		Value:  30 * time.Second,
+		EnvVar: "HERMES_REQUEST_TIMEOUT",
+	},
+	cli.StringFlag{
+		Name:   "hermes-ca-file",
+		Usage:  "PEM CA bundle to verify Hermes server certificate, in addition to system CAs",
+		EnvVar: "HERMES_CA_FILE",
+	},
+	cli.StringFlag{
+		Name:   "hermes-cert-file",
+		Usage:  "PEM client certificate for Hermes mTLS",
+		EnvVar: "HERMES_CERT_FILE",
+	},
+	cli.StringFlag{
+		Name:   "hermes-key-file",
+		Usage:  "PEM client certificate key for Hermes mTLS",
+		EnvVar: "HERMES_KEY_FILE",
+	},
+	cli.StringFlag{
+		Name:   "hermes-proxy",
+		Usage:  "Hermes proxy URL (default: HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables)",
+		EnvVar: "HERMES_PROXY",
+	},
+	cli.IntFlag{
+		Name:   "hermes-max-idle-conns",
+		Usage:  "max number of idle Hermes connections, all hosts (0 - unlimited)",
+		Value:  100,
+		EnvVar: "HERMES_MAX_IDLE_CONNS",
+	},
+	cli.IntFlag{
+		Name:   "hermes-max-idle-conns-per-host",
+		Usage:  "max number of idle connections to Hermes host (0 - Go default, 2)",
+		Value:  100,
+		EnvVar: "HERMES_MAX_IDLE_CONNS_PER_HOST",
+	},
+	cli.IntFlag{
+		Name:   "hermes-max-conns",
+		Usage:  "max number of Hermes connections (0 - unlimited)",
+		EnvVar: "HERMES_MAX_CONNS",
+	},
+	cli.DurationFlag{
+		Name:   "hermes-idle-conn-timeout",
+		Usage:  "how long idle Hermes connection is kept open",
+		Value:  90 * time.Second,
+		EnvVar: "HERMES_IDLE_CONN_TIMEOUT",
+	},
+}
+
+// newHermesClientOptions Hermes HTTP client options from flags
+func newHermesClientOptions(c *cli.Context) hermes.ClientOptions {
+	return hermes.ClientOptions{
+		ConnectTimeout:      c.Duration("hermes-connect-timeout"),
+		RequestTimeout:      c.Duration("hermes-request-timeout"),
+		CAFile:              c.String("hermes-ca-file"),
+		CertFile:            c.String("hermes-cert-file"),
+		KeyFile:             c.String("hermes-key-file"),
+		Proxy:               c.String("hermes-proxy"),
+		MaxIdleConns:        c.Int("hermes-max-idle-conns"),
+		MaxIdleConnsPerHost: c.Int("hermes-max-idle-conns-per-host"),
+		MaxConnsPerHost:     c.Int("hermes-max-conns"),
+		IdleConnTimeout:     c.Duration("hermes-idle-conn-timeout"),
+	}
+}
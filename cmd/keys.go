@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/codefresh-io/cronus/pkg/backend"
+	"github.com/codefresh-io/cronus/pkg/keyring"
+	"github.com/urfave/cli"
+)
+
+var keysCommand = cli.Command{
+	Name:  "keys",
+	Usage: "manage event secret encryption keys",
+	Subcommands: []cli.Command{
+		{
+			Name:  "generate",
+			Usage: "generate new encryption key",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "id",
+					Usage: "key ID",
+					Value: "key-1",
+				},
+			},
+			Action: generateKey,
+		},
+		{
+			Name:  "rotate",
+			Usage: "re-encrypt all event secrets with primary encryption key",
+			Description: `Re-encrypt event secrets, encrypted with old keys (or stored as plain text), with primary (first) encryption key.
+   Keep old keys configured (after the primary key) until rotation completes. Stop cronus server before rotating BoltDB store.`,
+			Flags:  storeFlags,
+			Action: rotateKeys,
+		},
+	},
+}
+
+func generateKey(c *cli.Context) error {
+	key, err := keyring.Generate(c.String("id"))
+	if err != nil {
+		return err
+	}
+	fmt.Println(key)
+	return nil
+}
+
+func rotateKeys(c *cli.Context) error {
+	s, err := openStore(c, c.String("store"))
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	encrypted, ok := s.(*backend.EncryptedEventStore)
+	if !ok {
+		return cli.NewExitError("secret encryption keys are not configured", 1)
+	}
+	rotated, err := encrypted.Rotate()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("re-encrypted %d event secrets\n", rotated)
+	return nil
+}
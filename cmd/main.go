@@ -1,18 +1,26 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"expvar"
 	"fmt"
 	newrelic "github.com/newrelic/go-agent"
 	"net/http"
 	"net/url"
 	"os"
+	"os/signal"
+	"sort"
 	"strconv"
-	"strings"
+	"syscall"
+	"time"
 
-	"github.com/codefresh-io/cronus/pkg/backend"
+	"github.com/codefresh-io/cronus/pkg/auth"
 	"github.com/codefresh-io/cronus/pkg/cron"
 	"github.com/codefresh-io/cronus/pkg/cronexp"
+	"github.com/codefresh-io/cronus/pkg/health"
 	"github.com/codefresh-io/cronus/pkg/hermes"
+	"github.com/codefresh-io/cronus/pkg/redact"
 	"github.com/codefresh-io/cronus/pkg/types"
 	"github.com/codefresh-io/cronus/pkg/version"
 	"github.com/codefresh-io/go-infra/pkg/logger"
@@ -24,6 +32,7 @@ import (
 var runner *cron.Runner
 var store types.EventStore
 var cronguru cronexp.Service
+var checker *health.Checker
 
 var nrApp newrelic.Application
 
@@ -32,9 +41,9 @@ type HermesDryRun struct {
 }
 
 // TriggerEvent dry run version
-func (m *HermesDryRun) TriggerEvent(eventURI string, event *hermes.NormalizedEvent) error {
+func (m *HermesDryRun) TriggerEvent(ctx context.Context, eventURI string, event *hermes.NormalizedEvent) error {
 	fmt.Println(eventURI)
-	fmt.Println("\tSecret: ", event.Secret)
+	fmt.Println("\tSecret: ", redact.Secret(event.Secret))
 	fmt.Println("\tVariables:")
 	for k, v := range event.Variables {
 		fmt.Println("\t\t", k, "=", v)
@@ -42,6 +51,11 @@ func (m *HermesDryRun) TriggerEvent(eventURI string, event *hermes.NormalizedEve
 	return nil
 }
 
+// Ping dry run version
+func (m *HermesDryRun) Ping(ctx context.Context) error {
+	return nil
+}
+
 func main() {
 	app := cli.NewApp()
 	app.Name = "cronus"
@@ -61,7 +75,7 @@ Copyright © Codefresh.io`, version.ASCIILogo)
 	app.Commands = []cli.Command{
 		{
 			Name: "server",
-			Flags: []cli.Flag{
+			Flags: append([]cli.Flag{
 				cli.StringFlag{
 					Name:   "hermes",
 					Usage:  "Codefresh Hermes service",
@@ -75,10 +89,13 @@ Copyright © Codefresh.io`, version.ASCIILogo)
 					EnvVar: "HERMES_TOKEN",
 				},
 				cli.StringFlag{
-					Name:   "store",
-					Usage:  "BoltDB storage file",
-					Value:  "/var/tmp/events.db",
-					EnvVar: "STORE_FILE",
+					Name:   "token-file",
+					Usage:  "Codefresh Hermes API token file, re-read when changed (overrides --token)",
+					EnvVar: "HERMES_TOKEN_FILE",
+				},
+				cli.StringFlag{
+					Name:  "token-env",
+					Usage: "environment variable to read Codefresh Hermes API token from on every request (overrides --token)",
 				},
 				cli.IntFlag{
 					Name:   "port",
@@ -92,18 +109,74 @@ Copyright © Codefresh.io`, version.ASCIILogo)
 					EnvVar: "LIMIT",
 					Value:  60,
 				},
+				cli.IntFlag{
+					Name:   "workers",
+					Usage:  "max number of concurrent Hermes triggers",
+					EnvVar: "WORKERS",
+					Value:  50,
+				},
+				cli.Float64Flag{
+					Name:   "rate",
+					Usage:  "max Hermes trigger rate (triggers per second, 0 - unlimited)",
+					EnvVar: "RATE",
+					Value:  100,
+				},
+				cli.IntFlag{
+					Name:   "burst",
+					Usage:  "Hermes trigger rate limit burst",
+					EnvVar: "BURST",
+					Value:  100,
+				},
+				cli.IntFlag{
+					Name:   "queue-size",
+					Usage:  "max number of queued triggers (0 - unlimited)",
+					EnvVar: "QUEUE_SIZE",
+					Value:  10000,
+				},
+				cli.DurationFlag{
+					Name:   "reconcile-interval",
+					Usage:  "how often to reconcile stored events with cron scheduler (0 - never)",
+					EnvVar: "RECONCILE_INTERVAL",
+					Value:  5 * time.Minute,
+				},
+				cli.DurationFlag{
+					Name:   "hermes-probe-interval",
+					Usage:  "how often to probe Hermes service availability",
+					EnvVar: "HERMES_PROBE_INTERVAL",
+					Value:  30 * time.Second,
+				},
+				cli.DurationFlag{
+					Name:   "hermes-probe-max-age",
+					Usage:  "cronus is not ready, if Hermes did not answer probe within this period",
+					EnvVar: "HERMES_PROBE_MAX_AGE",
+					Value:  2 * time.Minute,
+				},
+				cli.DurationFlag{
+					Name:   "shutdown-timeout",
+					Usage:  "max time to wait for running triggers on shutdown",
+					EnvVar: "SHUTDOWN_TIMEOUT",
+					Value:  25 * time.Second,
+				},
 				cli.BoolFlag{
 					Name:  "dry-run",
-					Usage: "do not execute triggers, just log to console",
+					Usage: "do not execute triggers, just log to console; use in-memory store, unless --store is set",
 				},
-			},
+			}, append(append(append(append(storeFlags, authFlags...), backupFlags...), configFlags...), hermesClientFlags...)...),
 			Usage: "start cronus server",
 			Description: `Run Cronus CRON Event Provider server. Cronus generates time-based events and sends normalized event payload to the Codefresh Hermes trigger manager service to invoke associated Codefresh pipelines.
 			
 		Event URI Pattern: cron:codefresh:{{cron-expression}}:{{message}}[:{{account}}]`,
 			Action: runServer,
 		},
+		keysCommand,
+		restoreCommand,
+		exportCommand,
+		importCommand,
+		deleteAccountCommand,
+		dbCommand,
+		cronCommand,
 	}
+	app.Commands = append(app.Commands, clientCommands...)
 	app.Flags = []cli.Flag{
 		cli.StringFlag{
 			Name:   "log-level, l",
@@ -128,27 +201,8 @@ Copyright © Codefresh.io`, version.ASCIILogo)
 }
 
 func before(c *cli.Context) error {
-	// set debug log level
-	switch level := c.GlobalString("log-level"); level {
-	case "debug", "DEBUG":
-		log.SetLevel(log.DebugLevel)
-	case "info", "INFO":
-		log.SetLevel(log.InfoLevel)
-	case "warning", "WARNING":
-		log.SetLevel(log.WarnLevel)
-	case "error", "ERROR":
-		log.SetLevel(log.ErrorLevel)
-	case "fatal", "FATAL":
-		log.SetLevel(log.FatalLevel)
-	case "panic", "PANIC":
-		log.SetLevel(log.PanicLevel)
-	default:
-		log.SetLevel(log.WarnLevel)
-	}
-	// set log formatter to JSON
-	if c.GlobalBool("json") {
-		log.SetFormatter(&logger.CFFormatter{})
-	}
+	// set log level and formatter
+	applyLogSettings(serverSettings{logLevel: c.GlobalString("log-level"), logJSON: c.GlobalBool("json")})
 	// trace function calls
 	traceHook := logger.NewHook()
 	traceHook.Prefix = "codefresh:hermes:"
@@ -156,6 +210,8 @@ func before(c *cli.Context) error {
 	traceHook.FunctionField = logger.FieldNamespace
 	traceHook.AppField = logger.FieldService
 	log.AddHook(traceHook)
+	// mask secrets in log entries
+	log.AddHook(redact.NewHook())
 
 	// set new relic monitoring
 	newRelicLicense := c.GlobalString("new-relic")
@@ -181,25 +237,77 @@ func runServer(c *cli.Context) error {
 	fmt.Println()
 	fmt.Println(version.ASCIILogo)
 
+	// load config file
+	cfg, err := loadConfig(c)
+	if err != nil {
+		log.WithError(err).Error("failed to load config file")
+		return err
+	}
+	settings := resolveSettings(c, cfg)
+	applyLogSettings(settings)
+
+	// setup API authentication
+	authenticator, err := newAuthenticator(c)
+	if err != nil {
+		log.WithError(err).Error("failed to setup API authentication")
+		return err
+	}
+	read := auth.Require(authenticator, auth.ScopeRead)
+	subscribe := auth.Require(authenticator, auth.ScopeSubscribe)
+	admin := auth.Require(authenticator, auth.ScopeAdmin)
+
 	// setup gin router
 	router := gin.New()
 	router.Use(gin.Recovery())
+	// event list route
+	handle(router, "GET", "/cronus/events", read, listEvents)
+	handle(router, "GET", "/events", read, listEvents)
 	// event info route
-	router.GET("/cronus/event/:uri/:secret", gin.Logger(), getEventInfo)
-	router.GET("/event/:uri/:secret", gin.Logger(), getEventInfo)
+	handle(router, "GET", "/cronus/event/:uri", read, getEventInfo)
+	handle(router, "GET", "/event/:uri", read, getEventInfo)
+	handle(router, "GET", "/cronus/event/:uri/:secret", read, getEventInfo)
+	handle(router, "GET", "/event/:uri/:secret", read, getEventInfo)
 	// subscribe/unsubscribe route
-	router.POST("/cronus/event/:uri/:secret/*creds", gin.Logger(), subscribeToEvent)
-	router.POST("/event/:uri/:secret/*creds", gin.Logger(), subscribeToEvent)
-	router.DELETE("/cronus/event/:uri/*creds", gin.Logger(), unsubscribeFromEvent)
-	router.DELETE("/event/:uri/*creds", gin.Logger(), unsubscribeFromEvent)
+	handle(router, "POST", "/cronus/event/:uri/:secret/*creds", subscribe, subscribeToEvent)
+	handle(router, "POST", "/event/:uri/:secret/*creds", subscribe, subscribeToEvent)
+	handle(router, "DELETE", "/cronus/event/:uri/*creds", subscribe, unsubscribeFromEvent)
+	handle(router, "DELETE", "/event/:uri/*creds", subscribe, unsubscribeFromEvent)
+	// pause/resume routes
+	handle(router, "POST", "/cronus/pause/:uri", subscribe, pauseEvent)
+	handle(router, "POST", "/pause/:uri", subscribe, pauseEvent)
+	handle(router, "POST", "/cronus/resume/:uri", subscribe, resumeEvent)
+	handle(router, "POST", "/resume/:uri", subscribe, resumeEvent)
+	// manual trigger route
+	handle(router, "POST", "/cronus/trigger/:uri", subscribe, triggerEvent)
+	handle(router, "POST", "/trigger/:uri", subscribe, triggerEvent)
+	// account offboarding route
+	handle(router, "DELETE", "/cronus/accounts/:account/events", admin, deleteAccountEvents)
+	handle(router, "DELETE", "/accounts/:account/events", admin, deleteAccountEvents)
+	// event fire history route
+	handle(router, "GET", "/cronus/history/:uri", read, getEventHistory)
+	handle(router, "GET", "/history/:uri", read, getEventHistory)
+	// cron expression explain route
+	handle(router, "GET", "/cronus/cron/explain", read, explainExpression)
+	handle(router, "GET", "/cron/explain", read, explainExpression)
 	// status routes
 	router.GET("/cronus/health", getHealth)
 	router.GET("/health", getHealth)
+	router.GET("/cronus/ready", getReadiness)
+	router.GET("/ready", getReadiness)
 	router.GET("/cronus/version", getVersion)
 	router.GET("/version", getVersion)
 	router.GET("/cronus/ping", ping)
 	router.GET("/ping", ping)
-	router.GET("/backup", backupDB)
+	handle(router, "GET", "/backup", admin, backupDB)
+	handle(router, "POST", "/restore", admin, restoreDB)
+	handle(router, "GET", "/export", admin, exportEvents)
+	handle(router, "GET", "/backups", admin, listBackups)
+	handle(router, "POST", "/backups", admin, createBackup)
+	handle(router, "POST", "/import", admin, importEvents)
+	router.GET("/debug/vars", admin, gin.WrapH(expvar.Handler()))
+	// admin routes
+	handle(router, "GET", "/admin/reconcile", admin, getReconcileReport)
+	handle(router, "POST", "/admin/reconcile", admin, reconcile)
 	router.GET("/", getVersion)
 
 	// access hermes
@@ -207,26 +315,51 @@ func runServer(c *cli.Context) error {
 	if c.Bool("dry-run") {
 		hermesSvc = &HermesDryRun{}
 	} else {
-		// add http protocol, if missing
-		hermesSvcName := c.String("hermes")
-		if !strings.HasPrefix(hermesSvcName, "http://") {
-			hermesSvcName = "http://" + hermesSvcName
+		creds, err := newHermesCredentials(c, settings)
+		if err != nil {
+			log.WithError(err).Error("failed to setup Hermes API credentials")
+			return err
+		}
+		opts := newHermesClientOptions(c)
+		hermesSvc, err = hermes.NewHermesEndpointWithOptions(hermesURL(settings.hermesURL, opts.TLS()), creds, opts)
+		if err != nil {
+			log.WithError(err).Error("failed to setup Hermes client")
+			return err
 		}
-		hermesSvc = hermes.NewHermesEndpoint(hermesSvcName, c.String("token"))
 	}
-	// access boltdb
-	var err error
-	log.WithField("store", store).Debug("initializing BoltDB")
-	store, err = backend.NewBoltEventStore(c.String("store"))
+	// access event store
+	log.Debug("initializing event store")
+	storeURL := c.String("store")
+	if c.Bool("dry-run") && !c.IsSet("store") {
+		// dry run does not need persistent store
+		storeURL = "memory://"
+	}
+	store, err = openStore(c, storeURL)
 	if err != nil {
-		log.WithError(err).Error("failed to start BoltDB")
+		log.WithError(err).Error("failed to open event store")
 		return err
 	}
 	// start cron runner
 	log.Debug("starting cron job runner")
-	runner = cron.NewCronRunner(store, hermesSvc, c.Int64("limit"))
+	dispatcher := cron.NewDispatcher(settings.workers, settings.rate, settings.burst, settings.queueSize)
+	runner = cron.NewCronRunner(store, hermesSvc, dispatcher, settings.limit)
+	if interval := c.Duration("reconcile-interval"); interval > 0 {
+		go runner.RunReconcile(interval)
+	}
+	// setup scheduled backups
+	if backups, err = newBackupManager(c, store); err != nil {
+		log.WithError(err).Error("failed to setup backup storage")
+		return err
+	}
 	// create cronguru service for cron expression description
 	cronguru = cronexp.NewCronExpression()
+	// setup readiness checks
+	stopBackground := make(chan struct{})
+	hermesProber := health.NewProber(hermesSvc.Ping, c.Duration("hermes-probe-interval"), 5*time.Second)
+	go hermesProber.Run(stopBackground)
+	runBackups(c.Duration("backup-interval"), stopBackground)
+	watchConfig(c, settings, hermesSvc, dispatcher, stopBackground)
+	checker = newReadinessChecker(hermesProber, c.Duration("hermes-probe-max-age"))
 
 	// set server port
 	port := c.Int("port")
@@ -234,7 +367,68 @@ func runServer(c *cli.Context) error {
 	// use RawPath: the url.RawPath will be used to find parameters
 	router.UseRawPath = true
 	// run server
-	return router.Run(fmt.Sprintf(":%d", port))
+	server := &http.Server{Addr: fmt.Sprintf(":%d", port), Handler: router}
+	errc := make(chan error, 1)
+	go func() {
+		errc <- server.ListenAndServe()
+	}()
+	// wait for termination signal
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGTERM, syscall.SIGINT)
+	select {
+	case err = <-errc:
+		log.WithError(err).Error("cronus server failed")
+	case sig := <-sigc:
+		log.WithField("signal", sig).Info("shutting down cronus server")
+		err = nil
+	}
+	close(stopBackground)
+	return shutdown(server, c.Duration("shutdown-timeout"), err)
+}
+
+// newReadinessChecker setup readiness checks: store, runner, scheduler and Hermes
+func newReadinessChecker(hermesProber *health.Prober, maxAge time.Duration) *health.Checker {
+	checker := health.NewChecker()
+	checker.Add("store", func() error {
+		_, err := store.GetDBStats()
+		return err
+	})
+	checker.Add("runner", func() error {
+		if !runner.Initialized() {
+			return errors.New("cron runner failed to load stored events")
+		}
+		return nil
+	})
+	checker.Add("scheduler", func() error {
+		if !runner.Running() {
+			return errors.New("cron scheduler is not running")
+		}
+		return nil
+	})
+	checker.Add("hermes", func() error {
+		return hermesProber.Check(maxAge)
+	})
+	return checker
+}
+
+// shutdown gracefully stop API server and cron runner, and close store
+func shutdown(server *http.Server, timeout time.Duration, err error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	// stop accepting API requests
+	if serr := server.Shutdown(ctx); serr != nil {
+		log.WithError(serr).Error("failed to shutdown API server")
+	}
+	// stop scheduler, wait for running triggers and flush history
+	if rerr := runner.Stop(ctx); rerr != nil {
+		log.WithError(rerr).Error("failed to stop cron runner gracefully")
+	}
+	// close store
+	if cerr := store.Close(); cerr != nil {
+		log.WithError(cerr).Error("failed to close store")
+	}
+	log.Info("cronus server stopped")
+	return err
 }
 
 func getParam(c *gin.Context, name string) string {
@@ -259,7 +453,92 @@ func getEventInfo(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, event)
+	c.JSON(http.StatusOK, eventResponse(c, *event))
+}
+
+// listEvents list stored events, filtered by account, status and next fire time (due within duration)
+func listEvents(c *gin.Context) {
+	account, status := c.Query("account"), c.Query("status")
+	var all []types.Event
+	var err error
+	switch {
+	case c.Query("due") != "":
+		due, perr := time.ParseDuration(c.Query("due"))
+		if perr != nil || due < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bad due duration"})
+			return
+		}
+		now := time.Now()
+		all, err = store.GetDueEvents(now, now.Add(due))
+	case account != "":
+		all, err = store.GetAccountEvents(account)
+	case status != "":
+		all, err = store.GetStatusEvents(status)
+	default:
+		all, err = store.GetAllEvents()
+	}
+	if err != nil {
+		log.WithError(err).Error("failed to list events")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	events := make([]types.Event, 0, len(all))
+	for _, e := range all {
+		if (account != "" && e.Account != account) || (status != "" && e.Status != status) {
+			continue
+		}
+		events = append(events, eventResponse(c, e))
+	}
+	// due events are ordered by next fire time
+	if c.Query("due") == "" {
+		sort.Slice(events, func(i, j int) bool {
+			return types.GetURI(events[i]) < types.GetURI(events[j])
+		})
+	}
+	c.JSON(http.StatusOK, events)
+}
+
+// triggerEvent trigger stored event now, following its concurrency policy
+func triggerEvent(c *gin.Context) {
+	uri := getParam(c, "uri")
+	log.WithField("uri", uri).Debug("trigger event")
+	event, err := store.GetEvent(uri)
+	if err == types.ErrEventNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	switch err = runner.TriggerEvent(*event); err {
+	case nil:
+		c.JSON(http.StatusOK, gin.H{"uri": uri, "result": types.ResultTriggered})
+	case cron.ErrSkipped, cron.ErrExpired:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case cron.ErrStopped, cron.ErrQueueFull, cron.ErrDispatcherStopped:
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		log.WithError(err).Error("failed to trigger event")
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	}
+}
+
+func getEventHistory(c *gin.Context) {
+	uri := getParam(c, "uri")
+	log.WithField("uri", uri).Debug("get event history")
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad limit value"})
+		return
+	}
+	records, err := runner.GetHistory(uri, limit)
+	if err != nil {
+		log.WithError(err).Error("failed to get event history")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, records)
 }
 
 func subscribeToEvent(c *gin.Context) {
@@ -273,6 +552,14 @@ func subscribeToEvent(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	// optional jitter window
+	event.Jitter = c.Query("jitter")
+	// optional concurrency policy
+	event.ConcurrencyPolicy = c.Query("concurrency")
+	// optional end time
+	event.Until = c.Query("until")
+	// optional cron expression dialect
+	event.Dialect = c.Query("dialect")
 	// add cron job
 	err = runner.AddCronJob(*event)
 	if err != nil {
@@ -280,8 +567,10 @@ func subscribeToEvent(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	// event is scheduled
+	event.Status = types.StatusActive
 
-	c.JSON(http.StatusOK, event)
+	c.JSON(http.StatusOK, eventResponse(c, *event))
 }
 
 func unsubscribeFromEvent(c *gin.Context) {
@@ -297,10 +586,69 @@ func unsubscribeFromEvent(c *gin.Context) {
 	c.Status(http.StatusOK)
 }
 
+func pauseEvent(c *gin.Context) {
+	uri := getParam(c, "uri")
+	log.WithField("uri", uri).Debug("pause event")
+	err := runner.PauseCronJob(uri)
+	if err == types.ErrEventNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.WithError(err).Error("failed to pause cron job")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+func resumeEvent(c *gin.Context) {
+	uri := getParam(c, "uri")
+	log.WithField("uri", uri).Debug("resume event")
+	err := runner.ResumeCronJob(uri)
+	if err == types.ErrEventNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.WithError(err).Error("failed to resume cron job")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
 func getHealth(c *gin.Context) {
 	c.Status(http.StatusOK)
 }
 
+func getReconcileReport(c *gin.Context) {
+	report := runner.LastReconcile()
+	if report == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "reconciliation did not run yet"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+func reconcile(c *gin.Context) {
+	report := runner.Reconcile()
+	if report.Error != "" {
+		c.JSON(http.StatusInternalServerError, report)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+func getReadiness(c *gin.Context) {
+	report := checker.Run()
+	status := http.StatusOK
+	if !report.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
+
 func getVersion(c *gin.Context) {
 	c.String(http.StatusOK, version.HumanVersion)
 }
@@ -313,7 +661,7 @@ func ping(c *gin.Context) {
 func backupDB(c *gin.Context) {
 	size, err := store.BackupDB(c.Writer)
 	if err != nil {
-		log.WithError(err).Error("failed to backup BoltDB")
+		log.WithError(err).Error("failed to backup event store")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
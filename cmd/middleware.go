@@ -0,0 +1,39 @@
+package main
+
+import (
+	"time"
+
+	"github.com/codefresh-io/cronus/pkg/auth"
+	"github.com/codefresh-io/cronus/pkg/redact"
+	"github.com/codefresh-io/cronus/pkg/types"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// handle register API route with request logger
+func handle(router gin.IRoutes, method, route string, handlers ...gin.HandlerFunc) {
+	router.Handle(method, route, append([]gin.HandlerFunc{requestLogger(route)}, handlers...)...)
+}
+
+// requestLogger log API requests of route, with secret path parameters masked
+func requestLogger(route string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		log.WithFields(log.Fields{
+			"method":  c.Request.Method,
+			"path":    redact.Path(c.Request.URL.EscapedPath(), route, "secret", "creds"),
+			"status":  c.Writer.Status(),
+			"latency": time.Since(start),
+			"client":  c.ClientIP(),
+		}).Info("api request")
+	}
+}
+
+// eventResponse event for API response: secret is masked, unless caller has admin scope
+func eventResponse(c *gin.Context, event types.Event) types.Event {
+	if auth.HasScope(c, auth.ScopeAdmin) {
+		return event
+	}
+	return redact.Event(event)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/codefresh-io/cronus/pkg/backend"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+var restoreCommand = cli.Command{
+	Name:      "restore",
+	Usage:     "restore BoltDB event store from backup",
+	ArgsUsage: "BACKUP_FILE (or '-' for stdin)",
+	Description: `Validate BoltDB backup (as returned by 'GET /backup') and replace event store file with it.
+   Stop cronus server before restoring, or use 'POST /restore' API of running server instead. Previous store file is kept with '.pre-restore' suffix.`,
+	Flags: []cli.Flag{
+		storeFlags[0],
+		cli.BoolFlag{
+			Name:  "validate",
+			Usage: "validate backup only, do not restore",
+		},
+	},
+	Action: restoreFile,
+}
+
+func restoreFile(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.NewExitError("backup file is required", 1)
+	}
+	file, ok := backend.BoltFile(c.String("store"))
+	if !ok {
+		return cli.NewExitError("restore is supported only for BoltDB store", 1)
+	}
+	var src io.Reader = os.Stdin
+	if name := c.Args().First(); name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		src = f
+		if c.Bool("validate") {
+			count, err := backend.ValidateBoltFile(name)
+			if err != nil {
+				return cli.NewExitError(err.Error(), 1)
+			}
+			fmt.Printf("backup is valid: %d events\n", count)
+			return nil
+		}
+	} else if c.Bool("validate") {
+		return cli.NewExitError("validate requires backup file", 1)
+	}
+	count, err := backend.RestoreBoltFile(file, src)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	fmt.Printf("restored %d events into %s\n", count, file)
+	return nil
+}
+
+// restoreDB restore event store from uploaded BoltDB backup and reschedule restored events
+func restoreDB(c *gin.Context) {
+	restorer, ok := store.(backend.Restorer)
+	if !ok {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": backend.ErrRestoreNotSupported.Error()})
+		return
+	}
+	count, err := restorer.RestoreDB(c.Request.Body)
+	if err == backend.ErrRestoreNotSupported {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, backend.ErrInvalidBackup) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.WithError(err).Error("failed to restore event store")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	report := runner.Reload()
+	c.JSON(http.StatusOK, gin.H{"events": count, "reload": report})
+}
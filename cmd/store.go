@@ -0,0 +1,48 @@
+package main
+
+import (
+	"github.com/codefresh-io/cronus/pkg/backend"
+	"github.com/codefresh-io/cronus/pkg/keyring"
+	"github.com/codefresh-io/cronus/pkg/types"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// storeFlags event store flags, shared by commands that open event store
+var storeFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:   "store",
+		Usage:  "event store: BoltDB file or store URL (postgres://, mysql://, sqlite://, memory://)",
+		Value:  "/var/tmp/events.db",
+		EnvVar: "STORE_FILE",
+	},
+	cli.StringFlag{
+		Name:   "secret-key-file",
+		Usage:  "event secret encryption key file: one 'key-id:base64-key' per line, first key encrypts",
+		EnvVar: "SECRET_KEY_FILE",
+	},
+	cli.StringFlag{
+		Name:   "secret-keys",
+		Usage:  "comma separated event secret encryption keys ('key-id:base64-key'), used before key file keys",
+		EnvVar: "SECRET_KEYS",
+	},
+}
+
+// openStore open event store; event secrets are encrypted, if encryption keys are configured
+func openStore(c *cli.Context, storeURL string) (types.EventStore, error) {
+	keys, err := keyring.Load(c.String("secret-key-file"), c.String("secret-keys"))
+	if err != nil {
+		log.WithError(err).Error("failed to load secret encryption keys")
+		return nil, err
+	}
+	s, err := backend.NewEventStore(storeURL)
+	if err != nil {
+		return nil, err
+	}
+	if keys == nil {
+		log.Warn("secret encryption keys are not configured: event secrets are stored as plain text")
+		return s, nil
+	}
+	log.WithField("key-id", keys.Primary()).Debug("encrypting event secrets")
+	return backend.NewEncryptedEventStore(s, keys), nil
+}
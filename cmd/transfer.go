@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/codefresh-io/cronus/pkg/client"
+	"github.com/codefresh-io/cronus/pkg/transfer"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// clientFlags cronus API client flags
+var clientFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:   "server",
+		Usage:  "cronus server URL",
+		Value:  "http://localhost:10002",
+		EnvVar: "CRONUS_URL",
+	},
+	cli.StringFlag{
+		Name:   "api-token",
+		Usage:  "cronus API bearer token",
+		EnvVar: "CRONUS_API_TOKEN",
+	},
+}
+
+var exportCommand = cli.Command{
+	Name:  "export",
+	Usage: "export events from cronus server into portable JSON document",
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "account",
+			Usage: "export events of account only",
+		},
+		cli.StringFlag{
+			Name:  "format",
+			Usage: "document format: json or ndjson",
+			Value: transfer.FormatJSON,
+		},
+		cli.StringFlag{
+			Name:  "output, o",
+			Usage: "output file (default: stdout)",
+		},
+	}, clientFlags...),
+	Action: exportToFile,
+}
+
+var importCommand = cli.Command{
+	Name:      "import",
+	Usage:     "import events from portable JSON document into cronus server",
+	ArgsUsage: "FILE (or '-' for stdin)",
+	Description: `Import events from export document. Events are validated as new subscriptions; invalid events are reported and skipped.
+   Modes: merge - add new and update changed events; replace - merge and remove events (of exported account) missing from document.`,
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "mode",
+			Usage: "import mode: merge or replace",
+			Value: transfer.ModeMerge,
+		},
+		cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "report changes, do not apply them",
+		},
+		cli.StringFlag{
+			Name:  "format",
+			Usage: "document format: json or ndjson (default: by file extension)",
+		},
+	}, clientFlags...),
+	Action: importFromFile,
+}
+
+func newClient(c *cli.Context) *client.Client {
+	return client.NewClient(c.String("server"), c.String("api-token"))
+}
+
+func exportToFile(c *cli.Context) error {
+	var w io.Writer = os.Stdout
+	if name := c.String("output"); name != "" {
+		f, err := os.Create(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := newClient(c).Export(c.String("account"), c.String("format"), w); err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	return nil
+}
+
+func importFromFile(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.NewExitError("document file is required", 1)
+	}
+	name := c.Args().First()
+	format := c.String("format")
+	if format == "" {
+		format = transfer.FormatJSON
+		if strings.HasSuffix(name, ".ndjson") {
+			format = transfer.FormatNDJSON
+		}
+	}
+	var r io.Reader = os.Stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	report, err := newClient(c).Import(r, format, c.String("mode"), c.Bool("dry-run"))
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// requestFormat get export format from format query parameter or request content type
+func requestFormat(c *gin.Context) string {
+	if format := c.Query("format"); format != "" {
+		return format
+	}
+	if strings.HasPrefix(c.ContentType(), transfer.ContentType(transfer.FormatNDJSON)) {
+		return transfer.FormatNDJSON
+	}
+	return transfer.FormatJSON
+}
+
+func exportEvents(c *gin.Context) {
+	format := requestFormat(c)
+	if err := transfer.ValidFormat(format); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	doc, err := runner.Export(c.Query("account"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Type", transfer.ContentType(format))
+	c.Header("Content-Disposition", `attachment; filename="events.`+format+`"`)
+	c.Status(http.StatusOK)
+	if err = transfer.Encode(c.Writer, doc, format); err != nil {
+		log.WithError(err).Error("failed to write export document")
+	}
+}
+
+func importEvents(c *gin.Context) {
+	mode := c.DefaultQuery("mode", transfer.ModeMerge)
+	if err := transfer.ValidMode(mode); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	doc, err := transfer.Decode(c.Request.Body, requestFormat(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	report, err := runner.Import(doc, mode, c.Query("dry-run") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -9,10 +9,13 @@ require (
 	github.com/dghubble/sling v1.1.0
 	github.com/gin-contrib/sse v0.0.0-20170109093832-22d885f9ecc7 // indirect
 	github.com/gin-gonic/gin v1.1.5-0.20170702092826-d459835d2b07
+	github.com/go-sql-driver/mysql v1.6.0
 	github.com/golang/protobuf v1.0.0 // indirect
 	github.com/google/go-querystring v0.0.0-20170111101155-53e6ce116135 // indirect
+	github.com/lib/pq v1.10.9
 	github.com/mattn/go-isatty v0.0.3 // indirect
-	github.com/newrelic/go-agent v1.11.0 // indirect
+	github.com/mattn/go-sqlite3 v1.14.6
+	github.com/newrelic/go-agent v1.11.0
 	github.com/niemeyer/pretty v0.0.0-20200227124842-a10e7caefd8e // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/robfig/cron/v3 v3.0.0
@@ -27,5 +30,5 @@ require (
 	gopkg.in/go-playground/assert.v1 v1.2.1 // indirect
 	gopkg.in/go-playground/validator.v8 v8.18.2 // indirect
 	gopkg.in/robfig/cron.v2 v2.0.0-20150107220207-be2e0b0deed5
-	gopkg.in/yaml.v2 v2.0.0 // indirect
+	gopkg.in/yaml.v2 v2.0.0
 )
@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type (
+	// Principal authenticated API caller
+	Principal struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+	}
+
+	// Authenticator authenticate API request; returns ErrNoCredentials if request has no credentials
+	// this authenticator can verify
+	Authenticator interface {
+		Authenticate(r *http.Request) (*Principal, error)
+	}
+
+	// chain try authenticators in order
+	chain []Authenticator
+)
+
+// API scopes
+const (
+	// ScopeRead get events and fire history
+	ScopeRead = "read"
+	// ScopeSubscribe subscribe, unsubscribe, pause and resume events
+	ScopeSubscribe = "subscribe"
+	// ScopeAdmin backup, restore and other admin operations; implies all other scopes
+	ScopeAdmin = "admin"
+)
+
+// principalKey gin context key for authenticated principal
+const principalKey = "principal"
+
+// ErrNoCredentials request has no credentials for authenticator
+var ErrNoCredentials = errors.New("missing credentials")
+
+// ErrUnauthorized invalid credentials
+var ErrUnauthorized = errors.New("invalid credentials")
+
+// Chain create authenticator that tries authenticators in order; nil if no authenticators
+func Chain(authenticators ...Authenticator) Authenticator {
+	var c chain
+	for _, a := range authenticators {
+		if a != nil {
+			c = append(c, a)
+		}
+	}
+	if len(c) == 0 {
+		return nil
+	}
+	return c
+}
+
+// Authenticate authenticate with first authenticator, that finds request credentials
+func (c chain) Authenticate(r *http.Request) (*Principal, error) {
+	for _, a := range c {
+		p, err := a.Authenticate(r)
+		if err == ErrNoCredentials {
+			continue
+		}
+		return p, err
+	}
+	return nil, ErrNoCredentials
+}
+
+// HasScope check if principal has scope (admin has all scopes)
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseScopes parse comma (or space) separated scope list
+func ParseScopes(scopes string) []string {
+	return strings.FieldsFunc(scopes, func(r rune) bool { return r == ',' || r == ' ' })
+}
+
+// Require gin middleware: authenticate request and require scope; nil authenticator disables authentication
+func Require(a Authenticator, scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if a == nil {
+			c.Next()
+			return
+		}
+		p, err := a.Authenticate(c.Request)
+		if err != nil {
+			log.WithError(err).WithField("path", c.Request.URL.EscapedPath()).Warn("unauthenticated API request")
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if !p.HasScope(scope) {
+			log.WithFields(log.Fields{
+				"principal": p.Name,
+				"scope":     scope,
+			}).Warn("API request without required scope")
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "missing '" + scope + "' scope"})
+			return
+		}
+		c.Set(principalKey, p)
+		c.Next()
+	}
+}
+
+// HasScope check if authenticated caller has scope
+func HasScope(c *gin.Context, scope string) bool {
+	p, ok := c.Get(principalKey)
+	return ok && p.(*Principal).HasScope(scope)
+}
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+const testTokens = `
+# name scopes token
+hermes read,subscribe hermes-token
+ops    admin          ops-token
+`
+
+func TestTokenAuthenticator(t *testing.T) {
+	a, err := parseTokens(strings.NewReader(testTokens))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		header  string
+		want    *Principal
+		wantErr error
+	}{
+		{
+			name:   "valid token",
+			header: "Bearer hermes-token",
+			want:   &Principal{Name: "hermes", Scopes: []string{ScopeRead, ScopeSubscribe}},
+		},
+		{
+			name:    "invalid token",
+			header:  "Bearer other-token",
+			wantErr: ErrUnauthorized,
+		},
+		{
+			name:    "no token",
+			wantErr: ErrNoCredentials,
+		},
+		{
+			name:    "JWT",
+			header:  "Bearer aaa.bbb.ccc",
+			wantErr: ErrNoCredentials,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/event/uri", nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			got, err := a.Authenticate(r)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+	// bad file format
+	_, err = parseTokens(strings.NewReader("hermes hermes-token"))
+	assert.Error(t, err)
+}
+
+func TestRequire(t *testing.T) {
+	tokens, err := parseTokens(strings.NewReader(testTokens))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name          string
+		authenticator Authenticator
+		scope         string
+		token         string
+		want          int
+		wantAdmin     bool
+	}{
+		{name: "authentication disabled", scope: ScopeAdmin, want: http.StatusOK},
+		{name: "allowed", authenticator: tokens, scope: ScopeSubscribe, token: "hermes-token", want: http.StatusOK},
+		{name: "admin has all scopes", authenticator: tokens, scope: ScopeRead, token: "ops-token", want: http.StatusOK, wantAdmin: true},
+		{name: "missing scope", authenticator: tokens, scope: ScopeAdmin, token: "hermes-token", want: http.StatusForbidden},
+		{name: "invalid token", authenticator: tokens, scope: ScopeRead, token: "other-token", want: http.StatusUnauthorized},
+		{name: "no credentials", authenticator: tokens, scope: ScopeRead, want: http.StatusUnauthorized},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			var admin bool
+			router.GET("/test", Require(tt.authenticator, tt.scope), func(c *gin.Context) {
+				admin = HasScope(c, ScopeAdmin)
+				c.Status(http.StatusOK)
+			})
+			r := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if tt.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			assert.Equal(t, tt.want, w.Code)
+			assert.Equal(t, tt.wantAdmin, admin)
+		})
+	}
+}
+
+func TestChain(t *testing.T) {
+	assert.Nil(t, Chain(nil, nil))
+	tokens, _ := parseTokens(strings.NewReader(testTokens))
+	a := Chain(nil, tokens)
+	r := httptest.NewRequest(http.MethodGet, "/test", nil)
+	_, err := a.Authenticate(r)
+	assert.Equal(t, ErrNoCredentials, err)
+	r.Header.Set("Authorization", "Bearer ops-token")
+	p, err := a.Authenticate(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "ops", p.Name)
+}
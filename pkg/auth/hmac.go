@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// HMACAuthenticator HMAC-SHA256 signed request authenticator
+	HMACAuthenticator struct {
+		keys map[string]hmacKey
+		skew time.Duration
+		now  func() time.Time
+	}
+
+	hmacKey struct {
+		secret    []byte
+		principal Principal
+	}
+)
+
+// HMACScheme authorization scheme: 'Authorization: HMAC-SHA256 key={key-id},signature={base64-signature}'
+const HMACScheme = "HMAC-SHA256"
+
+// DateHeader signed request time header (Unix seconds)
+const DateHeader = "X-Cronus-Date"
+
+// defaultSkew max allowed difference between request time and server time
+const defaultSkew = 5 * time.Minute
+
+// NewHMACAuthenticator load HMAC keys from file: one '{key-id} {scopes} {base64-secret}' per line
+func NewHMACAuthenticator(file string) (*HMACAuthenticator, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HMAC keys file: %v", err)
+	}
+	defer f.Close()
+	a := &HMACAuthenticator{keys: make(map[string]hmacKey), skew: defaultSkew, now: time.Now}
+	err = readEntries(f, func(name string, scopes []string, secret string) error {
+		key, err := base64.StdEncoding.DecodeString(secret)
+		if err != nil {
+			return fmt.Errorf("bad HMAC secret: %v", err)
+		}
+		a.keys[name] = hmacKey{secret: key, principal: Principal{Name: name, Scopes: scopes}}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// StringToSign request string to sign: method, escaped path with query, date and hex SHA256 of body,
+// separated by new line
+func StringToSign(method, path, date string, body []byte) string {
+	sum := sha256.Sum256(body)
+	return strings.Join([]string{method, path, date, hex.EncodeToString(sum[:])}, "\n")
+}
+
+// Sign sign request with HMAC key; request body is read and restored
+func Sign(r *http.Request, keyID string, secret []byte, now time.Time) error {
+	body, err := readBody(r)
+	if err != nil {
+		return err
+	}
+	date := strconv.FormatInt(now.Unix(), 10)
+	r.Header.Set(DateHeader, date)
+	signature := sign(secret, StringToSign(r.Method, r.URL.RequestURI(), date, body))
+	r.Header.Set("Authorization", fmt.Sprintf("%s key=%s,signature=%s", HMACScheme, keyID, signature))
+	return nil
+}
+
+// Authenticate verify request signature
+func (a *HMACAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, HMACScheme+" ") {
+		return nil, ErrNoCredentials
+	}
+	params := make(map[string]string)
+	for _, p := range strings.Split(strings.TrimPrefix(header, HMACScheme+" "), ",") {
+		kv := strings.SplitN(strings.TrimSpace(p), "=", 2)
+		if len(kv) == 2 {
+			params[kv[0]] = kv[1]
+		}
+	}
+	key, ok := a.keys[params["key"]]
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+	// check request time
+	date := r.Header.Get(DateHeader)
+	sec, err := strconv.ParseInt(date, 10, 64)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	if d := a.now().Sub(time.Unix(sec, 0)); d > a.skew || d < -a.skew {
+		return nil, fmt.Errorf("%v: request time is out of allowed range", ErrUnauthorized)
+	}
+	body, err := readBody(r)
+	if err != nil {
+		return nil, err
+	}
+	expected := sign(key.secret, StringToSign(r.Method, r.URL.RequestURI(), date, body))
+	if !hmac.Equal([]byte(expected), []byte(params["signature"])) {
+		return nil, ErrUnauthorized
+	}
+	p := key.principal
+	return &p, nil
+}
+
+func sign(secret []byte, s string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(s))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// readBody read request body and restore it for next reader
+func readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHMACAuthenticator(t *testing.T) {
+	secret := []byte("hmac-secret")
+	f, err := ioutil.TempFile("", "hmac")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("ci subscribe " + base64.StdEncoding.EncodeToString(secret) + "\n")
+	f.Close()
+	a, err := NewHMACAuthenticator(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1500000000, 0)
+	a.now = func() time.Time { return now }
+	newRequest := func() *http.Request {
+		return httptest.NewRequest(http.MethodPost, "/event/cron%3Acodefresh%3A0%200%204%20%2A%20%2A%20%2A%3Ahello/1234?until=2019", bytes.NewBufferString(`{"a":1}`))
+	}
+	tests := []struct {
+		name    string
+		request func() *http.Request
+		wantErr bool
+	}{
+		{
+			name: "valid signature",
+			request: func() *http.Request {
+				r := newRequest()
+				Sign(r, "ci", secret, now)
+				return r
+			},
+		},
+		{
+			name: "wrong secret",
+			request: func() *http.Request {
+				r := newRequest()
+				Sign(r, "ci", []byte("other"), now)
+				return r
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown key",
+			request: func() *http.Request {
+				r := newRequest()
+				Sign(r, "other", secret, now)
+				return r
+			},
+			wantErr: true,
+		},
+		{
+			name: "expired request",
+			request: func() *http.Request {
+				r := newRequest()
+				Sign(r, "ci", secret, now.Add(-10*time.Minute))
+				return r
+			},
+			wantErr: true,
+		},
+		{
+			name: "tampered body",
+			request: func() *http.Request {
+				r := newRequest()
+				Sign(r, "ci", secret, now)
+				r.Body = ioutil.NopCloser(bytes.NewBufferString(`{"a":2}`))
+				return r
+			},
+			wantErr: true,
+		},
+		{
+			name: "tampered path",
+			request: func() *http.Request {
+				r := newRequest()
+				Sign(r, "ci", secret, now)
+				r.URL.RawQuery = "until=2099"
+				return r
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := tt.request()
+			p, err := a.Authenticate(r)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("HMACAuthenticator.Authenticate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil {
+				assert.Equal(t, &Principal{Name: "ci", Scopes: []string{ScopeSubscribe}}, p)
+				// body is available for handler
+				body, _ := ioutil.ReadAll(r.Body)
+				assert.Equal(t, `{"a":1}`, string(body))
+			}
+		})
+	}
+	// other scheme
+	_, err = a.Authenticate(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, ErrNoCredentials, err)
+}
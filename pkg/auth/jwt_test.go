@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func encodeSegment(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	signing := encodeSegment(map[string]string{"alg": "RS256", "kid": kid}) + "." + encodeSegment(claims)
+	digest := sha256.Sum256([]byte(signing))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signing + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func signES256(t *testing.T, key *ecdsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	signing := encodeSegment(map[string]string{"alg": "ES256", "kid": kid}) + "." + encodeSegment(claims)
+	digest := sha256.Sum256([]byte(signing))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := make([]byte, 64)
+	rb, sb := r.Bytes(), s.Bytes()
+	copy(sig[32-len(rb):32], rb)
+	copy(sig[64-len(sb):], sb)
+	return signing + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwks := map[string]interface{}{
+		"keys": []map[string]string{
+			{"kid": "rsa", "kty": "RSA", "use": "sig", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+			{"kid": "ec", "kty": "EC", "crv": "P-256", "x": b64(ecKey.X.Bytes()), "y": b64(ecKey.Y.Bytes())},
+		},
+	}
+	f, err := ioutil.TempFile("", "jwks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	json.NewEncoder(f).Encode(jwks)
+	f.Close()
+	a, err := NewJWTAuthenticator(f.Name(), "https://issuer", "cronus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1500000000, 0)
+	a.now = func() time.Time { return now }
+	claims := func(override map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"sub":   "hermes",
+			"iss":   "https://issuer",
+			"aud":   []string{"cronus", "other"},
+			"exp":   now.Add(time.Hour).Unix(),
+			"scope": "read subscribe",
+		}
+		for k, v := range override {
+			c[k] = v
+		}
+		return c
+	}
+	tests := []struct {
+		name    string
+		token   string
+		want    *Principal
+		wantErr bool
+	}{
+		{
+			name:  "RS256 token",
+			token: signRS256(t, rsaKey, "rsa", claims(nil)),
+			want:  &Principal{Name: "hermes", Scopes: []string{ScopeRead, ScopeSubscribe}},
+		},
+		{
+			name:  "ES256 token with scopes list",
+			token: signES256(t, ecKey, "ec", claims(map[string]interface{}{"scope": "", "scopes": []string{ScopeAdmin}, "aud": "cronus"})),
+			want:  &Principal{Name: "hermes", Scopes: []string{ScopeAdmin}},
+		},
+		{
+			name:    "wrong signing key",
+			token:   signRS256(t, otherKey, "rsa", claims(nil)),
+			wantErr: true,
+		},
+		{
+			name:    "unknown key",
+			token:   signRS256(t, rsaKey, "other", claims(nil)),
+			wantErr: true,
+		},
+		{
+			name:    "expired token",
+			token:   signRS256(t, rsaKey, "rsa", claims(map[string]interface{}{"exp": now.Add(-time.Hour).Unix()})),
+			wantErr: true,
+		},
+		{
+			name:    "token not valid yet",
+			token:   signRS256(t, rsaKey, "rsa", claims(map[string]interface{}{"nbf": now.Add(time.Hour).Unix()})),
+			wantErr: true,
+		},
+		{
+			name:    "wrong issuer",
+			token:   signRS256(t, rsaKey, "rsa", claims(map[string]interface{}{"iss": "https://other"})),
+			wantErr: true,
+		},
+		{
+			name:    "wrong audience",
+			token:   signRS256(t, rsaKey, "rsa", claims(map[string]interface{}{"aud": "other"})),
+			wantErr: true,
+		},
+		{
+			name:    "algorithm mismatch",
+			token:   signES256(t, ecKey, "rsa", claims(nil)),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/event/uri", nil)
+			r.Header.Set("Authorization", "Bearer "+tt.token)
+			got, err := a.Authenticate(r)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("JWTAuthenticator.Authenticate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+type (
+	// TokenAuthenticator static bearer token authenticator
+	TokenAuthenticator struct {
+		tokens []tokenEntry
+	}
+
+	tokenEntry struct {
+		token     []byte
+		principal Principal
+	}
+)
+
+// NewTokenAuthenticator load static tokens from file: one '{name} {scopes} {token}' per line,
+// where scopes is comma separated scope list
+func NewTokenAuthenticator(file string) (*TokenAuthenticator, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tokens file: %v", err)
+	}
+	defer f.Close()
+	return parseTokens(f)
+}
+
+func parseTokens(r io.Reader) (*TokenAuthenticator, error) {
+	a := &TokenAuthenticator{}
+	err := readEntries(r, func(name string, scopes []string, secret string) error {
+		a.tokens = append(a.tokens, tokenEntry{
+			token:     []byte(secret),
+			principal: Principal{Name: name, Scopes: scopes},
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Authenticate authenticate request with 'Authorization: Bearer {token}' header
+func (a *TokenAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return nil, ErrNoCredentials
+	}
+	token := []byte(strings.TrimPrefix(header, "Bearer "))
+	// JWT is verified by JWT authenticator
+	if strings.Count(string(token), ".") == 2 {
+		return nil, ErrNoCredentials
+	}
+	for _, t := range a.tokens {
+		if subtle.ConstantTimeCompare(t.token, token) == 1 {
+			p := t.principal
+			return &p, nil
+		}
+	}
+	return nil, ErrUnauthorized
+}
+
+// readEntries read '{name} {scopes} {secret}' lines; empty lines and # comments are skipped
+func readEntries(r io.Reader, add func(name string, scopes []string, secret string) error) error {
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 3 {
+			return fmt.Errorf("line %d: should be '{name} {scopes} {secret}'", line)
+		}
+		if err := add(fields[0], ParseScopes(fields[1]), fields[2]); err != nil {
+			return fmt.Errorf("line %d: %v", line, err)
+		}
+	}
+	return scanner.Err()
+}
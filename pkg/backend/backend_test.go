@@ -0,0 +1,90 @@
+package backend
+
+import "testing"
+
+func Test_parseStoreURL(t *testing.T) {
+	tests := []struct {
+		name       string
+		store      string
+		wantDriver string
+		wantDSN    string
+		wantErr    bool
+	}{
+		{
+			name:       "bolt file path",
+			store:      "/var/tmp/events.db",
+			wantDriver: "bolt",
+			wantDSN:    "/var/tmp/events.db",
+		},
+		{
+			name:       "bolt URL",
+			store:      "bolt:///var/tmp/events.db",
+			wantDriver: "bolt",
+			wantDSN:    "/var/tmp/events.db",
+		},
+		{
+			name:       "postgres URL",
+			store:      "postgres://cronus:secret@db:5432/cronus?sslmode=disable",
+			wantDriver: "postgres",
+			wantDSN:    "postgres://cronus:secret@db:5432/cronus?sslmode=disable",
+		},
+		{
+			name:       "mysql URL",
+			store:      "mysql://cronus:secret@db:3306/cronus?charset=utf8mb4",
+			wantDriver: "mysql",
+			wantDSN:    "cronus:secret@tcp(db:3306)/cronus?charset=utf8mb4",
+		},
+		{
+			name:       "mysql URL without password",
+			store:      "mysql://cronus@db:3306/cronus",
+			wantDriver: "mysql",
+			wantDSN:    "cronus@tcp(db:3306)/cronus",
+		},
+		{
+			name:       "sqlite URL",
+			store:      "sqlite:///var/tmp/events.sqlite",
+			wantDriver: "sqlite3",
+			wantDSN:    "/var/tmp/events.sqlite",
+		},
+		{
+			name:       "memory URL",
+			store:      "memory://",
+			wantDriver: "memory",
+		},
+		{
+			name:       "memory URL with snapshot",
+			store:      "memory:///var/tmp/snapshot.json",
+			wantDriver: "memory",
+			wantDSN:    "/var/tmp/snapshot.json",
+		},
+		{
+			name:    "unsupported scheme",
+			store:   "mongodb://db:27017/cronus",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driver, dsn, err := parseStoreURL(tt.store)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseStoreURL() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if driver != tt.wantDriver || dsn != tt.wantDSN {
+				t.Errorf("parseStoreURL() = %v, %v, want %v, %v", driver, dsn, tt.wantDriver, tt.wantDSN)
+			}
+		})
+	}
+}
+
+func TestSQLEventStore_query(t *testing.T) {
+	q := "SELECT data FROM cronus_history WHERE uri = ? AND fired > ?"
+	postgres := &SQLEventStore{dialect: dialects["postgres"]}
+	if got, want := postgres.query(q), "SELECT data FROM cronus_history WHERE uri = $1 AND fired > $2"; got != want {
+		t.Errorf("SQLEventStore.query() = %v, want %v", got, want)
+	}
+	mysql := &SQLEventStore{dialect: dialects["mysql"]}
+	if got := mysql.query(q); got != q {
+		t.Errorf("SQLEventStore.query() = %v, want %v", got, q)
+	}
+}
@@ -0,0 +1,325 @@
+package backend
+
+import (
+	"bytes"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/codefresh-io/cronus/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// storeFactory create new empty store, backed by files in dir
+type storeFactory func(t *testing.T, dir string) types.EventStore
+
+// testEventStore conformance test suite, every EventStore implementation should pass
+func testEventStore(t *testing.T, newStore storeFactory) {
+	e1 := types.Event{
+		Expression:  "5 4 * * *",
+		Message:     "test-message-1",
+		Secret:      "1234",
+		Description: "At 04:05",
+		Status:      types.StatusActive,
+		Help:        "help",
+	}
+	e2 := types.Event{
+		Expression:        "5 0 * 8 *",
+		Message:           "test-message-2",
+		Account:           "cb1e73c5215b",
+		Secret:            "1234",
+		Description:       "At 00:05 in August",
+		Status:            types.StatusFailing,
+		Jitter:            "5m",
+		ConcurrencyPolicy: types.ConcurrencyForbid,
+		FailureCount:      2,
+		LastError:         "Test Error",
+	}
+	tests := []struct {
+		name string
+		test func(t *testing.T, s types.EventStore)
+	}{
+		{
+			name: "store and get event",
+			test: func(t *testing.T, s types.EventStore) {
+				assert.NoError(t, s.StoreEvent(e1))
+				assert.NoError(t, s.StoreEvent(e2))
+				got, err := s.GetEvent(types.GetURI(e2))
+				assert.NoError(t, err)
+				assert.Equal(t, &e2, got)
+				all, err := s.GetAllEvents()
+				assert.NoError(t, err)
+				assert.ElementsMatch(t, []types.Event{e1, e2}, all)
+				records, err := s.GetDBStats()
+				assert.NoError(t, err)
+				assert.Equal(t, 2, records)
+			},
+		},
+		{
+			name: "update event",
+			test: func(t *testing.T, s types.EventStore) {
+				assert.NoError(t, s.StoreEvent(e1))
+				updated := e1
+				updated.Status = types.StatusPaused
+				assert.NoError(t, s.StoreEvent(updated))
+				got, err := s.GetEvent(types.GetURI(e1))
+				assert.NoError(t, err)
+				assert.Equal(t, &updated, got)
+				records, err := s.GetDBStats()
+				assert.NoError(t, err)
+				assert.Equal(t, 1, records)
+			},
+		},
+		{
+			name: "get account and status events",
+			test: func(t *testing.T, s types.EventStore) {
+				e3 := e2
+				e3.Message = "test-message-3"
+				e3.Status = types.StatusActive
+				for _, e := range []types.Event{e1, e2, e3} {
+					assert.NoError(t, s.StoreEvent(e))
+				}
+				got, err := s.GetAccountEvents(e2.Account)
+				assert.NoError(t, err)
+				assert.ElementsMatch(t, []types.Event{e2, e3}, got)
+				got, err = s.GetStatusEvents(types.StatusActive)
+				assert.NoError(t, err)
+				assert.ElementsMatch(t, []types.Event{e1, e3}, got)
+				// update and delete change lookups
+				e3.Status = types.StatusPaused
+				assert.NoError(t, s.StoreEvent(e3))
+				assert.NoError(t, s.DeleteEvent(types.GetURI(e2)))
+				got, err = s.GetAccountEvents(e2.Account)
+				assert.NoError(t, err)
+				assert.Equal(t, []types.Event{e3}, got)
+				got, err = s.GetStatusEvents(types.StatusActive)
+				assert.NoError(t, err)
+				assert.Equal(t, []types.Event{e1}, got)
+				got, err = s.GetStatusEvents(types.StatusFailing)
+				assert.NoError(t, err)
+				assert.Empty(t, got)
+				got, err = s.GetAccountEvents("missing")
+				assert.NoError(t, err)
+				assert.Empty(t, got)
+			},
+		},
+		{
+			name: "delete account events",
+			test: func(t *testing.T, s types.EventStore) {
+				e3 := e2
+				e3.Message = "test-message-3"
+				uri := types.GetURI(e3)
+				for _, e := range []types.Event{e1, e2, e3} {
+					assert.NoError(t, s.StoreEvent(e))
+				}
+				assert.NoError(t, s.AddHistory([]types.HistoryRecord{{URI: uri, Time: time.Now(), Result: types.ResultTriggered}}))
+				deleted, err := s.DeleteAccountEvents(e2.Account)
+				assert.NoError(t, err)
+				assert.ElementsMatch(t, []types.Event{e2, e3}, deleted)
+				all, err := s.GetAllEvents()
+				assert.NoError(t, err)
+				assert.Equal(t, []types.Event{e1}, all)
+				got, err := s.GetAccountEvents(e2.Account)
+				assert.NoError(t, err)
+				assert.Empty(t, got)
+				history, err := s.GetHistory(uri, 0)
+				assert.NoError(t, err)
+				assert.Empty(t, history)
+				// nothing to delete
+				deleted, err = s.DeleteAccountEvents(e2.Account)
+				assert.NoError(t, err)
+				assert.Empty(t, deleted)
+			},
+		},
+		{
+			name: "get due events",
+			test: func(t *testing.T, s types.EventStore) {
+				now := time.Now()
+				minutely := types.Event{Expression: "0 * * * * *", Message: "minutely", Account: "acc1", Secret: "1234", Status: types.StatusActive}
+				hourly := types.Event{Expression: "0 30 * * * *", Message: "hourly", Account: "acc1", Secret: "1234", Status: types.StatusActive}
+				ended := hourly
+				ended.Message = "ended"
+				ended.Until = now.Add(-time.Hour).Format(time.RFC3339)
+				paused := types.Event{Expression: "30 * * * * *", Message: "paused", Account: "acc1", Secret: "1234", Status: types.StatusPaused}
+				for _, e := range []types.Event{minutely, hourly, ended, paused} {
+					assert.NoError(t, s.StoreEvent(e))
+				}
+				got, err := s.GetDueEvents(now, now.Add(time.Hour))
+				assert.NoError(t, err)
+				assert.ElementsMatch(t, []types.Event{minutely, hourly}, got)
+				assertDueOrder(t, got, now)
+				// resumed event is due
+				paused.Status = types.StatusActive
+				assert.NoError(t, s.StoreEvent(paused))
+				got, err = s.GetDueEvents(now, now.Add(time.Hour))
+				assert.NoError(t, err)
+				assert.ElementsMatch(t, []types.Event{minutely, hourly, paused}, got)
+				assertDueOrder(t, got, now)
+				// later "now" (already fired events are due again)
+				later := now.Add(48 * time.Hour)
+				got, err = s.GetDueEvents(later, later.Add(time.Hour))
+				assert.NoError(t, err)
+				assert.ElementsMatch(t, []types.Event{minutely, hourly, paused}, got)
+				assertDueOrder(t, got, later)
+			},
+		},
+		{
+			name: "get missing event",
+			test: func(t *testing.T, s types.EventStore) {
+				_, err := s.GetEvent(types.GetURI(e1))
+				assert.Equal(t, types.ErrEventNotFound, err)
+				all, err := s.GetAllEvents()
+				assert.NoError(t, err)
+				assert.Empty(t, all)
+			},
+		},
+		{
+			name: "delete event with history",
+			test: func(t *testing.T, s types.EventStore) {
+				uri := types.GetURI(e1)
+				assert.NoError(t, s.StoreEvent(e1))
+				assert.NoError(t, s.AddHistory([]types.HistoryRecord{{URI: uri, Time: time.Now(), Result: types.ResultTriggered}}))
+				assert.NoError(t, s.DeleteEvent(uri))
+				assert.Equal(t, types.ErrEventNotFound, s.DeleteEvent(uri))
+				_, err := s.GetEvent(uri)
+				assert.Equal(t, types.ErrEventNotFound, err)
+				history, err := s.GetHistory(uri, 0)
+				assert.NoError(t, err)
+				assert.Empty(t, history)
+			},
+		},
+		{
+			name: "history newest first, with limit",
+			test: func(t *testing.T, s types.EventStore) {
+				uri := types.GetURI(e1)
+				start := time.Unix(1500000000, 0).UTC()
+				var records []types.HistoryRecord
+				for i := 0; i < 5; i++ {
+					records = append(records, types.HistoryRecord{
+						URI:      uri,
+						Time:     start.Add(time.Duration(i) * time.Minute),
+						Duration: time.Second,
+						Result:   types.ResultFailed,
+						Reason:   "Test Error",
+					})
+				}
+				assert.NoError(t, s.AddHistory(records))
+				got, err := s.GetHistory(uri, 2)
+				assert.NoError(t, err)
+				if assert.Len(t, got, 2) {
+					assert.Equal(t, records[4], got[0])
+					assert.Equal(t, records[3], got[1])
+				}
+				got, err = s.GetHistory(uri, 0)
+				assert.NoError(t, err)
+				assert.Len(t, got, 5)
+			},
+		},
+		{
+			name: "trim old history",
+			test: func(t *testing.T, s types.EventStore) {
+				uri := types.GetURI(e1)
+				start := time.Now()
+				// add in two batches
+				for batch := 0; batch < 2; batch++ {
+					var records []types.HistoryRecord
+					for i := 0; i < maxHistory; i++ {
+						records = append(records, types.HistoryRecord{
+							URI:    uri,
+							Time:   start.Add(time.Duration(batch*maxHistory+i) * time.Second),
+							Result: types.ResultTriggered,
+						})
+					}
+					assert.NoError(t, s.AddHistory(records))
+				}
+				got, err := s.GetHistory(uri, 0)
+				assert.NoError(t, err)
+				if assert.Len(t, got, maxHistory) {
+					assert.True(t, got[0].Time.Equal(start.Add(time.Duration(2*maxHistory-1)*time.Second)))
+					assert.True(t, got[maxHistory-1].Time.Equal(start.Add(time.Duration(maxHistory)*time.Second)))
+				}
+			},
+		},
+		{
+			name: "backup",
+			test: func(t *testing.T, s types.EventStore) {
+				assert.NoError(t, s.StoreEvent(e1))
+				w := &bytes.Buffer{}
+				size, err := s.BackupDB(w)
+				assert.NoError(t, err)
+				assert.True(t, size > 0)
+			},
+		},
+		{
+			name: "concurrent access",
+			test: func(t *testing.T, s types.EventStore) {
+				var wg sync.WaitGroup
+				for i := 0; i < 10; i++ {
+					wg.Add(1)
+					go func(i int) {
+						defer wg.Done()
+						e := e1
+						e.Message = "test-message-" + string(rune('a'+i))
+						assert.NoError(t, s.StoreEvent(e))
+						assert.NoError(t, s.AddHistory([]types.HistoryRecord{{URI: types.GetURI(e), Time: time.Now(), Result: types.ResultTriggered}}))
+						_, err := s.GetAllEvents()
+						assert.NoError(t, err)
+					}(i)
+				}
+				wg.Wait()
+				records, err := s.GetDBStats()
+				assert.NoError(t, err)
+				assert.Equal(t, 10, records)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			teardownTestCase, eventsDB := setupTestCase(t)
+			defer teardownTestCase(t)
+			s := newStore(t, filepath.Dir(eventsDB))
+			defer s.Close()
+			tt.test(t, s)
+		})
+	}
+}
+
+// assertDueOrder check events are ordered by next fire time
+func assertDueOrder(t *testing.T, due []types.Event, now time.Time) {
+	for i := 1; i < len(due); i++ {
+		prev, _ := types.NextFire(due[i-1], now)
+		next, _ := types.NextFire(due[i], now)
+		assert.False(t, next.Before(prev), "events should be ordered by next fire time")
+	}
+}
+
+func TestBoltEventStore_Conformance(t *testing.T) {
+	testEventStore(t, func(t *testing.T, dir string) types.EventStore {
+		s, err := NewBoltEventStore(filepath.Join(dir, "events.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	})
+}
+
+func TestMemoryEventStore_Conformance(t *testing.T) {
+	testEventStore(t, func(t *testing.T, dir string) types.EventStore {
+		s, err := NewMemoryEventStore("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	})
+}
+
+func TestEncryptedEventStore_Conformance(t *testing.T) {
+	testEventStore(t, func(t *testing.T, dir string) types.EventStore {
+		s, err := NewMemoryEventStore("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return NewEncryptedEventStore(s, newTestKeyring(t, "k1"))
+	})
+}
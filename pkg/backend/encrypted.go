@@ -0,0 +1,125 @@
+package backend
+
+import (
+	"io"
+	"time"
+
+	"github.com/codefresh-io/cronus/pkg/keyring"
+	"github.com/codefresh-io/cronus/pkg/types"
+	log "github.com/sirupsen/logrus"
+)
+
+type (
+	// EncryptedEventStore store decorator: encrypts event secrets on write and decrypts them on read
+	EncryptedEventStore struct {
+		types.EventStore
+		keys *keyring.Keyring
+	}
+)
+
+// NewEncryptedEventStore wrap store with event secret encryption
+func NewEncryptedEventStore(store types.EventStore, keys *keyring.Keyring) *EncryptedEventStore {
+	return &EncryptedEventStore{EventStore: store, keys: keys}
+}
+
+// StoreEvent encrypt event secret, bound to event URI, and store event
+func (s *EncryptedEventStore) StoreEvent(event types.Event) error {
+	if event.Secret != "" {
+		secret, err := s.keys.Encrypt(event.Secret, types.GetURI(event))
+		if err != nil {
+			log.WithError(err).Error("failed to encrypt event secret")
+			return err
+		}
+		event.Secret = secret
+	}
+	return s.EventStore.StoreEvent(event)
+}
+
+// GetEvent get event with decrypted secret
+func (s *EncryptedEventStore) GetEvent(uri string) (*types.Event, error) {
+	event, err := s.EventStore.GetEvent(uri)
+	if err != nil {
+		return nil, err
+	}
+	if event.Secret, err = s.keys.Decrypt(event.Secret, uri); err != nil {
+		log.WithError(err).WithField("uri", uri).Error("failed to decrypt event secret")
+		return nil, err
+	}
+	return event, nil
+}
+
+// GetAllEvents get all events with decrypted secrets
+func (s *EncryptedEventStore) GetAllEvents() ([]types.Event, error) {
+	return s.decryptAll(s.EventStore.GetAllEvents())
+}
+
+// DeleteAccountEvents delete account events; deleted events are returned with decrypted secrets
+func (s *EncryptedEventStore) DeleteAccountEvents(account string) ([]types.Event, error) {
+	return s.decryptAll(s.EventStore.DeleteAccountEvents(account))
+}
+
+// GetAccountEvents get account events with decrypted secrets
+func (s *EncryptedEventStore) GetAccountEvents(account string) ([]types.Event, error) {
+	return s.decryptAll(s.EventStore.GetAccountEvents(account))
+}
+
+// GetStatusEvents get events with status, with decrypted secrets
+func (s *EncryptedEventStore) GetStatusEvents(status string) ([]types.Event, error) {
+	return s.decryptAll(s.EventStore.GetStatusEvents(status))
+}
+
+// GetDueEvents get due events with decrypted secrets
+func (s *EncryptedEventStore) GetDueEvents(now, until time.Time) ([]types.Event, error) {
+	return s.decryptAll(s.EventStore.GetDueEvents(now, until))
+}
+
+// decryptAll decrypt secrets of loaded events
+func (s *EncryptedEventStore) decryptAll(all []types.Event, err error) ([]types.Event, error) {
+	if err != nil {
+		return nil, err
+	}
+	for i := range all {
+		if all[i].Secret, err = s.keys.Decrypt(all[i].Secret, types.GetURI(all[i])); err != nil {
+			log.WithError(err).WithField("uri", types.GetURI(all[i])).Error("failed to decrypt event secret")
+			return nil, err
+		}
+	}
+	return all, nil
+}
+
+// Rotate re-encrypt all event secrets, not encrypted with primary key in current format (including plain
+// secrets); returns number of re-encrypted events
+func (s *EncryptedEventStore) Rotate() (int, error) {
+	all, err := s.EventStore.GetAllEvents()
+	if err != nil {
+		return 0, err
+	}
+	rotated := 0
+	for _, event := range all {
+		if !s.keys.NeedsRotation(event.Secret) {
+			continue
+		}
+		if event.Secret, err = s.keys.Decrypt(event.Secret, types.GetURI(event)); err != nil {
+			log.WithError(err).WithField("uri", types.GetURI(event)).Error("failed to decrypt event secret")
+			return rotated, err
+		}
+		if err = s.StoreEvent(event); err != nil {
+			return rotated, err
+		}
+		rotated++
+	}
+	log.WithFields(log.Fields{
+		"key-id":  s.keys.Primary(),
+		"rotated": rotated,
+	}).Info("event secrets re-encrypted")
+	return rotated, nil
+}
+
+// RestoreDB restore wrapped store from backup; restored secrets are decrypted with configured keys on read
+func (s *EncryptedEventStore) RestoreDB(r io.Reader) (int, error) {
+	restorer, ok := s.EventStore.(Restorer)
+	if !ok {
+		return 0, ErrRestoreNotSupported
+	}
+	return restorer.RestoreDB(r)
+}
@@ -0,0 +1,97 @@
+package backend
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/codefresh-io/cronus/pkg/keyring"
+	"github.com/codefresh-io/cronus/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestKeyring(t *testing.T, kids ...string) *keyring.Keyring {
+	var keys []string
+	for _, kid := range kids {
+		key, err := keyring.Generate(kid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key)
+	}
+	k, err := keyring.New(keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func TestEncryptedEventStore(t *testing.T) {
+	plain, _ := NewMemoryEventStore("")
+	s := NewEncryptedEventStore(plain, newTestKeyring(t, "k1"))
+	e := types.Event{Expression: "5 4 * * *", Message: "test-message-1", Secret: "1234"}
+	uri := types.GetURI(e)
+	assert.NoError(t, s.StoreEvent(e))
+	// secret is encrypted in underlying store and backup
+	raw, err := plain.GetEvent(uri)
+	assert.NoError(t, err)
+	assert.True(t, keyring.IsEncrypted(raw.Secret))
+	assert.Equal(t, "k1", keyring.KeyID(raw.Secret))
+	w := &bytes.Buffer{}
+	_, err = s.BackupDB(w)
+	assert.NoError(t, err)
+	assert.NotContains(t, w.String(), `"secret":"1234"`)
+	// secret is decrypted on read
+	got, err := s.GetEvent(uri)
+	assert.NoError(t, err)
+	assert.Equal(t, &e, got)
+	all, err := s.GetAllEvents()
+	assert.NoError(t, err)
+	assert.Equal(t, []types.Event{e}, all)
+	// unknown key
+	other := NewEncryptedEventStore(plain, newTestKeyring(t, "k2"))
+	_, err = other.GetEvent(uri)
+	assert.Error(t, err)
+	_, err = other.GetAllEvents()
+	assert.Error(t, err)
+	// encrypted secret moved to other event cannot be decrypted
+	moved := types.Event{Expression: "5 4 * * *", Message: "test-message-2", Secret: raw.Secret}
+	assert.NoError(t, plain.StoreEvent(moved))
+	_, err = s.GetEvent(types.GetURI(moved))
+	assert.Error(t, err)
+	// plain secret looking like encrypted secret is rejected
+	assert.Equal(t, keyring.ErrReservedPrefix, s.StoreEvent(types.Event{Expression: "5 4 * * *", Message: "test-message-3", Secret: raw.Secret}))
+}
+
+func TestEncryptedEventStore_Rotate(t *testing.T) {
+	k1, _ := keyring.Generate("k1")
+	k2, _ := keyring.Generate("k2")
+	old, _ := keyring.New([]string{k1})
+	// new primary key; old key is kept for decryption
+	current, _ := keyring.New([]string{k2, k1})
+	plain, _ := NewMemoryEventStore("")
+	// legacy plain secret
+	legacy := types.Event{Expression: "5 4 * * *", Message: "test-message-1", Secret: "1234"}
+	assert.NoError(t, plain.StoreEvent(legacy))
+	// secret encrypted with old key
+	encrypted := types.Event{Expression: "5 4 * * *", Message: "test-message-2", Secret: "5678"}
+	assert.NoError(t, NewEncryptedEventStore(plain, old).StoreEvent(encrypted))
+	// empty secret
+	empty := types.Event{Expression: "5 4 * * *", Message: "test-message-3"}
+	assert.NoError(t, plain.StoreEvent(empty))
+
+	s := NewEncryptedEventStore(plain, current)
+	rotated, err := s.Rotate()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, rotated)
+	for _, e := range []types.Event{legacy, encrypted} {
+		raw, _ := plain.GetEvent(types.GetURI(e))
+		assert.Equal(t, "k2", keyring.KeyID(raw.Secret))
+		got, err := s.GetEvent(types.GetURI(e))
+		assert.NoError(t, err)
+		assert.Equal(t, e.Secret, got.Secret)
+	}
+	// nothing left to rotate
+	rotated, err = s.Rotate()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, rotated)
+}
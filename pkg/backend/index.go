@@ -0,0 +1,245 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/codefresh-io/cronus/pkg/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// BoltDB secondary indexes, updated in StoreEvent/DeleteEvent transaction:
+//   - index_account: {account}\x00{uri} -> empty
+//   - index_status: {status}\x00{uri} -> empty
+//   - index_next: {next fire unix time, 8 bytes}{uri} -> empty (schedulable events only)
+//   - index_next_uri: {uri} -> index_next key, to find entry of changed or deleted event
+var (
+	accountIndex = []byte("index_account")
+	statusIndex  = []byte("index_status")
+	nextIndex    = []byte("index_next")
+	nextURIIndex = []byte("index_next_uri")
+	indexBuckets = [][]byte{accountIndex, statusIndex, nextIndex, nextURIIndex}
+)
+
+func indexKey(value, uri string) []byte {
+	return []byte(value + "\x00" + uri)
+}
+
+func nextKey(next time.Time, uri string) []byte {
+	key := make([]byte, 8, 8+len(uri))
+	binary.BigEndian.PutUint64(key, uint64(next.Unix()))
+	return append(key, uri...)
+}
+
+func nextKeyTime(key []byte) time.Time {
+	return time.Unix(int64(binary.BigEndian.Uint64(key[:8])), 0)
+}
+
+// indexEvent add event index entries
+func indexEvent(tx *bolt.Tx, e types.Event, now time.Time) error {
+	uri := types.GetURI(e)
+	if err := tx.Bucket(accountIndex).Put(indexKey(e.Account, uri), []byte{}); err != nil {
+		return err
+	}
+	if err := tx.Bucket(statusIndex).Put(indexKey(e.Status, uri), []byte{}); err != nil {
+		return err
+	}
+	return indexNext(tx, e, now)
+}
+
+// indexNext add next fire time index entry
+func indexNext(tx *bolt.Tx, e types.Event, now time.Time) error {
+	next, ok := types.NextFire(e, now)
+	if !ok {
+		return nil
+	}
+	uri := types.GetURI(e)
+	key := nextKey(next, uri)
+	if err := tx.Bucket(nextIndex).Put(key, []byte{}); err != nil {
+		return err
+	}
+	return tx.Bucket(nextURIIndex).Put([]byte(uri), key)
+}
+
+// unindexEvent remove event index entries
+func unindexEvent(tx *bolt.Tx, e types.Event) error {
+	uri := types.GetURI(e)
+	if err := tx.Bucket(accountIndex).Delete(indexKey(e.Account, uri)); err != nil {
+		return err
+	}
+	if err := tx.Bucket(statusIndex).Delete(indexKey(e.Status, uri)); err != nil {
+		return err
+	}
+	return unindexNext(tx, uri)
+}
+
+// unindexNext remove next fire time index entry
+func unindexNext(tx *bolt.Tx, uri string) error {
+	byURI := tx.Bucket(nextURIIndex)
+	key := byURI.Get([]byte(uri))
+	if key == nil {
+		return nil
+	}
+	if err := tx.Bucket(nextIndex).Delete(append([]byte(nil), key...)); err != nil {
+		return err
+	}
+	return byURI.Delete([]byte(uri))
+}
+
+// reindexEvent replace index entries of stored event (if any) with entries of new event
+func reindexEvent(tx *bolt.Tx, old []byte, e types.Event) error {
+	if old != nil {
+		var prev types.Event
+		if err := json.Unmarshal(old, &prev); err == nil {
+			if err = unindexEvent(tx, prev); err != nil {
+				return err
+			}
+		}
+	}
+	return indexEvent(tx, e, time.Now())
+}
+
+// buildIndexes rebuild all indexes from events bucket
+func buildIndexes(tx *bolt.Tx) error {
+	for _, name := range indexBuckets {
+		if err := tx.DeleteBucket(name); err != nil && err != bolt.ErrBucketNotFound {
+			return err
+		}
+		if _, err := tx.CreateBucket(name); err != nil {
+			return err
+		}
+	}
+	now := time.Now()
+	return tx.Bucket(events).ForEach(func(k, v []byte) error {
+		var event types.Event
+		if err := json.Unmarshal(v, &event); err != nil {
+			log.WithError(err).WithField("key", string(k)).Warn("skipping invalid event record")
+			return nil
+		}
+		return indexEvent(tx, event, now)
+	})
+}
+
+// lookup get events from index entries with value prefix, that match filter
+func (b *BoltEventStore) lookup(index []byte, value string, match func(e types.Event) bool) ([]types.Event, error) {
+	all := make([]types.Event, 0)
+	err := b.view(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(events)
+		prefix := indexKey(value, "")
+		c := tx.Bucket(index).Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			v := bucket.Get(k[len(prefix):])
+			if v == nil {
+				continue
+			}
+			var event types.Event
+			if err := json.Unmarshal(v, &event); err != nil {
+				log.WithError(err).Error("failed to parse JSON")
+				return err
+			}
+			if match(event) {
+				all = append(all, event)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("failed to get events")
+		return nil, err
+	}
+	return all, nil
+}
+
+// GetAccountEvents get account events (account index lookup)
+func (b *BoltEventStore) GetAccountEvents(account string) ([]types.Event, error) {
+	log.WithField("account", account).Debug("getting account events from store")
+	return b.lookup(accountIndex, account, func(e types.Event) bool { return e.Account == account })
+}
+
+// GetStatusEvents get events with status (status index lookup)
+func (b *BoltEventStore) GetStatusEvents(status string) ([]types.Event, error) {
+	log.WithField("status", status).Debug("getting events by status from store")
+	return b.lookup(statusIndex, status, func(e types.Event) bool { return e.Status == status })
+}
+
+// GetDueEvents get events with next fire time between now and until, ordered by next fire time; index
+// entries of already fired events (before now) are refreshed first
+func (b *BoltEventStore) GetDueEvents(now, until time.Time) ([]types.Event, error) {
+	log.WithField("until", until).Debug("getting due events from store")
+	// find outdated entries
+	var stale []string
+	err := b.view(func(tx *bolt.Tx) error {
+		c := tx.Bucket(nextIndex).Cursor()
+		for k, _ := c.First(); k != nil && nextKeyTime(k).Before(now); k, _ = c.Next() {
+			stale = append(stale, string(k[8:]))
+		}
+		return nil
+	})
+	if err == nil && len(stale) > 0 {
+		err = b.update(func(tx *bolt.Tx) error {
+			bucket := tx.Bucket(events)
+			for _, uri := range stale {
+				if err := unindexNext(tx, uri); err != nil {
+					return err
+				}
+				var event types.Event
+				if v := bucket.Get([]byte(uri)); v == nil || json.Unmarshal(v, &event) != nil {
+					continue
+				}
+				if err := indexNext(tx, event, now); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	if err != nil {
+		log.WithError(err).Error("failed to refresh next fire time index")
+		return nil, err
+	}
+	all := make([]types.Event, 0)
+	err = b.view(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(events)
+		// index keys are ordered by next fire time
+		c := tx.Bucket(nextIndex).Cursor()
+		for k, _ := c.Seek(nextKey(now, "")); k != nil && !nextKeyTime(k).After(until); k, _ = c.Next() {
+			v := bucket.Get(k[8:])
+			if v == nil {
+				continue
+			}
+			var event types.Event
+			if err := json.Unmarshal(v, &event); err != nil {
+				log.WithError(err).Error("failed to parse JSON")
+				return err
+			}
+			all = append(all, event)
+		}
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("failed to get due events")
+		return nil, err
+	}
+	return all, nil
+}
+
+// dueEvents filter events with next fire time between now and until, ordered by next fire time
+// (stores without next fire time index)
+func dueEvents(all []types.Event, now, until time.Time) []types.Event {
+	next := make(map[string]time.Time)
+	due := make([]types.Event, 0)
+	for _, e := range all {
+		if t, ok := types.NextFire(e, now); ok && !t.After(until) {
+			next[types.GetURI(e)] = t
+			due = append(due, e)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool {
+		return next[types.GetURI(due[i])].Before(next[types.GetURI(due[j])])
+	})
+	return due
+}
@@ -0,0 +1,123 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/codefresh-io/cronus/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// indexEntries get index bucket keys
+func indexEntries(t *testing.T, s types.EventStore, index []byte) []string {
+	var keys []string
+	err := s.(*BoltEventStore).view(func(tx *bolt.Tx) error {
+		return tx.Bucket(index).ForEach(func(k, v []byte) error {
+			if string(index) == string(nextIndex) {
+				k = k[8:]
+			}
+			keys = append(keys, string(k))
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+func TestBoltEventStore_Indexes(t *testing.T) {
+	teardown, file := setupTestCase(t)
+	defer teardown(t)
+	s, err := NewBoltEventStore(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	e := types.Event{Expression: "0 0 4 * * *", Message: "message", Account: "acc1", Secret: "1234", Status: types.StatusActive}
+	uri := types.GetURI(e)
+	assert.NoError(t, s.StoreEvent(e))
+	assert.Equal(t, []string{indexKeyString("acc1", uri)}, indexEntries(t, s, accountIndex))
+	assert.Equal(t, []string{indexKeyString(types.StatusActive, uri)}, indexEntries(t, s, statusIndex))
+	assert.Equal(t, []string{uri}, indexEntries(t, s, nextIndex))
+	assert.Equal(t, []string{uri}, indexEntries(t, s, nextURIIndex))
+	// paused event is not in next fire time index
+	e.Status = types.StatusPaused
+	assert.NoError(t, s.StoreEvent(e))
+	assert.Equal(t, []string{indexKeyString(types.StatusPaused, uri)}, indexEntries(t, s, statusIndex))
+	assert.Empty(t, indexEntries(t, s, nextIndex))
+	assert.Empty(t, indexEntries(t, s, nextURIIndex))
+	// delete removes all entries
+	assert.NoError(t, s.DeleteEvent(uri))
+	for _, index := range indexBuckets {
+		assert.Empty(t, indexEntries(t, s, index), "index %s", index)
+	}
+}
+
+func TestBoltEventStore_DueRefresh(t *testing.T) {
+	teardown, file := setupTestCase(t)
+	defer teardown(t)
+	s, err := NewBoltEventStore(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	e := types.Event{Expression: "0 0 4 * * *", Message: "message", Account: "acc1", Secret: "1234", Status: types.StatusActive}
+	assert.NoError(t, s.StoreEvent(e))
+	stored := nextFireIndex(t, s)
+	// next fire time in index has passed: entry is refreshed
+	later := stored.Add(time.Hour)
+	got, err := s.GetDueEvents(later, later.Add(24*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, []types.Event{e}, got)
+	refreshed := nextFireIndex(t, s)
+	want, _ := types.NextFire(e, later)
+	assert.Equal(t, want.Unix(), refreshed.Unix())
+	assert.Len(t, indexEntries(t, s, nextIndex), 1)
+}
+
+func TestBoltEventStore_MigrateIndexes(t *testing.T) {
+	teardown, file := setupTestCase(t)
+	defer teardown(t)
+	records := make(map[string]string)
+	k1, v1 := legacyRecord(legacyEvent{Expression: "0 0 4 * * *", Message: "message1", Account: "acc1", Secret: "1234", Status: "active"})
+	k2, v2 := legacyRecord(legacyEvent{Expression: "0 0 5 * * *", Message: "message2", Account: "acc2", Secret: "1234", Status: "paused"})
+	records[k1] = v1
+	records[k2] = v2
+	createFixture(t, file, records)
+	s, err := NewBoltEventStore(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	got, err := s.GetAccountEvents("acc1")
+	assert.NoError(t, err)
+	if assert.Len(t, got, 1) {
+		assert.Equal(t, k1, types.GetURI(got[0]))
+	}
+	got, err = s.GetStatusEvents(types.StatusPaused)
+	assert.NoError(t, err)
+	if assert.Len(t, got, 1) {
+		assert.Equal(t, k2, types.GetURI(got[0]))
+	}
+	assert.Equal(t, []string{k1}, indexEntries(t, s, nextIndex))
+}
+
+func indexKeyString(value, uri string) string {
+	return string(indexKey(value, uri))
+}
+
+// nextFireIndex get next fire time of single next fire time index entry
+func nextFireIndex(t *testing.T, s types.EventStore) time.Time {
+	var next time.Time
+	s.(*BoltEventStore).view(func(tx *bolt.Tx) error {
+		k, _ := tx.Bucket(nextIndex).Cursor().First()
+		if k == nil {
+			t.Fatal("next fire time index is empty")
+		}
+		next = nextKeyTime(k)
+		return nil
+	})
+	return next
+}
@@ -0,0 +1,457 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/codefresh-io/cronus/pkg/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// Offline BoltDB file inspection and repair tools; database file should not be used by running server
+
+type (
+	// BoltRecord events bucket record; unparsable record is returned as raw value with parse error
+	BoltRecord struct {
+		Key     string                `json:"key"`
+		Event   *types.Event          `json:"event,omitempty"`
+		Raw     string                `json:"raw,omitempty"`
+		Error   string                `json:"error,omitempty"`
+		History []types.HistoryRecord `json:"history,omitempty"`
+	}
+
+	// BucketStats top level bucket statistics
+	BucketStats struct {
+		Name string `json:"name"`
+		// Keys number of keys (including keys of nested buckets)
+		Keys int `json:"keys"`
+		// Buckets number of nested buckets
+		Buckets int `json:"buckets"`
+		// Bytes bytes used by bucket pages
+		Bytes int `json:"bytes"`
+	}
+
+	// BoltStats BoltDB file statistics
+	BoltStats struct {
+		File    string        `json:"file"`
+		Size    int64         `json:"size"`
+		Version int           `json:"version"`
+		Events  int           `json:"events"`
+		Buckets []BucketStats `json:"buckets"`
+	}
+
+	// BoltProblem invalid record (or bucket) found by check, with fix
+	BoltProblem struct {
+		Bucket  string `json:"bucket"`
+		Key     string `json:"key,omitempty"`
+		Problem string `json:"problem"`
+		// Fix fix applied by repair; empty, if problem cannot be fixed
+		Fix string `json:"fix,omitempty"`
+	}
+
+	// CheckReport BoltDB file check report
+	CheckReport struct {
+		Events   int           `json:"events"`
+		Problems []BoltProblem `json:"problems"`
+		Fixed    bool          `json:"fixed"`
+	}
+
+	// CheckOptions BoltDB file check options
+	CheckOptions struct {
+		// Validate validate event schedule and options; optional
+		Validate func(e types.Event) error
+		// Fix apply fixes
+		Fix bool
+		// Delete delete invalid events, instead of marking them invalid
+		Delete bool
+	}
+)
+
+// preCompactSuffix suffix of BoltDB file before compaction
+const preCompactSuffix = ".pre-compact"
+
+// openBoltFile open existing BoltDB file; fails if file is used by running server
+func openBoltFile(file string, readOnly bool) (*bolt.DB, error) {
+	if _, err := os.Stat(file); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(file, 0600, &bolt.Options{ReadOnly: readOnly, Timeout: time.Second})
+	if err == bolt.ErrTimeout {
+		return nil, fmt.Errorf("failed to lock database (is cronus server running?): %v", err)
+	}
+	return db, err
+}
+
+// DumpBoltFile call fn for every events bucket record, in key order; event history is added, if requested
+func DumpBoltFile(file string, withHistory bool, fn func(r BoltRecord) error) error {
+	db, err := openBoltFile(file, true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	var records []BoltRecord
+	err = db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(events)
+		if bucket == nil {
+			return errors.New("missing events bucket")
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			r := BoltRecord{Key: string(k)}
+			var event types.Event
+			switch err := json.Unmarshal(v, &event); {
+			case v == nil:
+				r.Error = "unexpected nested bucket"
+			case err != nil:
+				r.Raw = string(v)
+				r.Error = err.Error()
+			default:
+				r.Event = &event
+			}
+			records = append(records, r)
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+	store := &BoltEventStore{file: file, db: db}
+	for _, r := range records {
+		if withHistory {
+			if r.History, err = store.GetHistory(r.Key, 0); err != nil {
+				return err
+			}
+		}
+		if err = fn(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BoltFileStats get BoltDB file statistics: schema version, number of events and top level bucket sizes
+func BoltFileStats(file string) (*BoltStats, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, err
+	}
+	db, err := openBoltFile(file, true)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	stats := &BoltStats{File: file, Size: info.Size()}
+	if stats.Events, err = (&BoltEventStore{file: file, db: db}).GetDBStats(); err != nil {
+		return nil, err
+	}
+	err = db.View(func(tx *bolt.Tx) error {
+		var err error
+		if stats.Version, err = readVersion(tx); err != nil {
+			return err
+		}
+		return tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+			s := b.Stats()
+			stats.Buckets = append(stats.Buckets, BucketStats{
+				Name:    string(name),
+				Keys:    s.KeyN,
+				Buckets: s.BucketN - 1,
+				Bytes:   s.BranchInuse + s.LeafInuse + s.InlineBucketInuse,
+			})
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// CheckBoltFile check every events bucket record: record should be a valid event (by current parser and
+// validate option) with known status, stored under its URI; history of missing events and out of date
+// indexes are reported too; with fix option problems are fixed and indexes are rebuilt in single transaction
+func CheckBoltFile(file string, opts CheckOptions) (*CheckReport, error) {
+	db, err := openBoltFile(file, !opts.Fix)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	err = db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(events) == nil {
+			return errors.New("missing events bucket")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if opts.Fix {
+		// fixes are applied to current schema
+		if err = migrateBolt(db, file); err != nil {
+			return nil, err
+		}
+	}
+	report := &CheckReport{Problems: []BoltProblem{}}
+	check := func(tx *bolt.Tx) error {
+		// indexes are checked before other fixes change events
+		if err := checkIndexes(tx, report); err != nil {
+			return err
+		}
+		if err := checkEvents(tx, opts, report); err != nil {
+			return err
+		}
+		if err := checkHistory(tx, opts.Fix, report); err != nil {
+			return err
+		}
+		if opts.Fix {
+			return buildIndexes(tx)
+		}
+		return nil
+	}
+	if opts.Fix {
+		err = db.Update(check)
+		report.Fixed = err == nil
+	} else {
+		err = db.View(check)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// checkEvents check events bucket records
+func checkEvents(tx *bolt.Tx, opts CheckOptions, report *CheckReport) error {
+	bucket := tx.Bucket(events)
+	// keys to remove: value is true for nested bucket
+	remove := make(map[string]bool)
+	update := make(map[string]types.Event)
+	problem := func(key, text, fix string) {
+		report.Problems = append(report.Problems, BoltProblem{Bucket: string(events), Key: key, Problem: text, Fix: fix})
+	}
+	err := bucket.ForEach(func(k, v []byte) error {
+		key := string(k)
+		if v == nil {
+			problem(key, "unexpected nested bucket", "delete")
+			remove[key] = true
+			return nil
+		}
+		var event types.Event
+		if err := json.Unmarshal(v, &event); err != nil {
+			problem(key, fmt.Sprintf("invalid JSON: %v", err), "delete")
+			remove[key] = false
+			return nil
+		}
+		changed := false
+		if uri := types.GetURI(event); uri != key {
+			remove[key] = false
+			if bucket.Get([]byte(uri)) != nil {
+				problem(key, fmt.Sprintf("duplicate of event '%s'", uri), "delete")
+				return nil
+			}
+			if _, ok := update[uri]; ok {
+				problem(key, fmt.Sprintf("duplicate of event '%s'", uri), "delete")
+				return nil
+			}
+			problem(key, fmt.Sprintf("stored under wrong key, event URI is '%s'", uri), "move to event URI")
+			key, changed = uri, true
+		}
+		if !types.ValidStatus(event.Status) {
+			problem(key, fmt.Sprintf("unknown status '%s'", event.Status), "set status to "+types.StatusPending)
+			event.Status = types.StatusPending
+			changed = true
+		}
+		if event.Status == types.StatusInvalid {
+			if opts.Delete {
+				problem(key, fmt.Sprintf("event is invalid: %s", event.LastError), "delete")
+				remove[string(k)] = false
+				return nil
+			}
+		} else if opts.Validate != nil {
+			if err := opts.Validate(event); err != nil {
+				if opts.Delete {
+					problem(key, err.Error(), "delete")
+					remove[string(k)] = false
+					return nil
+				}
+				problem(key, err.Error(), "mark invalid")
+				event.Status = types.StatusInvalid
+				event.LastError = err.Error()
+				changed = true
+			}
+		}
+		if changed {
+			update[key] = event
+		}
+		report.Events++
+		return nil
+	})
+	if err != nil || !opts.Fix {
+		return err
+	}
+	for key, nested := range remove {
+		if nested {
+			err = bucket.DeleteBucket([]byte(key))
+		} else {
+			err = bucket.Delete([]byte(key))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for key, event := range update {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if err = bucket.Put([]byte(key), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkHistory check history bucket: every nested bucket should belong to existing event
+func checkHistory(tx *bolt.Tx, fix bool, report *CheckReport) error {
+	bucket := tx.Bucket(history)
+	if bucket == nil {
+		return nil
+	}
+	var orphans []string
+	err := bucket.ForEach(func(k, v []byte) error {
+		if v != nil || tx.Bucket(events).Get(k) == nil {
+			orphans = append(orphans, string(k))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, key := range orphans {
+		report.Problems = append(report.Problems, BoltProblem{Bucket: string(history), Key: key, Problem: "history of missing event", Fix: "delete"})
+		if !fix {
+			continue
+		}
+		if bucket.Bucket([]byte(key)) != nil {
+			err = bucket.DeleteBucket([]byte(key))
+		} else {
+			err = bucket.Delete([]byte(key))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkIndexes compare account and status indexes with events
+func checkIndexes(tx *bolt.Tx, report *CheckReport) error {
+	expected := map[string]map[string]bool{string(accountIndex): {}, string(statusIndex): {}}
+	err := tx.Bucket(events).ForEach(func(k, v []byte) error {
+		var event types.Event
+		if v == nil || json.Unmarshal(v, &event) != nil {
+			return nil
+		}
+		expected[string(accountIndex)][string(indexKey(event.Account, string(k)))] = true
+		expected[string(statusIndex)][string(indexKey(event.Status, string(k)))] = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	stale := false
+	for name, keys := range expected {
+		bucket := tx.Bucket([]byte(name))
+		if bucket == nil || bucket.Stats().KeyN != len(keys) {
+			stale = true
+			break
+		}
+		for key := range keys {
+			if bucket.Get([]byte(key)) == nil {
+				stale = true
+				break
+			}
+		}
+	}
+	if !stale {
+		return nil
+	}
+	report.Problems = append(report.Problems, BoltProblem{Bucket: string(accountIndex), Problem: "indexes are out of date", Fix: "rebuild indexes"})
+	return nil
+}
+
+// CompactBoltFile rewrite BoltDB file, dropping free pages; previous file is kept with '.pre-compact'
+// suffix; returns file size before and after compaction
+func CompactBoltFile(file string) (int64, int64, error) {
+	src, err := openBoltFile(file, false)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer src.Close()
+	info, err := os.Stat(file)
+	if err != nil {
+		return 0, 0, err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(file), filepath.Base(file)+".compact")
+	if err != nil {
+		return 0, 0, err
+	}
+	tmp := f.Name()
+	f.Close()
+	defer os.Remove(tmp)
+	dst, err := bolt.Open(tmp, 0600, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	err = src.View(func(stx *bolt.Tx) error {
+		return dst.Update(func(dtx *bolt.Tx) error {
+			return stx.ForEach(func(name []byte, b *bolt.Bucket) error {
+				nb, err := dtx.CreateBucket(name)
+				if err != nil {
+					return err
+				}
+				return copyBucket(b, nb)
+			})
+		})
+	})
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to copy database: %v", err)
+	}
+	compacted, err := os.Stat(tmp)
+	if err != nil {
+		return 0, 0, err
+	}
+	if err = swap(tmp, file, preCompactSuffix); err != nil {
+		return 0, 0, fmt.Errorf("failed to replace database: %v", err)
+	}
+	log.WithFields(log.Fields{
+		"store":  file,
+		"before": info.Size(),
+		"after":  compacted.Size(),
+	}).Info("database compacted")
+	return info.Size(), compacted.Size(), nil
+}
+
+// copyBucket copy bucket keys, nested buckets and sequence
+func copyBucket(src, dst *bolt.Bucket) error {
+	if err := dst.SetSequence(src.Sequence()); err != nil {
+		return err
+	}
+	return src.ForEach(func(k, v []byte) error {
+		if v == nil {
+			nb, err := dst.CreateBucket(k)
+			if err != nil {
+				return err
+			}
+			return copyBucket(src.Bucket(k), nb)
+		}
+		return dst.Put(k, v)
+	})
+}
@@ -0,0 +1,261 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/codefresh-io/cronus/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// createBrokenDB create current schema database with valid event (with history) and broken records
+func createBrokenDB(t *testing.T, file string, valid types.Event, broken map[string]string) {
+	s, err := NewBoltEventStore(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, s.StoreEvent(valid))
+	assert.NoError(t, s.AddHistory([]types.HistoryRecord{{URI: types.GetURI(valid), Time: time.Now(), Result: types.ResultTriggered}}))
+	assert.NoError(t, s.AddHistory([]types.HistoryRecord{{URI: "cron:codefresh:deleted", Time: time.Now(), Result: types.ResultTriggered}}))
+	s.Close()
+	db, err := bolt.Open(file, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = db.Update(func(tx *bolt.Tx) error {
+		for k, v := range broken {
+			if err := tx.Bucket(events).Put([]byte(k), []byte(v)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func eventRecord(e types.Event) string {
+	data, _ := json.Marshal(e)
+	return string(data)
+}
+
+func TestDumpBoltFile(t *testing.T) {
+	teardown, file := setupTestCase(t)
+	defer teardown(t)
+	valid := types.Event{Expression: "0 0 4 * * *", Message: "valid", Account: "acc1", Secret: "1234", Status: types.StatusActive}
+	createBrokenDB(t, file, valid, map[string]string{"cron:codefresh:bad": "{bad json"})
+	var records []BoltRecord
+	err := DumpBoltFile(file, true, func(r BoltRecord) error {
+		records = append(records, r)
+		return nil
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, &valid, records[0].Event)
+		assert.Len(t, records[0].History, 1)
+		assert.Equal(t, "cron:codefresh:bad", records[1].Key)
+		assert.Equal(t, "{bad json", records[1].Raw)
+		assert.NotEmpty(t, records[1].Error)
+	}
+	// callback error stops dump
+	stop := errors.New("stop")
+	assert.Equal(t, stop, DumpBoltFile(file, false, func(r BoltRecord) error { return stop }))
+}
+
+func TestBoltFileStats(t *testing.T) {
+	teardown, file := setupTestCase(t)
+	defer teardown(t)
+	valid := types.Event{Expression: "0 0 4 * * *", Message: "valid", Account: "acc1", Secret: "1234", Status: types.StatusActive}
+	createBrokenDB(t, file, valid, nil)
+	stats, err := BoltFileStats(file)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, BoltSchemaVersion(), stats.Version)
+	assert.Equal(t, 1, stats.Events)
+	assert.True(t, stats.Size > 0)
+	buckets := make(map[string]BucketStats)
+	for _, b := range stats.Buckets {
+		buckets[b.Name] = b
+	}
+	assert.Equal(t, 1, buckets["events"].Keys)
+	assert.Equal(t, 2, buckets["history"].Buckets)
+	// nested bucket names and records
+	assert.Equal(t, 4, buckets["history"].Keys)
+	assert.Equal(t, 1, buckets["index_account"].Keys)
+}
+
+func TestCheckBoltFile(t *testing.T) {
+	valid := types.Event{Expression: "0 0 4 * * *", Message: "valid", Account: "acc1", Secret: "1234", Status: types.StatusActive}
+	moved := types.Event{Expression: "0 0 5 * * *", Message: "moved", Account: "acc1", Secret: "1234", Status: types.StatusActive}
+	unknown := types.Event{Expression: "0 0 6 * * *", Message: "unknown", Account: "acc1", Secret: "1234", Status: "error"}
+	short := types.Event{Expression: "* * * * * *", Message: "short", Account: "acc1", Secret: "1234", Status: types.StatusActive}
+	broken := map[string]string{
+		"cron:codefresh:bad":      "{bad json",
+		"cron:codefresh:old-key":  eventRecord(moved),
+		types.GetURI(unknown):     eventRecord(unknown),
+		types.GetURI(short):       eventRecord(short),
+		"cron:codefresh:dup-copy": eventRecord(valid),
+	}
+	validate := func(e types.Event) error {
+		if e.Message == "short" {
+			return errors.New("too short interval")
+		}
+		return nil
+	}
+	// problems are reported in bucket key order
+	problems := func(short, unknown BoltProblem) []BoltProblem {
+		return []BoltProblem{
+			{Bucket: "index_account", Problem: "indexes are out of date", Fix: "rebuild indexes"},
+			short,
+			unknown,
+			{Bucket: "events", Key: "cron:codefresh:bad", Problem: "invalid JSON: invalid character 'b' looking for beginning of object key string", Fix: "delete"},
+			{Bucket: "events", Key: "cron:codefresh:dup-copy", Problem: "duplicate of event '" + types.GetURI(valid) + "'", Fix: "delete"},
+			{Bucket: "events", Key: "cron:codefresh:old-key", Problem: "stored under wrong key, event URI is '" + types.GetURI(moved) + "'", Fix: "move to event URI"},
+			{Bucket: "history", Key: "cron:codefresh:deleted", Problem: "history of missing event", Fix: "delete"},
+		}
+	}
+	unknownProblem := BoltProblem{Bucket: "events", Key: types.GetURI(unknown), Problem: "unknown status 'error'", Fix: "set status to pending"}
+	tests := []struct {
+		name         string
+		opts         CheckOptions
+		wantProblems []BoltProblem
+		wantEvents   map[string]types.Event
+	}{
+		{
+			name:         "check",
+			opts:         CheckOptions{Validate: validate},
+			wantProblems: problems(BoltProblem{Bucket: "events", Key: types.GetURI(short), Problem: "too short interval", Fix: "mark invalid"}, unknownProblem),
+		},
+		{
+			name:         "fix",
+			opts:         CheckOptions{Validate: validate, Fix: true},
+			wantProblems: problems(BoltProblem{Bucket: "events", Key: types.GetURI(short), Problem: "too short interval", Fix: "mark invalid"}, unknownProblem),
+			wantEvents: map[string]types.Event{
+				"valid":   valid,
+				"moved":   moved,
+				"unknown": func() types.Event { e := unknown; e.Status = types.StatusPending; return e }(),
+				"short": func() types.Event {
+					e := short
+					e.Status = types.StatusInvalid
+					e.LastError = "too short interval"
+					return e
+				}(),
+			},
+		},
+		{
+			name:         "fix with delete",
+			opts:         CheckOptions{Validate: validate, Fix: true, Delete: true},
+			wantProblems: problems(BoltProblem{Bucket: "events", Key: types.GetURI(short), Problem: "too short interval", Fix: "delete"}, unknownProblem),
+			wantEvents: map[string]types.Event{
+				"valid":   valid,
+				"moved":   moved,
+				"unknown": func() types.Event { e := unknown; e.Status = types.StatusPending; return e }(),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			teardown, file := setupTestCase(t)
+			defer teardown(t)
+			createBrokenDB(t, file, valid, broken)
+			// break index
+			db, _ := bolt.Open(file, 0600, nil)
+			db.Update(func(tx *bolt.Tx) error {
+				return tx.Bucket(accountIndex).Delete(indexKey(valid.Account, types.GetURI(valid)))
+			})
+			db.Close()
+			report, err := CheckBoltFile(file, tt.opts)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.wantProblems, report.Problems)
+			assert.Equal(t, tt.opts.Fix, report.Fixed)
+			if !tt.opts.Fix {
+				return
+			}
+			// fixed database has no problems
+			report, err = CheckBoltFile(file, CheckOptions{Validate: validate})
+			assert.NoError(t, err)
+			if tt.opts.Delete {
+				assert.Empty(t, report.Problems)
+			}
+			assert.Equal(t, len(tt.wantEvents), report.Events)
+			s, err := NewBoltEventStore(file)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer s.Close()
+			all, _ := s.GetAllEvents()
+			got := make(map[string]types.Event)
+			for _, e := range all {
+				got[e.Message] = e
+			}
+			assert.Equal(t, tt.wantEvents, got)
+			account, _ := s.GetAccountEvents("acc1")
+			assert.Len(t, account, len(tt.wantEvents))
+			history, _ := s.GetHistory(types.GetURI(valid), 0)
+			assert.Len(t, history, 1)
+		})
+	}
+}
+
+func TestCompactBoltFile(t *testing.T) {
+	teardown, file := setupTestCase(t)
+	defer teardown(t)
+	s, err := NewBoltEventStore(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// grow and shrink database
+	var uris []string
+	for i := 0; i < 500; i++ {
+		e := types.Event{Expression: "0 0 4 * * *", Message: "message" + string(rune('a'+i%26)) + string(rune('a'+i/26)), Account: "acc1", Secret: "1234"}
+		assert.NoError(t, s.StoreEvent(e))
+		uris = append(uris, types.GetURI(e))
+	}
+	assert.NoError(t, s.AddHistory([]types.HistoryRecord{{URI: uris[0], Time: time.Now(), Result: types.ResultTriggered}}))
+	for _, uri := range uris[1:] {
+		assert.NoError(t, s.DeleteEvent(uri))
+	}
+	s.Close()
+	before, after, err := CompactBoltFile(file)
+	assert.NoError(t, err)
+	assert.True(t, after < before, "compacted %d -> %d", before, after)
+	_, err = os.Stat(file + preCompactSuffix)
+	assert.NoError(t, err)
+	// compacted database has the same content
+	s, err = NewBoltEventStore(file)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer s.Close()
+	all, _ := s.GetAllEvents()
+	assert.Len(t, all, 1)
+	history, _ := s.GetHistory(uris[0], 0)
+	assert.Len(t, history, 1)
+	// history sequence is kept
+	assert.NoError(t, s.AddHistory([]types.HistoryRecord{{URI: uris[0], Time: time.Now(), Result: types.ResultTriggered}}))
+	history, _ = s.GetHistory(uris[0], 0)
+	assert.Len(t, history, 2)
+}
+
+func TestBoltFileLocked(t *testing.T) {
+	teardown, file := setupTestCase(t)
+	defer teardown(t)
+	s, err := NewBoltEventStore(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	_, err = CheckBoltFile(file, CheckOptions{Fix: true})
+	assert.Error(t, err)
+	_, _, err = CompactBoltFile(file)
+	assert.Error(t, err)
+}
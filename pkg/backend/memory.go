@@ -0,0 +1,234 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/codefresh-io/cronus/pkg/types"
+	log "github.com/sirupsen/logrus"
+)
+
+type (
+	// MemoryEventStore in-memory store, with optional JSON snapshot file
+	MemoryEventStore struct {
+		mu       sync.RWMutex
+		events   map[string]types.Event
+		history  map[string][]types.HistoryRecord
+		snapshot string
+	}
+)
+
+// NewMemoryEventStore new in-memory store; if snapshot file is set, events and history are loaded
+// from it (if exists) and saved to it on Close
+func NewMemoryEventStore(snapshot string) (types.EventStore, error) {
+	log.WithField("snapshot", snapshot).Debug("starting in-memory store")
+	m := &MemoryEventStore{
+		events:   make(map[string]types.Event),
+		history:  make(map[string][]types.HistoryRecord),
+		snapshot: snapshot,
+	}
+	if snapshot == "" {
+		return m, nil
+	}
+	data, err := ioutil.ReadFile(snapshot)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		log.WithError(err).Error("failed to read snapshot file")
+		return nil, fmt.Errorf("failed to read snapshot, %v", err)
+	}
+	var d dump
+	if err = json.Unmarshal(data, &d); err != nil {
+		log.WithError(err).Error("failed to parse snapshot file")
+		return nil, fmt.Errorf("failed to parse snapshot, %v", err)
+	}
+	for _, e := range d.Events {
+		m.events[types.GetURI(e)] = e
+	}
+	for _, r := range d.History {
+		m.history[r.URI] = append(m.history[r.URI], r)
+	}
+	return m, nil
+}
+
+// save save JSON snapshot to file
+func (m *MemoryEventStore) save(file string) error {
+	tmp := file + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = m.BackupDB(f); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err = f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	// replace snapshot atomically
+	return os.Rename(tmp, file)
+}
+
+// Close save JSON snapshot, if snapshot file is set
+func (m *MemoryEventStore) Close() error {
+	if m.snapshot == "" {
+		return nil
+	}
+	log.WithField("snapshot", m.snapshot).Debug("saving in-memory store snapshot")
+	return m.save(m.snapshot)
+}
+
+// BackupDB write JSON snapshot with all events and history records
+func (m *MemoryEventStore) BackupDB(w io.Writer) (int, error) {
+	m.mu.RLock()
+	d := dump{Events: make([]types.Event, 0, len(m.events)), History: make([]types.HistoryRecord, 0)}
+	for _, e := range m.events {
+		d.Events = append(d.Events, e)
+	}
+	for _, records := range m.history {
+		d.History = append(d.History, records...)
+	}
+	m.mu.RUnlock()
+	sort.Slice(d.Events, func(i, j int) bool { return types.GetURI(d.Events[i]) < types.GetURI(d.Events[j]) })
+	v, err := json.Marshal(d)
+	if err != nil {
+		return 0, err
+	}
+	return w.Write(v)
+}
+
+// StoreEvent store event record
+func (m *MemoryEventStore) StoreEvent(event types.Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events[types.GetURI(event)] = event
+	return nil
+}
+
+// DeleteEvent delete event record and its history
+func (m *MemoryEventStore) DeleteEvent(uri string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.events[uri]; !ok {
+		return types.ErrEventNotFound
+	}
+	delete(m.events, uri)
+	delete(m.history, uri)
+	return nil
+}
+
+// DeleteAccountEvents delete all account events and their history; deleted events are returned
+func (m *MemoryEventStore) DeleteAccountEvents(account string) ([]types.Event, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	deleted := make([]types.Event, 0)
+	for uri, e := range m.events {
+		if e.Account != account {
+			continue
+		}
+		delete(m.events, uri)
+		delete(m.history, uri)
+		deleted = append(deleted, e)
+	}
+	return deleted, nil
+}
+
+// GetEvent get event record
+func (m *MemoryEventStore) GetEvent(uri string) (*types.Event, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	e, ok := m.events[uri]
+	if !ok {
+		return nil, types.ErrEventNotFound
+	}
+	return &e, nil
+}
+
+// GetAllEvents get all stored events
+func (m *MemoryEventStore) GetAllEvents() ([]types.Event, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	all := make([]types.Event, 0, len(m.events))
+	for _, e := range m.events {
+		all = append(all, e)
+	}
+	return all, nil
+}
+
+// filter get events that match filter
+func (m *MemoryEventStore) filter(match func(e types.Event) bool) []types.Event {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	all := make([]types.Event, 0)
+	for _, e := range m.events {
+		if match(e) {
+			all = append(all, e)
+		}
+	}
+	return all
+}
+
+// GetAccountEvents get account events
+func (m *MemoryEventStore) GetAccountEvents(account string) ([]types.Event, error) {
+	return m.filter(func(e types.Event) bool { return e.Account == account }), nil
+}
+
+// GetStatusEvents get events with status
+func (m *MemoryEventStore) GetStatusEvents(status string) ([]types.Event, error) {
+	return m.filter(func(e types.Event) bool { return e.Status == status }), nil
+}
+
+// GetDueEvents get events with next fire time between now and until, ordered by next fire time
+func (m *MemoryEventStore) GetDueEvents(now, until time.Time) ([]types.Event, error) {
+	all, _ := m.GetAllEvents()
+	return dueEvents(all, now, until), nil
+}
+
+// GetDBStats get number of records
+func (m *MemoryEventStore) GetDBStats() (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.events), nil
+}
+
+// AddHistory store event history records, keeping last maxHistory records per event
+func (m *MemoryEventStore) AddHistory(records []types.HistoryRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	touched := make(map[string]bool)
+	for _, r := range records {
+		m.history[r.URI] = append(m.history[r.URI], r)
+		touched[r.URI] = true
+	}
+	for uri := range touched {
+		all := m.history[uri]
+		// keep records ordered by fire time
+		sort.SliceStable(all, func(i, j int) bool { return all[i].Time.Before(all[j].Time) })
+		if len(all) > maxHistory {
+			all = append([]types.HistoryRecord(nil), all[len(all)-maxHistory:]...)
+		}
+		m.history[uri] = all
+	}
+	return nil
+}
+
+// GetHistory get last (up to limit) event history records, newest first
+func (m *MemoryEventStore) GetHistory(uri string, limit int) ([]types.HistoryRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	all := m.history[uri]
+	got := make([]types.HistoryRecord, 0, len(all))
+	for i := len(all) - 1; i >= 0 && (limit <= 0 || len(got) < limit); i-- {
+		got = append(got, all[i])
+	}
+	return got, nil
+}
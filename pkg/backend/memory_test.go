@@ -0,0 +1,48 @@
+package backend
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codefresh-io/cronus/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryEventStore_Snapshot(t *testing.T) {
+	teardownTestCase, eventsDB := setupTestCase(t)
+	defer teardownTestCase(t)
+	snapshot := filepath.Join(filepath.Dir(eventsDB), "snapshot.json")
+	e := types.Event{
+		Expression: "5 4 * * *",
+		Message:    "test-message-1",
+		Secret:     "1234",
+		Status:     types.StatusActive,
+	}
+	record := types.HistoryRecord{URI: types.GetURI(e), Time: time.Unix(1500000000, 0).UTC(), Result: types.ResultTriggered}
+	// missing snapshot: empty store
+	s, err := NewEventStore("memory://" + snapshot)
+	if err != nil {
+		t.Fatalf("NewMemoryEventStore() error = %v", err)
+	}
+	assert.NoError(t, s.StoreEvent(e))
+	assert.NoError(t, s.AddHistory([]types.HistoryRecord{record}))
+	// save snapshot on close
+	assert.NoError(t, s.Close())
+	// load snapshot
+	s, err = NewMemoryEventStore(snapshot)
+	if err != nil {
+		t.Fatalf("NewMemoryEventStore() error = %v", err)
+	}
+	all, err := s.GetAllEvents()
+	assert.NoError(t, err)
+	assert.Equal(t, []types.Event{e}, all)
+	history, err := s.GetHistory(types.GetURI(e), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.HistoryRecord{record}, history)
+	// bad snapshot
+	assert.NoError(t, ioutil.WriteFile(snapshot, []byte("not a json"), 0600))
+	_, err = NewMemoryEventStore(snapshot)
+	assert.Error(t, err)
+}
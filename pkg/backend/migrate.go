@@ -0,0 +1,150 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/boltdb/bolt"
+	"github.com/codefresh-io/cronus/pkg/types"
+	log "github.com/sirupsen/logrus"
+)
+
+type (
+	// boltMigration BoltDB schema migration to version
+	boltMigration struct {
+		version     int
+		description string
+		migrate     func(tx *bolt.Tx) error
+	}
+)
+
+var meta = []byte("meta")
+var versionKey = []byte("version")
+
+// boltMigrations ordered BoltDB schema migrations; never change applied migration, add a new one
+var boltMigrations = []boltMigration{
+	{version: 1, description: "normalize legacy event statuses", migrate: migrateStatuses},
+	{version: 2, description: "build account, status and next fire time indexes", migrate: buildIndexes},
+}
+
+// BoltSchemaVersion current BoltDB schema version
+func BoltSchemaVersion() int {
+	return boltMigrations[len(boltMigrations)-1].version
+}
+
+// readVersion read schema version from meta bucket; 0 for database without meta bucket (before versioning)
+func readVersion(tx *bolt.Tx) (int, error) {
+	bucket := tx.Bucket(meta)
+	if bucket == nil {
+		return 0, nil
+	}
+	v := bucket.Get(versionKey)
+	if v == nil {
+		return 0, nil
+	}
+	version, err := strconv.Atoi(string(v))
+	if err != nil {
+		return 0, fmt.Errorf("bad schema version '%s'", v)
+	}
+	return version, nil
+}
+
+// writeVersion write schema version into meta bucket
+func writeVersion(tx *bolt.Tx, version int) error {
+	bucket, err := tx.CreateBucketIfNotExists(meta)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(versionKey, []byte(strconv.Itoa(version)))
+}
+
+// migrateBolt run pending migrations, each in own transaction with version update; database file is copied
+// to {file}.v{version}.bak before first migration
+func migrateBolt(db *bolt.DB, file string) error {
+	var version int
+	err := db.View(func(tx *bolt.Tx) error {
+		var err error
+		version, err = readVersion(tx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if version > BoltSchemaVersion() {
+		return fmt.Errorf("database schema version %d is newer than supported version %d", version, BoltSchemaVersion())
+	}
+	if version == BoltSchemaVersion() {
+		return nil
+	}
+	// pre-migration backup
+	backup := fmt.Sprintf("%s.v%d.bak", file, version)
+	log.WithFields(log.Fields{
+		"from":   version,
+		"to":     BoltSchemaVersion(),
+		"backup": backup,
+	}).Info("migrating database schema")
+	err = db.View(func(tx *bolt.Tx) error {
+		return tx.CopyFile(backup, 0600)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to backup database before migration: %v", err)
+	}
+	for _, m := range boltMigrations {
+		if m.version <= version {
+			continue
+		}
+		err = db.Update(func(tx *bolt.Tx) error {
+			if err := m.migrate(tx); err != nil {
+				return err
+			}
+			return writeVersion(tx, m.version)
+		})
+		if err != nil {
+			return fmt.Errorf("migration to version %d (%s) failed: %v", m.version, m.description, err)
+		}
+		log.WithField("version", m.version).Infof("database migrated: %s", m.description)
+	}
+	return nil
+}
+
+// migrateStatuses v1: map legacy 'error' status to 'invalid' and unknown (or missing) statuses to 'pending',
+// so events are scheduled and get valid status on start
+func migrateStatuses(tx *bolt.Tx) error {
+	bucket := tx.Bucket(events)
+	updated := make(map[string][]byte)
+	err := bucket.ForEach(func(k, v []byte) error {
+		var event types.Event
+		if err := json.Unmarshal(v, &event); err != nil {
+			// keep unparsable records for validation tools
+			log.WithError(err).WithField("key", string(k)).Warn("skipping invalid event record")
+			return nil
+		}
+		status := event.Status
+		switch {
+		case status == "error":
+			status = types.StatusInvalid
+		case !types.ValidStatus(status):
+			status = types.StatusPending
+		}
+		if status == event.Status {
+			return nil
+		}
+		event.Status = status
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		updated[string(k)] = data
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for k, v := range updated {
+		if err = bucket.Put([]byte(k), v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/boltdb/bolt"
+	"github.com/codefresh-io/cronus/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// legacyEvent event record in format before schema versioning
+type legacyEvent struct {
+	Expression  string `json:"expression"`
+	Message     string `json:"message"`
+	Account     string `json:"account"`
+	Secret      string `json:"secret"`
+	Description string `json:"description,omitempty"`
+	Status      string `json:"status,omitempty"`
+}
+
+// createFixture create database in format before schema versioning (no meta bucket)
+func createFixture(t *testing.T, file string, records map[string]string) {
+	db, err := bolt.Open(file, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucket(events)
+		if err != nil {
+			return err
+		}
+		if _, err = tx.CreateBucket(history); err != nil {
+			return err
+		}
+		for k, v := range records {
+			if err = bucket.Put([]byte(k), []byte(v)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func legacyRecord(e legacyEvent) (string, string) {
+	data, _ := json.Marshal(e)
+	return types.GetURI(types.Event{Expression: e.Expression, Message: e.Message, Account: e.Account}), string(data)
+}
+
+func schemaVersion(t *testing.T, file string) int {
+	db, err := bolt.Open(file, 0600, &bolt.Options{ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var version int
+	db.View(func(tx *bolt.Tx) error {
+		version, err = readVersion(tx)
+		return err
+	})
+	return version
+}
+
+func TestBoltEventStore_Migrate(t *testing.T) {
+	teardown, file := setupTestCase(t)
+	defer teardown(t)
+	records := make(map[string]string)
+	statuses := map[string]string{
+		"active":     types.StatusActive,
+		"error":      types.StatusInvalid,
+		"":           types.StatusPending,
+		"not active": types.StatusPending,
+	}
+	uris := make(map[string]string)
+	i := 0
+	for legacy := range statuses {
+		i++
+		k, v := legacyRecord(legacyEvent{Expression: "0 0 4 * * *", Message: "message" + string(rune('0'+i)), Account: "acc", Secret: "1234", Status: legacy})
+		records[k] = v
+		uris[legacy] = k
+	}
+	records["cron:codefresh:bad"] = "{bad json"
+	createFixture(t, file, records)
+
+	s, err := NewBoltEventStore(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for legacy, want := range statuses {
+		e, err := s.GetEvent(uris[legacy])
+		if assert.NoError(t, err) {
+			assert.Equal(t, want, e.Status, "legacy status '%s'", legacy)
+			assert.Equal(t, "1234", e.Secret)
+		}
+	}
+	s.Close()
+	assert.Equal(t, BoltSchemaVersion(), schemaVersion(t, file))
+	// unparsable record is kept
+	db, _ := bolt.Open(file, 0600, nil)
+	db.View(func(tx *bolt.Tx) error {
+		assert.Equal(t, []byte("{bad json"), tx.Bucket(events).Get([]byte("cron:codefresh:bad")))
+		return nil
+	})
+	db.Close()
+	// pre-migration backup has original records
+	backup := file + ".v0.bak"
+	assert.Equal(t, 0, schemaVersion(t, backup))
+	db, _ = bolt.Open(backup, 0600, &bolt.Options{ReadOnly: true})
+	db.View(func(tx *bolt.Tx) error {
+		assert.Equal(t, []byte(records[uris["error"]]), tx.Bucket(events).Get([]byte(uris["error"])))
+		return nil
+	})
+	db.Close()
+	// reopen: nothing to migrate
+	os.Remove(backup)
+	s, err = NewBoltEventStore(file)
+	assert.NoError(t, err)
+	s.Close()
+	_, err = os.Stat(backup)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestBoltEventStore_MigrateNew(t *testing.T) {
+	teardown, file := setupTestCase(t)
+	defer teardown(t)
+	s, err := NewBoltEventStore(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+	assert.Equal(t, BoltSchemaVersion(), schemaVersion(t, file))
+	_, err = os.Stat(file + ".v0.bak")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestBoltEventStore_MigrateNewer(t *testing.T) {
+	teardown, file := setupTestCase(t)
+	defer teardown(t)
+	createFixture(t, file, nil)
+	db, _ := bolt.Open(file, 0600, nil)
+	db.Update(func(tx *bolt.Tx) error { return writeVersion(tx, BoltSchemaVersion()+1) })
+	db.Close()
+	_, err := NewBoltEventStore(file)
+	assert.Error(t, err)
+	_, err = ValidateBoltFile(file)
+	assert.Error(t, err)
+}
+
+func TestBoltEventStore_MigrateFailed(t *testing.T) {
+	teardown, file := setupTestCase(t)
+	defer teardown(t)
+	k, v := legacyRecord(legacyEvent{Expression: "0 0 4 * * *", Message: "message", Status: "error"})
+	createFixture(t, file, map[string]string{k: v})
+	// add failing migration
+	saved := boltMigrations
+	defer func() { boltMigrations = saved }()
+	boltMigrations = append(append([]boltMigration{}, saved...), boltMigration{
+		version:     BoltSchemaVersion() + 1,
+		description: "failing migration",
+		migrate: func(tx *bolt.Tx) error {
+			tx.Bucket(events).Delete([]byte(k))
+			return errors.New("migration error")
+		},
+	})
+	_, err := NewBoltEventStore(file)
+	assert.Error(t, err)
+	// applied migrations are kept, failed migration is rolled back
+	assert.Equal(t, BoltSchemaVersion()-1, schemaVersion(t, file))
+	boltMigrations = saved
+	s, err := NewBoltEventStore(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	e, err := s.GetEvent(k)
+	assert.NoError(t, err)
+	assert.Equal(t, types.StatusInvalid, e.Status)
+}
@@ -0,0 +1,211 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/codefresh-io/cronus/pkg/types"
+	log "github.com/sirupsen/logrus"
+)
+
+type (
+	// Restorer event store that can be restored from backup
+	Restorer interface {
+		// RestoreDB replace store content with backup; returns number of restored events
+		RestoreDB(r io.Reader) (int, error)
+	}
+)
+
+// ErrRestoreNotSupported store cannot be restored from backup
+var ErrRestoreNotSupported = errors.New("event store does not support restore")
+
+// ErrInvalidBackup backup is not valid BoltDB file with cronus events
+var ErrInvalidBackup = errors.New("invalid backup")
+
+// preRestoreSuffix suffix of previous BoltDB file, kept after restore
+const preRestoreSuffix = ".pre-restore"
+
+// ValidateBoltFile validate BoltDB backup file: schema version is supported (older is migrated on open),
+// events bucket exists and every record is a valid event, stored under its URI; returns number of events
+func ValidateBoltFile(file string) (int, error) {
+	db, err := bolt.Open(file, 0600, &bolt.Options{ReadOnly: true, Timeout: time.Second})
+	if err != nil {
+		return 0, fmt.Errorf("%w: not a BoltDB file: %v", ErrInvalidBackup, err)
+	}
+	defer db.Close()
+	count := 0
+	err = db.View(func(tx *bolt.Tx) error {
+		version, err := readVersion(tx)
+		if err != nil {
+			return err
+		}
+		if version > BoltSchemaVersion() {
+			return fmt.Errorf("schema version %d is newer than supported version %d", version, BoltSchemaVersion())
+		}
+		bucket := tx.Bucket(events)
+		if bucket == nil {
+			return errors.New("missing events bucket")
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			if v == nil {
+				return fmt.Errorf("unexpected nested bucket '%s'", k)
+			}
+			var event types.Event
+			if err := json.Unmarshal(v, &event); err != nil {
+				return fmt.Errorf("invalid event '%s': %v", k, err)
+			}
+			if uri := types.GetURI(event); uri != string(k) {
+				return fmt.Errorf("event '%s' is stored under '%s'", uri, k)
+			}
+			count++
+			return nil
+		})
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	return count, nil
+}
+
+// writeTemp write backup into temporary file next to file (same file system, for atomic rename)
+func writeTemp(file string, r io.Reader) (string, error) {
+	f, err := ioutil.TempFile(filepath.Dir(file), filepath.Base(file)+".restore")
+	if err != nil {
+		return "", err
+	}
+	if _, err = io.Copy(f, r); err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// linkFile create hard link; replaced in tests
+var linkFile = os.Link
+
+// swap atomically replace file with new one; previous file is kept with suffix (hard link, or copy, if file
+// system does not support links); file is not replaced, if previous file cannot be kept
+func swap(tmp, file, suffix string) error {
+	os.Remove(file + suffix)
+	if err := linkFile(file, file+suffix); err != nil && !os.IsNotExist(err) {
+		log.WithError(err).Warn("failed to link previous database, copying it")
+		if err = copyFile(file, file+suffix); err != nil {
+			return fmt.Errorf("failed to keep copy of previous database: %v", err)
+		}
+	}
+	return os.Rename(tmp, file)
+}
+
+// copyFile copy file content and sync it to disk
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err == nil {
+		err = out.Sync()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(dst)
+	}
+	return err
+}
+
+// RestoreBoltFile validate BoltDB backup and replace (not opened) BoltDB file with it; returns number
+// of restored events
+func RestoreBoltFile(file string, r io.Reader) (int, error) {
+	tmp, err := writeTemp(file, r)
+	if err != nil {
+		return 0, fmt.Errorf("failed to write backup: %v", err)
+	}
+	defer os.Remove(tmp)
+	count, err := ValidateBoltFile(tmp)
+	if err != nil {
+		return 0, err
+	}
+	// fail, if database is used by running server
+	if _, err = os.Stat(file); err == nil {
+		db, err := bolt.Open(file, 0600, &bolt.Options{Timeout: time.Second})
+		if err != nil {
+			return 0, fmt.Errorf("failed to lock database (is cronus server running?): %v", err)
+		}
+		defer db.Close()
+	}
+	if err = swap(tmp, file, preRestoreSuffix); err != nil {
+		return 0, fmt.Errorf("failed to replace database: %v", err)
+	}
+	log.WithFields(log.Fields{"store": file, "events": count}).Info("database restored")
+	return count, nil
+}
+
+// RestoreDB validate BoltDB backup and replace open database with it; previous database file is kept
+// with '.pre-restore' suffix
+func (b *BoltEventStore) RestoreDB(r io.Reader) (int, error) {
+	log.Debug("database restore")
+	tmp, err := writeTemp(b.file, r)
+	if err != nil {
+		log.WithError(err).Error("failed to write backup")
+		return 0, fmt.Errorf("failed to write backup: %v", err)
+	}
+	defer os.Remove(tmp)
+	count, err := ValidateBoltFile(tmp)
+	if err != nil {
+		log.WithError(err).Error("invalid backup")
+		return 0, err
+	}
+	// wait for running transactions and swap database
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err = b.db.Close(); err != nil {
+		log.WithError(err).Error("failed to close database")
+		return 0, err
+	}
+	if err = swap(tmp, b.file, preRestoreSuffix); err != nil {
+		log.WithError(err).Error("failed to replace database")
+	}
+	// reopen restored (or, on failure, previous) database
+	db, oerr := setupDB(b.file)
+	if oerr != nil && err == nil {
+		// restored database cannot be opened (failed migration): put previous database back
+		log.WithError(oerr).Error("failed to open restored database, reverting to previous database")
+		if rerr := os.Rename(b.file+preRestoreSuffix, b.file); rerr != nil {
+			log.WithError(rerr).Error("failed to revert to previous database")
+			return 0, fmt.Errorf("failed to open restored database: %v; failed to revert: %v", oerr, rerr)
+		}
+		if db, err = setupDB(b.file); err != nil {
+			return 0, fmt.Errorf("failed to open restored database: %v; failed to reopen previous: %v", oerr, err)
+		}
+		b.db = db
+		return 0, fmt.Errorf("failed to open restored database: %v", oerr)
+	}
+	if oerr != nil {
+		return 0, oerr
+	}
+	b.db = db
+	if err != nil {
+		return 0, fmt.Errorf("failed to replace database: %v", err)
+	}
+	log.WithField("events", count).Info("database restored")
+	return count, nil
+}
@@ -0,0 +1,239 @@
+package backend
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/boltdb/bolt"
+	"github.com/codefresh-io/cronus/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoltEventStore_RestoreDB(t *testing.T) {
+	teardown, file := setupTestCase(t)
+	defer teardown(t)
+	dir := filepath.Dir(file)
+	e1 := types.Event{Expression: "0 0 4 * * *", Message: "test-message-1", Secret: "1234"}
+	e2 := types.Event{Expression: "0 0 5 * * *", Message: "test-message-2", Secret: "1234"}
+	// backup with e1 and e2
+	src, err := NewBoltEventStore(filepath.Join(dir, "src.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src.StoreEvent(e1)
+	src.StoreEvent(e2)
+	var backup bytes.Buffer
+	if _, err = src.BackupDB(&backup); err != nil {
+		t.Fatal(err)
+	}
+	src.Close()
+	// store with other event
+	s, err := NewBoltEventStore(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	other := types.Event{Expression: "0 0 6 * * *", Message: "test-message-3", Secret: "1234"}
+	s.StoreEvent(other)
+	restorer := s.(Restorer)
+
+	// invalid backup is rejected, store is not changed
+	_, err = restorer.RestoreDB(bytes.NewBufferString("not a database"))
+	assert.Error(t, err)
+	all, _ := s.GetAllEvents()
+	assert.Equal(t, []types.Event{other}, all)
+
+	// valid backup replaces store content
+	count, err := restorer.RestoreDB(bytes.NewReader(backup.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	all, _ = s.GetAllEvents()
+	assert.Equal(t, []types.Event{e1, e2}, all)
+	// store is writable after restore
+	assert.NoError(t, s.StoreEvent(other))
+	// previous database is kept
+	_, err = os.Stat(file + preRestoreSuffix)
+	assert.NoError(t, err)
+}
+
+func TestBoltEventStore_RestoreDB_OpenFailed(t *testing.T) {
+	teardown, file := setupTestCase(t)
+	defer teardown(t)
+	dir := filepath.Dir(file)
+	// backup with current schema version
+	src, err := NewBoltEventStore(filepath.Join(dir, "src.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := types.Event{Expression: "0 0 4 * * *", Message: "test-message-1", Secret: "1234"}
+	src.StoreEvent(restored)
+	var backup bytes.Buffer
+	if _, err = src.BackupDB(&backup); err != nil {
+		t.Fatal(err)
+	}
+	src.Close()
+	// store with other event
+	s, err := NewBoltEventStore(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	other := types.Event{Expression: "0 0 6 * * *", Message: "test-message-3", Secret: "1234"}
+	s.StoreEvent(other)
+	// newer schema: backup is valid, but its migration fails on open
+	saved := boltMigrations
+	defer func() { boltMigrations = saved }()
+	boltMigrations = append(append([]boltMigration{}, saved...), boltMigration{
+		version:     BoltSchemaVersion() + 1,
+		description: "failing migration",
+		migrate: func(tx *bolt.Tx) error {
+			if tx.Bucket(events).Get([]byte(types.GetURI(restored))) != nil {
+				return errors.New("migration error")
+			}
+			return nil
+		},
+	})
+
+	_, err = s.(Restorer).RestoreDB(bytes.NewReader(backup.Bytes()))
+	assert.Error(t, err)
+	// previous database is back and writable
+	all, err := s.GetAllEvents()
+	assert.NoError(t, err)
+	assert.Equal(t, []types.Event{other}, all)
+	assert.NoError(t, s.StoreEvent(other))
+}
+
+func TestValidateBoltFile(t *testing.T) {
+	teardown, file := setupTestCase(t)
+	defer teardown(t)
+	e := types.Event{Expression: "0 0 4 * * *", Message: "test-message-1", Secret: "1234"}
+	tests := []struct {
+		name    string
+		setup   func(tx *bolt.Tx) error
+		want    int
+		wantErr bool
+	}{
+		{
+			name: "valid events",
+			setup: func(tx *bolt.Tx) error {
+				b, _ := tx.CreateBucket(events)
+				return b.Put([]byte(types.GetURI(e)), []byte(`{"expression":"0 0 4 * * *","message":"test-message-1"}`))
+			},
+			want: 1,
+		},
+		{
+			name:    "missing events bucket",
+			setup:   func(tx *bolt.Tx) error { return nil },
+			wantErr: true,
+		},
+		{
+			name: "bad event record",
+			setup: func(tx *bolt.Tx) error {
+				b, _ := tx.CreateBucket(events)
+				return b.Put([]byte(types.GetURI(e)), []byte(`{bad json`))
+			},
+			wantErr: true,
+		},
+		{
+			name: "event stored under other URI",
+			setup: func(tx *bolt.Tx) error {
+				b, _ := tx.CreateBucket(events)
+				return b.Put([]byte("cron:codefresh:0 0 5 * * *:other"), []byte(`{"expression":"0 0 4 * * *","message":"test-message-1"}`))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Remove(file)
+			db, err := bolt.Open(file, 0600, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			db.Update(tt.setup)
+			db.Close()
+			got, err := ValidateBoltFile(file)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateBoltFile() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRestoreBoltFile(t *testing.T) {
+	teardown, file := setupTestCase(t)
+	defer teardown(t)
+	e := types.Event{Expression: "0 0 4 * * *", Message: "test-message-1", Secret: "1234"}
+	src, err := NewBoltEventStore(filepath.Join(filepath.Dir(file), "src.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src.StoreEvent(e)
+	var backup bytes.Buffer
+	src.BackupDB(&backup)
+	src.Close()
+	// database does not exist yet
+	count, err := RestoreBoltFile(file, bytes.NewReader(backup.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	s, err := NewBoltEventStore(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := s.GetEvent(types.GetURI(e))
+	assert.Equal(t, &e, got)
+	// database is used
+	_, err = RestoreBoltFile(file, bytes.NewReader(backup.Bytes()))
+	assert.Error(t, err)
+	s.Close()
+}
+
+func TestSwap(t *testing.T) {
+	tests := []struct {
+		name    string
+		link    bool
+		keepErr bool
+		wantErr bool
+	}{
+		{name: "keep hard link", link: true},
+		{name: "copy when link fails"},
+		{name: "fail when previous file cannot be kept", keepErr: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			teardown, file := setupTestCase(t)
+			defer teardown(t)
+			tmp := file + ".new"
+			assert.NoError(t, ioutil.WriteFile(file, []byte("previous"), 0600))
+			assert.NoError(t, ioutil.WriteFile(tmp, []byte("restored"), 0600))
+			if !tt.link {
+				defer func() { linkFile = os.Link }()
+				linkFile = func(oldname, newname string) error {
+					return &os.LinkError{Op: "link", Old: oldname, New: newname, Err: errors.New("cross-device link")}
+				}
+			}
+			if tt.keepErr {
+				// directory in place of previous file copy
+				assert.NoError(t, os.MkdirAll(filepath.Join(file+preRestoreSuffix, "dir"), 0700))
+			}
+			err := swap(tmp, file, preRestoreSuffix)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("swap() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			data, _ := ioutil.ReadFile(file)
+			if tt.wantErr {
+				assert.Equal(t, "previous", string(data), "file should not be replaced")
+				return
+			}
+			assert.Equal(t, "restored", string(data))
+			data, _ = ioutil.ReadFile(file + preRestoreSuffix)
+			assert.Equal(t, "previous", string(data))
+		})
+	}
+}
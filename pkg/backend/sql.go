@@ -0,0 +1,498 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/codefresh-io/cronus/pkg/types"
+	"github.com/go-sql-driver/mysql"
+	// register PostgreSQL driver
+	_ "github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+)
+
+type (
+	// SQLEventStore SQL database store (PostgreSQL, MySQL or SQLite)
+	SQLEventStore struct {
+		db      *sql.DB
+		dialect *dialect
+	}
+
+	// dialect SQL dialect differences
+	dialect struct {
+		// driver database/sql driver name
+		driver string
+		// serial auto increment primary key column type
+		serial string
+		// upsert insert or update event statement
+		upsert string
+		// numbered placeholders ($1) instead of ?
+		numbered bool
+		// indexIfNotExists CREATE INDEX supports IF NOT EXISTS
+		indexIfNotExists bool
+		// lock take schema migration lock on connection, returns unlock function; nil - no lock needed
+		lock func(ctx context.Context, conn *sql.Conn) (func(), error)
+	}
+
+	// migration schema migration
+	migration struct {
+		version    int
+		statements func(d *dialect) []string
+	}
+)
+
+var dialects = map[string]*dialect{
+	"postgres": {
+		driver:           "postgres",
+		serial:           "BIGSERIAL PRIMARY KEY",
+		upsert:           "INSERT INTO cronus_events (uri, account, data) VALUES (?, ?, ?) ON CONFLICT (uri) DO UPDATE SET account = excluded.account, data = excluded.data",
+		numbered:         true,
+		indexIfNotExists: true,
+		lock:             postgresLock,
+	},
+	"mysql": {
+		driver: "mysql",
+		serial: "BIGINT AUTO_INCREMENT PRIMARY KEY",
+		upsert: "INSERT INTO cronus_events (uri, account, data) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE account = VALUES(account), data = VALUES(data)",
+		lock:   mysqlLock,
+	},
+	"sqlite3": {
+		driver:           "sqlite3",
+		serial:           "INTEGER PRIMARY KEY AUTOINCREMENT",
+		upsert:           "INSERT INTO cronus_events (uri, account, data) VALUES (?, ?, ?) ON CONFLICT (uri) DO UPDATE SET account = excluded.account, data = excluded.data",
+		indexIfNotExists: true,
+	},
+}
+
+// schema migration lock: PostgreSQL advisory lock key and MySQL named lock
+const (
+	migrationLockKey     = 0x63726f6e
+	migrationLockName    = "cronus_schema"
+	migrationLockTimeout = 60 // seconds
+)
+
+// mysqlDuplicateKeyName MySQL error code of CREATE INDEX for existing index
+const mysqlDuplicateKeyName = 1061
+
+// postgresLock take session advisory lock; waits for other replica migration to complete
+func postgresLock(ctx context.Context, conn *sql.Conn) (func(), error) {
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+		return nil, err
+	}
+	return func() {
+		conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", migrationLockKey)
+	}, nil
+}
+
+// mysqlLock take named lock; waits up to lock timeout for other replica migration to complete
+func mysqlLock(ctx context.Context, conn *sql.Conn) (func(), error) {
+	var locked sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", migrationLockName, migrationLockTimeout).Scan(&locked); err != nil {
+		return nil, err
+	}
+	if !locked.Valid || locked.Int64 != 1 {
+		return nil, errors.New("timed out waiting for schema migration lock")
+	}
+	return func() {
+		conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", migrationLockName)
+	}, nil
+}
+
+// createIndex idempotent CREATE INDEX statement; MySQL has no IF NOT EXISTS, duplicate index error is ignored
+func (d *dialect) createIndex(name, table, columns string) string {
+	if d.indexIfNotExists {
+		return fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (%s)", name, table, columns)
+	}
+	return fmt.Sprintf("CREATE INDEX %s ON %s (%s)", name, table, columns)
+}
+
+// ignoreMigrationError check if migration statement error means statement was already applied
+func (d *dialect) ignoreMigrationError(err error) bool {
+	merr, ok := err.(*mysql.MySQLError)
+	return ok && d.driver == "mysql" && merr.Number == mysqlDuplicateKeyName
+}
+
+// migrations ordered schema migrations; never change applied migration, add a new one; statements should be
+// idempotent, since MySQL commits DDL statements implicitly and failed migration may be partially applied
+var migrations = []migration{
+	{
+		version: 1,
+		statements: func(d *dialect) []string {
+			return []string{
+				"CREATE TABLE IF NOT EXISTS cronus_events (uri VARCHAR(512) NOT NULL PRIMARY KEY, account VARCHAR(64) NOT NULL, data TEXT NOT NULL)",
+				"CREATE TABLE IF NOT EXISTS cronus_history (id " + d.serial + ", uri VARCHAR(512) NOT NULL, fired BIGINT NOT NULL, data TEXT NOT NULL)",
+				d.createIndex("cronus_history_uri_fired", "cronus_history", "uri, fired"),
+			}
+		},
+	},
+	{
+		version: 2,
+		statements: func(d *dialect) []string {
+			return []string{
+				d.createIndex("cronus_events_account", "cronus_events", "account"),
+			}
+		},
+	},
+}
+
+// NewSQLEventStore new SQL database store; runs pending schema migrations
+func NewSQLEventStore(driver, dsn string) (types.EventStore, error) {
+	log.WithField("driver", driver).Debug("connecting to SQL database")
+	d, ok := dialects[driver]
+	if !ok {
+		return nil, fmt.Errorf("unsupported SQL driver '%s'", driver)
+	}
+	db, err := sql.Open(d.driver, dsn)
+	if err != nil {
+		log.WithError(err).Error("failed to open database")
+		return nil, fmt.Errorf("failed to open db, %v", err)
+	}
+	if err = db.Ping(); err != nil {
+		log.WithError(err).Error("failed to connect to database")
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to db, %v", err)
+	}
+	s := &SQLEventStore{db: db, dialect: d}
+	if err = s.migrate(); err != nil {
+		log.WithError(err).Error("failed to migrate database schema")
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate db, %v", err)
+	}
+	log.Debug("setup db done")
+	return s, nil
+}
+
+// query rebind query placeholders for SQL dialect
+func (s *SQLEventStore) query(q string) string {
+	if !s.dialect.numbered {
+		return q
+	}
+	var b strings.Builder
+	n := 0
+	for _, c := range q {
+		if c == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// migrate apply pending schema migrations, each in its own transaction; migration lock serializes replicas
+// starting together
+func (s *SQLEventStore) migrate() error {
+	ctx := context.Background()
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if s.dialect.lock != nil {
+		unlock, err := s.dialect.lock(ctx, conn)
+		if err != nil {
+			return fmt.Errorf("failed to take schema migration lock: %v", err)
+		}
+		defer unlock()
+	}
+	if _, err = conn.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS cronus_schema (version INTEGER NOT NULL PRIMARY KEY)"); err != nil {
+		return err
+	}
+	// read version under lock: other replica may have migrated schema
+	var current int
+	if err = conn.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM cronus_schema").Scan(&current); err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		log.WithField("version", m.version).Info("applying database schema migration")
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		for _, stmt := range m.statements(s.dialect) {
+			if _, err = tx.Exec(stmt); err != nil && !s.dialect.ignoreMigrationError(err) {
+				tx.Rollback()
+				return fmt.Errorf("migration %d: %v", m.version, err)
+			}
+		}
+		if _, err = tx.Exec(s.query("INSERT INTO cronus_schema (version) VALUES (?)"), m.version); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %v", m.version, err)
+		}
+		if err = tx.Commit(); err != nil {
+			return fmt.Errorf("migration %d: %v", m.version, err)
+		}
+	}
+	return nil
+}
+
+// Close close database connections
+func (s *SQLEventStore) Close() error {
+	log.Debug("closing database")
+	return s.db.Close()
+}
+
+// BackupDB backup SQL database: JSON document with all events and history records
+func (s *SQLEventStore) BackupDB(w io.Writer) (int, error) {
+	log.Debug("database backup")
+	backup := dump{History: make([]types.HistoryRecord, 0)}
+	tx, err := s.db.Begin()
+	if err != nil {
+		log.WithError(err).Error("failed to backup db")
+		return 0, err
+	}
+	defer tx.Rollback()
+	if backup.Events, err = s.scanEvents(tx.Query("SELECT data FROM cronus_events ORDER BY uri")); err != nil {
+		log.WithError(err).Error("failed to backup db")
+		return 0, err
+	}
+	if backup.History, err = s.scanHistory(tx.Query("SELECT data FROM cronus_history ORDER BY uri, fired, id")); err != nil {
+		log.WithError(err).Error("failed to backup db")
+		return 0, err
+	}
+	v, err := json.Marshal(backup)
+	if err != nil {
+		return 0, err
+	}
+	size, err := w.Write(v)
+	if err != nil {
+		log.WithError(err).Error("failed to backup db")
+	}
+	return size, err
+}
+
+// StoreEvent store event record into SQL database
+func (s *SQLEventStore) StoreEvent(event types.Event) error {
+	log.WithFields(log.Fields{
+		"expression": event.Expression,
+		"message":    event.Message,
+	}).Debug("storing new event")
+	v, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(s.query(s.dialect.upsert), types.GetURI(event), event.Account, string(v))
+	return err
+}
+
+// DeleteEvent delete event record and its history from SQL database
+func (s *SQLEventStore) DeleteEvent(uri string) error {
+	log.WithField("uri", uri).Debug("deleting event from store")
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec(s.query("DELETE FROM cronus_events WHERE uri = ?"), uri)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		log.WithField("uri", uri).Error("event not found")
+		return types.ErrEventNotFound
+	}
+	// delete event history
+	if _, err = tx.Exec(s.query("DELETE FROM cronus_history WHERE uri = ?"), uri); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteAccountEvents delete all account events and their history in single transaction; deleted events
+// are returned
+func (s *SQLEventStore) DeleteAccountEvents(account string) ([]types.Event, error) {
+	log.WithField("account", account).Debug("deleting account events from store")
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	deleted, err := s.scanEvents(tx.Query(s.query("SELECT data FROM cronus_events WHERE account = ? ORDER BY uri"), account))
+	if err != nil {
+		return nil, err
+	}
+	if _, err = tx.Exec(s.query("DELETE FROM cronus_history WHERE uri IN (SELECT uri FROM cronus_events WHERE account = ?)"), account); err != nil {
+		return nil, err
+	}
+	if _, err = tx.Exec(s.query("DELETE FROM cronus_events WHERE account = ?"), account); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return deleted, nil
+}
+
+// GetEvent get event record
+func (s *SQLEventStore) GetEvent(uri string) (*types.Event, error) {
+	log.WithField("uri", uri).Debug("getting event from store")
+	var data string
+	err := s.db.QueryRow(s.query("SELECT data FROM cronus_events WHERE uri = ?"), uri).Scan(&data)
+	if err == sql.ErrNoRows {
+		log.WithField("uri", uri).Error("event not found")
+		return nil, types.ErrEventNotFound
+	}
+	var event types.Event
+	if err == nil {
+		err = json.Unmarshal([]byte(data), &event)
+	}
+	if err != nil {
+		log.WithError(err).Error("failed to get event")
+		return nil, err
+	}
+	return &event, nil
+}
+
+// GetAllEvents get all stored events
+func (s *SQLEventStore) GetAllEvents() ([]types.Event, error) {
+	log.Debug("getting all events from store")
+	all, err := s.scanEvents(s.db.Query("SELECT data FROM cronus_events ORDER BY uri"))
+	if err != nil {
+		log.WithError(err).Error("failed to get events")
+	}
+	return all, err
+}
+
+// GetAccountEvents get account events
+func (s *SQLEventStore) GetAccountEvents(account string) ([]types.Event, error) {
+	log.WithField("account", account).Debug("getting account events from store")
+	all, err := s.scanEvents(s.db.Query(s.query("SELECT data FROM cronus_events WHERE account = ? ORDER BY uri"), account))
+	if err != nil {
+		log.WithError(err).Error("failed to get events")
+	}
+	return all, err
+}
+
+// GetStatusEvents get events with status (status is stored in event data only)
+func (s *SQLEventStore) GetStatusEvents(status string) ([]types.Event, error) {
+	all, err := s.GetAllEvents()
+	if err != nil {
+		return nil, err
+	}
+	found := make([]types.Event, 0)
+	for _, e := range all {
+		if e.Status == status {
+			found = append(found, e)
+		}
+	}
+	return found, nil
+}
+
+// GetDueEvents get events with next fire time between now and until, ordered by next fire time
+func (s *SQLEventStore) GetDueEvents(now, until time.Time) ([]types.Event, error) {
+	all, err := s.GetAllEvents()
+	if err != nil {
+		return nil, err
+	}
+	return dueEvents(all, now, until), nil
+}
+
+// GetDBStats get number of records
+func (s *SQLEventStore) GetDBStats() (int, error) {
+	var records int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM cronus_events").Scan(&records)
+	if err != nil {
+		log.WithError(err).Error("failed to get db stats")
+	}
+	return records, err
+}
+
+// AddHistory store event history records in single transaction, keeping last maxHistory records per event
+func (s *SQLEventStore) AddHistory(records []types.HistoryRecord) error {
+	log.WithField("records", len(records)).Debug("storing history records")
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	touched := make(map[string]bool)
+	insert := s.query("INSERT INTO cronus_history (uri, fired, data) VALUES (?, ?, ?)")
+	for _, r := range records {
+		v, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		if _, err = tx.Exec(insert, r.URI, r.Time.UnixNano(), string(v)); err != nil {
+			return err
+		}
+		touched[r.URI] = true
+	}
+	// trim old records
+	trim := s.query(fmt.Sprintf(`DELETE FROM cronus_history WHERE uri = ? AND id NOT IN (
+		SELECT id FROM (SELECT id FROM cronus_history WHERE uri = ? ORDER BY fired DESC, id DESC LIMIT %d) AS recent)`, maxHistory))
+	for uri := range touched {
+		if _, err = tx.Exec(trim, uri, uri); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetHistory get last (up to limit) event history records, newest first
+func (s *SQLEventStore) GetHistory(uri string, limit int) ([]types.HistoryRecord, error) {
+	log.WithField("uri", uri).Debug("getting event history from store")
+	q := "SELECT data FROM cronus_history WHERE uri = ? ORDER BY fired DESC, id DESC"
+	if limit > 0 {
+		q += fmt.Sprintf(" LIMIT %d", limit)
+	}
+	all, err := s.scanHistory(s.db.Query(s.query(q), uri))
+	if err != nil {
+		log.WithError(err).Error("failed to get event history")
+		return nil, err
+	}
+	return all, nil
+}
+
+// scanEvents read JSON encoded events from query rows
+func (s *SQLEventStore) scanEvents(rows *sql.Rows, err error) ([]types.Event, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	all := make([]types.Event, 0)
+	for rows.Next() {
+		var data string
+		if err = rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var event types.Event
+		if err = json.Unmarshal([]byte(data), &event); err != nil {
+			log.WithError(err).Error("failed to parse JSON")
+			return nil, err
+		}
+		all = append(all, event)
+	}
+	return all, rows.Err()
+}
+
+// scanHistory read JSON encoded history records from query rows
+func (s *SQLEventStore) scanHistory(rows *sql.Rows, err error) ([]types.HistoryRecord, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	all := make([]types.HistoryRecord, 0)
+	for rows.Next() {
+		var data string
+		if err = rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var r types.HistoryRecord
+		if err = json.Unmarshal([]byte(data), &r); err != nil {
+			log.WithError(err).Error("failed to parse JSON")
+			return nil, err
+		}
+		all = append(all, r)
+	}
+	return all, rows.Err()
+}
@@ -0,0 +1,203 @@
+//go:build cgo
+// +build cgo
+
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codefresh-io/cronus/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupSQLTestCase(t *testing.T) (types.EventStore, func(t *testing.T)) {
+	teardownTestCase, eventsDB := setupTestCase(t)
+	s, err := NewEventStore("sqlite://" + eventsDB)
+	if err != nil {
+		teardownTestCase(t)
+		t.Fatal(err)
+	}
+	return s, func(t *testing.T) {
+		s.Close()
+		teardownTestCase(t)
+	}
+}
+
+func TestSQLEventStore_Events(t *testing.T) {
+	s, teardown := setupSQLTestCase(t)
+	defer teardown(t)
+	events := []types.Event{
+		{
+			Expression:  "5 4 * * *",
+			Message:     "test-message-1",
+			Secret:      "1234",
+			Description: "At 04:05",
+			Status:      "active",
+			Help:        "help",
+		},
+		{
+			Expression:  "5 0 * 8 *",
+			Message:     "test-message-2",
+			Account:     "cb1e73c5215b",
+			Secret:      "1234",
+			Description: "At 00:05 in August",
+			Status:      "active",
+			Help:        "help",
+		},
+	}
+	for _, e := range events {
+		if err := s.StoreEvent(e); err != nil {
+			t.Fatalf("SQLEventStore.StoreEvent() error = %v", err)
+		}
+	}
+	// update existing event
+	events[0].Status = types.StatusFailing
+	events[0].FailureCount = 1
+	if err := s.StoreEvent(events[0]); err != nil {
+		t.Fatalf("SQLEventStore.StoreEvent() error = %v", err)
+	}
+	records, err := s.GetDBStats()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, records)
+	got, err := s.GetEvent(types.GetURI(events[0]))
+	assert.NoError(t, err)
+	assert.Equal(t, &events[0], got)
+	all, err := s.GetAllEvents()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, events, all)
+	// delete
+	assert.NoError(t, s.DeleteEvent(types.GetURI(events[1])))
+	assert.Equal(t, types.ErrEventNotFound, s.DeleteEvent(types.GetURI(events[1])))
+	_, err = s.GetEvent(types.GetURI(events[1]))
+	assert.Equal(t, types.ErrEventNotFound, err)
+}
+
+func TestSQLEventStore_History(t *testing.T) {
+	const uri = "cron:codefresh:5 4 * * *:test-message-1:abcd1234"
+	tests := []struct {
+		name    string
+		records int
+		limit   int
+		want    int
+	}{
+		{name: "no history", records: 0, limit: 10, want: 0},
+		{name: "last records", records: 20, limit: 10, want: 10},
+		{name: "all records", records: 20, limit: 0, want: 20},
+		{name: "trim old records", records: maxHistory + 10, limit: 0, want: maxHistory},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, teardown := setupSQLTestCase(t)
+			defer teardown(t)
+			// add history records
+			start := time.Now()
+			var records []types.HistoryRecord
+			for i := 0; i < tt.records; i++ {
+				records = append(records, types.HistoryRecord{
+					URI:    uri,
+					Time:   start.Add(time.Duration(i) * time.Minute),
+					Result: types.ResultTriggered,
+				})
+			}
+			if err := s.AddHistory(records); err != nil {
+				t.Fatal(err)
+			}
+			// invoke
+			got, err := s.GetHistory(uri, tt.limit)
+			if err != nil {
+				t.Errorf("SQLEventStore.GetHistory() error = %v", err)
+				return
+			}
+			assert.Len(t, got, tt.want)
+			// newest first
+			if len(got) > 0 {
+				assert.True(t, got[0].Time.Equal(records[len(records)-1].Time), "expected newest record first")
+			}
+		})
+	}
+}
+
+func TestSQLEventStore_DeleteEventHistory(t *testing.T) {
+	s, teardown := setupSQLTestCase(t)
+	defer teardown(t)
+	e := types.Event{Expression: "5 4 * * *", Message: "test-message-1", Secret: "1234"}
+	uri := types.GetURI(e)
+	assert.NoError(t, s.StoreEvent(e))
+	assert.NoError(t, s.AddHistory([]types.HistoryRecord{{URI: uri, Time: time.Now(), Result: types.ResultTriggered}}))
+	assert.NoError(t, s.DeleteEvent(uri))
+	got, err := s.GetHistory(uri, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestSQLEventStore_BackupDB(t *testing.T) {
+	s, teardown := setupSQLTestCase(t)
+	defer teardown(t)
+	e := types.Event{Expression: "5 4 * * *", Message: "test-message-1", Secret: "1234", Status: "active"}
+	assert.NoError(t, s.StoreEvent(e))
+	assert.NoError(t, s.AddHistory([]types.HistoryRecord{{URI: types.GetURI(e), Time: time.Unix(1500000000, 0).UTC(), Result: types.ResultTriggered}}))
+	w := &bytes.Buffer{}
+	size, err := s.BackupDB(w)
+	if err != nil {
+		t.Fatalf("SQLEventStore.BackupDB() error = %v", err)
+	}
+	assert.Equal(t, w.Len(), size)
+	var backup dump
+	assert.NoError(t, json.Unmarshal(w.Bytes(), &backup))
+	assert.Equal(t, []types.Event{e}, backup.Events)
+	assert.Len(t, backup.History, 1)
+}
+
+func TestSQLEventStore_Migrate(t *testing.T) {
+	teardownTestCase, eventsDB := setupTestCase(t)
+	defer teardownTestCase(t)
+	// open twice: migrations are applied once
+	for i := 0; i < 2; i++ {
+		s, err := NewSQLEventStore("sqlite3", eventsDB)
+		if err != nil {
+			t.Fatalf("NewSQLEventStore() error = %v", err)
+		}
+		var version, applied int
+		db := s.(*SQLEventStore).db
+		assert.NoError(t, db.QueryRow("SELECT MAX(version), COUNT(*) FROM cronus_schema").Scan(&version, &applied))
+		assert.Equal(t, migrations[len(migrations)-1].version, version)
+		assert.Equal(t, len(migrations), applied)
+		s.Close()
+	}
+}
+
+func TestSQLEventStore_MigratePartiallyApplied(t *testing.T) {
+	teardownTestCase, eventsDB := setupTestCase(t)
+	defer teardownTestCase(t)
+	s, err := NewSQLEventStore("sqlite3", eventsDB)
+	if err != nil {
+		t.Fatalf("NewSQLEventStore() error = %v", err)
+	}
+	// schema changes applied, but version not recorded, like MySQL migration failed after implicit DDL commit
+	_, err = s.(*SQLEventStore).db.Exec("DELETE FROM cronus_schema")
+	assert.NoError(t, err)
+	s.Close()
+
+	s, err = NewSQLEventStore("sqlite3", eventsDB)
+	if !assert.NoError(t, err, "re-applying migrations") {
+		return
+	}
+	defer s.Close()
+	var applied int
+	assert.NoError(t, s.(*SQLEventStore).db.QueryRow("SELECT COUNT(*) FROM cronus_schema").Scan(&applied))
+	assert.Equal(t, len(migrations), applied)
+}
+
+func TestSQLEventStore_Conformance(t *testing.T) {
+	testEventStore(t, func(t *testing.T, dir string) types.EventStore {
+		s, err := NewEventStore("sqlite://" + filepath.Join(dir, "events.sqlite"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	})
+}
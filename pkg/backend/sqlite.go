@@ -0,0 +1,9 @@
+//go:build cgo
+// +build cgo
+
+package backend
+
+import (
+	// register SQLite driver; requires cgo
+	_ "github.com/mattn/go-sqlite3"
+)
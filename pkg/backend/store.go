@@ -1,9 +1,12 @@
 package backend
 
 import (
+	"bytes"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"io"
+	"sync"
 
 	"github.com/boltdb/bolt"
 	"github.com/codefresh-io/cronus/pkg/types"
@@ -13,17 +16,24 @@ import (
 type (
 	// BoltEventStore BoltDB store
 	BoltEventStore struct {
+		file string
+		// mu guards db, replaced on restore
+		mu sync.RWMutex
 		db *bolt.DB
 	}
 )
 
 var events = []byte("events")
+var history = []byte("history")
+
+// maxHistory max number of history records kept per event
+const maxHistory = 100
 
 // NewBoltEventStore new BoldDB store
 func NewBoltEventStore(file string) (types.EventStore, error) {
 	log.WithField("store", file).Debug("starting BoltDB")
 	db, err := setupDB(file)
-	return &BoltEventStore{db}, err
+	return &BoltEventStore{file: file, db: db}, err
 }
 
 func setupDB(file string) (*bolt.DB, error) {
@@ -33,27 +43,71 @@ func setupDB(file string) (*bolt.DB, error) {
 		return nil, fmt.Errorf("failed to open db, %v", err)
 	}
 	err = db.Update(func(tx *bolt.Tx) error {
+		// new database has current schema
+		if tx.Bucket(events) == nil && tx.Bucket(meta) == nil {
+			if err := writeVersion(tx, BoltSchemaVersion()); err != nil {
+				return fmt.Errorf("failed to create meta bucket: %v", err)
+			}
+		}
 		_, err := tx.CreateBucketIfNotExists(events)
 		if err != nil {
 			log.WithError(err).Error("failed to create events bucket")
 			return fmt.Errorf("failed to create events bucket: %v", err)
 		}
+		_, err = tx.CreateBucketIfNotExists(history)
+		if err != nil {
+			log.WithError(err).Error("failed to create history bucket")
+			return fmt.Errorf("failed to create history bucket: %v", err)
+		}
+		for _, name := range indexBuckets {
+			if _, err = tx.CreateBucketIfNotExists(name); err != nil {
+				log.WithError(err).Error("failed to create index bucket")
+				return fmt.Errorf("failed to create index bucket: %v", err)
+			}
+		}
 		return nil
 	})
 	if err != nil {
 		log.WithError(err).Error("failed to setup db")
 		return nil, fmt.Errorf("failed to set up db, %v", err)
 	}
+	if err = migrateBolt(db, file); err != nil {
+		log.WithError(err).Error("failed to migrate db")
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate db, %v", err)
+	}
 
 	log.Debug("setup db done")
 	return db, nil
 }
 
+// view run read-only transaction
+func (b *BoltEventStore) view(fn func(*bolt.Tx) error) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.db.View(fn)
+}
+
+// update run read-write transaction
+func (b *BoltEventStore) update(fn func(*bolt.Tx) error) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.db.Update(fn)
+}
+
+// Close close BoltDB database, releasing file lock
+func (b *BoltEventStore) Close() error {
+	log.Debug("closing database")
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.db.Close()
+}
+
 // BackupDB backup BoltDB database
 func (b *BoltEventStore) BackupDB(w io.Writer) (int, error) {
 	log.Debug("database backup")
 	var size int
-	err := b.db.View(func(tx *bolt.Tx) error {
+	err := b.view(func(tx *bolt.Tx) error {
 		size = int(tx.Size())
 		_, err := tx.WriteTo(w)
 		return err
@@ -70,32 +124,93 @@ func (b *BoltEventStore) StoreEvent(event types.Event) error {
 		"expression": event.Expression,
 		"message":    event.Message,
 	}).Debug("storing new event")
-	return b.db.Update(func(tx *bolt.Tx) error {
+	return b.update(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket(events)
+		key := []byte(types.GetURI(event))
+		if err := reindexEvent(tx, bucket.Get(key), event); err != nil {
+			return err
+		}
 		v, _ := json.Marshal(event)
-		return bucket.Put([]byte(types.GetURI(event)), v)
+		return bucket.Put(key, v)
 	})
 }
 
 // DeleteEvent delete event record from BoltDB
 func (b *BoltEventStore) DeleteEvent(uri string) error {
 	log.WithField("uri", uri).Debug("deleting event from store")
-	return b.db.Update(func(tx *bolt.Tx) error {
+	return b.update(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket(events)
 		v := bucket.Get([]byte(uri))
 		if v == nil {
 			log.WithField("uri", uri).Error("event not found")
 			return types.ErrEventNotFound
 		}
+		// delete index entries
+		var event types.Event
+		if json.Unmarshal(v, &event) == nil {
+			if err := unindexEvent(tx, event); err != nil {
+				return err
+			}
+		}
+		// delete event history
+		err := tx.Bucket(history).DeleteBucket([]byte(uri))
+		if err != nil && err != bolt.ErrBucketNotFound {
+			return err
+		}
 		return bucket.Delete([]byte(uri))
 	})
 }
 
+// DeleteAccountEvents delete all account events (found with account index) and their history in single
+// transaction; deleted events are returned
+func (b *BoltEventStore) DeleteAccountEvents(account string) ([]types.Event, error) {
+	log.WithField("account", account).Debug("deleting account events from store")
+	deleted := make([]types.Event, 0)
+	err := b.update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(events)
+		prefix := indexKey(account, "")
+		// collect keys first: bucket must not be changed while iterating with cursor
+		var uris []string
+		c := tx.Bucket(accountIndex).Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			uris = append(uris, string(k[len(prefix):]))
+		}
+		for _, uri := range uris {
+			v := bucket.Get([]byte(uri))
+			if v == nil {
+				continue
+			}
+			var event types.Event
+			if err := json.Unmarshal(v, &event); err != nil {
+				log.WithError(err).Error("failed to parse JSON")
+				return err
+			}
+			if err := unindexEvent(tx, event); err != nil {
+				return err
+			}
+			err := tx.Bucket(history).DeleteBucket([]byte(uri))
+			if err != nil && err != bolt.ErrBucketNotFound {
+				return err
+			}
+			if err = bucket.Delete([]byte(uri)); err != nil {
+				return err
+			}
+			deleted = append(deleted, event)
+		}
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("failed to delete account events")
+		return nil, err
+	}
+	return deleted, nil
+}
+
 // GetEvent get event record
 func (b *BoltEventStore) GetEvent(uri string) (*types.Event, error) {
 	var event types.Event
 	log.WithField("uri", uri).Debug("getting event from store")
-	err := b.db.View(func(tx *bolt.Tx) error {
+	err := b.view(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket(events)
 		v := bucket.Get([]byte(uri))
 		if v == nil {
@@ -115,7 +230,7 @@ func (b *BoltEventStore) GetEvent(uri string) (*types.Event, error) {
 func (b *BoltEventStore) GetAllEvents() ([]types.Event, error) {
 	log.Debug("getting all events from store")
 	all := make([]types.Event, 0)
-	b.db.View(func(tx *bolt.Tx) error {
+	b.view(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket(events)
 		bucket.ForEach(func(k, v []byte) error {
 			var event types.Event
@@ -135,7 +250,7 @@ func (b *BoltEventStore) GetAllEvents() ([]types.Event, error) {
 // GetDBStats get number of records
 func (b *BoltEventStore) GetDBStats() (int, error) {
 	var records int
-	err := b.db.View(func(tx *bolt.Tx) error {
+	err := b.view(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket(events)
 		stats := bucket.Stats()
 		records = stats.KeyN
@@ -146,3 +261,77 @@ func (b *BoltEventStore) GetDBStats() (int, error) {
 	}
 	return records, err
 }
+
+// AddHistory store event history records, keeping last maxHistory records per event
+func (b *BoltEventStore) AddHistory(records []types.HistoryRecord) error {
+	log.WithField("records", len(records)).Debug("storing history records")
+	return b.update(func(tx *bolt.Tx) error {
+		touched := make(map[string]*bolt.Bucket)
+		for _, r := range records {
+			bucket, err := tx.Bucket(history).CreateBucketIfNotExists([]byte(r.URI))
+			if err != nil {
+				return err
+			}
+			seq, err := bucket.NextSequence()
+			if err != nil {
+				return err
+			}
+			// key: {fire time}{sequence} - keeps records ordered by time
+			key := make([]byte, 16)
+			binary.BigEndian.PutUint64(key, uint64(r.Time.UnixNano()))
+			binary.BigEndian.PutUint64(key[8:], seq)
+			v, err := json.Marshal(r)
+			if err != nil {
+				return err
+			}
+			if err = bucket.Put(key, v); err != nil {
+				return err
+			}
+			touched[r.URI] = bucket
+		}
+		// trim old records
+		for _, bucket := range touched {
+			var keys [][]byte
+			c := bucket.Cursor()
+			for k, _ := c.First(); k != nil; k, _ = c.Next() {
+				keys = append(keys, append([]byte(nil), k...))
+			}
+			if len(keys) <= maxHistory {
+				continue
+			}
+			for _, k := range keys[:len(keys)-maxHistory] {
+				if err := bucket.Delete(k); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// GetHistory get last (up to limit) event history records, newest first
+func (b *BoltEventStore) GetHistory(uri string, limit int) ([]types.HistoryRecord, error) {
+	log.WithField("uri", uri).Debug("getting event history from store")
+	all := make([]types.HistoryRecord, 0)
+	err := b.view(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(history).Bucket([]byte(uri))
+		if bucket == nil {
+			return nil
+		}
+		c := bucket.Cursor()
+		for k, v := c.Last(); k != nil && (limit <= 0 || len(all) < limit); k, v = c.Prev() {
+			var r types.HistoryRecord
+			if err := json.Unmarshal(v, &r); err != nil {
+				log.WithError(err).Error("failed to parse JSON")
+				return err
+			}
+			all = append(all, r)
+		}
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("failed to get event history")
+		return nil, err
+	}
+	return all, nil
+}
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/codefresh-io/cronus/pkg/types"
 	"github.com/stretchr/testify/assert"
@@ -410,3 +411,53 @@ func TestBoltEventStore_BackupDB(t *testing.T) {
 		})
 	}
 }
+
+func TestBoltEventStore_History(t *testing.T) {
+	const uri = "cron:codefresh:5 4 * * *:test-message-1:abcd1234"
+	tests := []struct {
+		name    string
+		records int
+		limit   int
+		want    int
+	}{
+		{name: "no history", records: 0, limit: 10, want: 0},
+		{name: "last records", records: 20, limit: 10, want: 10},
+		{name: "all records", records: 20, limit: 0, want: 20},
+		{name: "trim old records", records: maxHistory + 10, limit: 0, want: maxHistory},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// setup and tear down the test case
+			teardownTestCase, eventsDB := setupTestCase(t)
+			defer teardownTestCase(t)
+			b, err := NewBoltEventStore(eventsDB)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// add history records
+			start := time.Now()
+			var records []types.HistoryRecord
+			for i := 0; i < tt.records; i++ {
+				records = append(records, types.HistoryRecord{
+					URI:    uri,
+					Time:   start.Add(time.Duration(i) * time.Minute),
+					Result: types.ResultTriggered,
+				})
+			}
+			if err = b.AddHistory(records); err != nil {
+				t.Fatal(err)
+			}
+			// invoke
+			got, err := b.GetHistory(uri, tt.limit)
+			if err != nil {
+				t.Errorf("BoltEventStore.GetHistory() error = %v", err)
+				return
+			}
+			assert.Len(t, got, tt.want)
+			// newest first
+			if len(got) > 0 {
+				assert.True(t, got[0].Time.Equal(records[len(records)-1].Time), "expected newest record first")
+			}
+		})
+	}
+}
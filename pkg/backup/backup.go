@@ -0,0 +1,166 @@
+package backup
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codefresh-io/cronus/pkg/types"
+	log "github.com/sirupsen/logrus"
+)
+
+type (
+	// Object stored backup
+	Object struct {
+		Name string    `json:"name"`
+		Size int64     `json:"size"`
+		Time time.Time `json:"time"`
+	}
+
+	// Sink backup storage
+	Sink interface {
+		// Put store backup object
+		Put(name string, r io.ReadSeeker, size int64) error
+		// List list stored objects
+		List() ([]Object, error)
+		// Delete delete stored object
+		Delete(name string) error
+	}
+
+	// Result last backup result
+	Result struct {
+		Time   time.Time `json:"time"`
+		Object *Object   `json:"object,omitempty"`
+		Error  string    `json:"error,omitempty"`
+	}
+
+	// Manager take compressed store snapshots into sink, keeping last backups
+	Manager struct {
+		store types.EventStore
+		sink  Sink
+		keep  int
+		now   func() time.Time
+		// mu serializes backups and guards last
+		mu   sync.Mutex
+		last *Result
+	}
+)
+
+// backup object name: {prefix}{UTC time}{suffix}; names are sorted by time
+const (
+	namePrefix = "cronus-"
+	nameSuffix = ".backup.gz"
+	timeFormat = "20060102T150405Z"
+)
+
+// NewManager create backup manager; keep - number of backups to keep (0 - keep all)
+func NewManager(store types.EventStore, sink Sink, keep int) *Manager {
+	return &Manager{store: store, sink: sink, keep: keep, now: time.Now}
+}
+
+// Backup take consistent store snapshot (BackupDB), compress it and upload to sink; old backups are deleted
+func (m *Manager) Backup() (*Object, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now().UTC()
+	obj, err := m.backup(now)
+	m.last = &Result{Time: now, Object: obj}
+	if err != nil {
+		log.WithError(err).Error("failed to backup event store")
+		m.last.Error = err.Error()
+		return nil, err
+	}
+	log.WithFields(log.Fields{"name": obj.Name, "size": obj.Size}).Info("event store backup completed")
+	if err = m.prune(); err != nil {
+		log.WithError(err).Warn("failed to delete old backups")
+	}
+	return obj, nil
+}
+
+func (m *Manager) backup(now time.Time) (*Object, error) {
+	f, err := ioutil.TempFile("", "cronus-backup")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	zw := gzip.NewWriter(f)
+	if _, err = m.store.BackupDB(zw); err != nil {
+		return nil, err
+	}
+	if err = zw.Close(); err != nil {
+		return nil, err
+	}
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	obj := &Object{Name: namePrefix + now.Format(timeFormat) + nameSuffix, Size: size, Time: now}
+	if err = m.sink.Put(obj.Name, f, size); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// prune delete backups older than last keep backups
+func (m *Manager) prune() error {
+	if m.keep <= 0 {
+		return nil
+	}
+	all, err := m.List()
+	if err != nil {
+		return err
+	}
+	for i := m.keep; i < len(all); i++ {
+		log.WithField("name", all[i].Name).Debug("deleting old backup")
+		if err = m.sink.Delete(all[i].Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List list cronus backups in sink, newest first
+func (m *Manager) List() ([]Object, error) {
+	all, err := m.sink.List()
+	if err != nil {
+		return nil, err
+	}
+	backups := make([]Object, 0, len(all))
+	for _, o := range all {
+		if strings.HasPrefix(o.Name, namePrefix) && strings.HasSuffix(o.Name, nameSuffix) {
+			backups = append(backups, o)
+		}
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Name > backups[j].Name })
+	return backups, nil
+}
+
+// Last last backup result; nil if no backup was taken yet
+func (m *Manager) Last() *Result {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.last
+}
+
+// Run take backups periodically, until stopped
+func (m *Manager) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.Backup()
+		case <-stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codefresh-io/cronus/pkg/backend"
+	"github.com/codefresh-io/cronus/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingSink sink that fails uploads
+type failingSink struct{ LocalSink }
+
+func (s *failingSink) Put(name string, r io.ReadSeeker, size int64) error {
+	return errors.New("upload failed")
+}
+
+func TestManager_Backup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backups")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store, _ := backend.NewMemoryEventStore("")
+	e := types.Event{Expression: "0 0 4 * * *", Message: "test-message-1", Secret: "1234"}
+	store.StoreEvent(e)
+	sink, err := NewLocalSink(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// unrelated file is not listed nor deleted
+	ioutil.WriteFile(filepath.Join(dir, "other.txt"), []byte("x"), 0600)
+	m := NewManager(store, sink, 2)
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	assert.Nil(t, m.Last())
+	for i := 0; i < 3; i++ {
+		obj, err := m.Backup()
+		assert.NoError(t, err)
+		assert.Equal(t, "cronus-2018010"+string('1'+rune(i))+"T000000Z.backup.gz", obj.Name)
+		now = now.Add(24 * time.Hour)
+	}
+	// keep last 2 backups, newest first
+	all, err := m.List()
+	assert.NoError(t, err)
+	if assert.Equal(t, 2, len(all)) {
+		assert.Equal(t, "cronus-20180103T000000Z.backup.gz", all[0].Name)
+		assert.Equal(t, "cronus-20180102T000000Z.backup.gz", all[1].Name)
+	}
+	_, err = os.Stat(filepath.Join(dir, "other.txt"))
+	assert.NoError(t, err)
+	// backup is compressed store dump
+	f, _ := os.Open(filepath.Join(dir, all[0].Name))
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if assert.NoError(t, err) {
+		data, _ := ioutil.ReadAll(zr)
+		var want bytes.Buffer
+		store.BackupDB(&want)
+		assert.Equal(t, want.String(), string(data))
+	}
+	assert.Equal(t, "", m.Last().Error)
+	// failed backup
+	m.sink = &failingSink{*sink}
+	_, err = m.Backup()
+	assert.Error(t, err)
+	assert.Equal(t, "upload failed", m.Last().Error)
+}
@@ -0,0 +1,64 @@
+package backup
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+type (
+	// LocalSink store backups in local directory (mounted volume)
+	LocalSink struct {
+		dir string
+	}
+)
+
+// NewLocalSink create local directory sink; directory is created, if missing
+func NewLocalSink(dir string) (*LocalSink, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &LocalSink{dir: dir}, nil
+}
+
+// Put write backup file atomically
+func (s *LocalSink) Put(name string, r io.ReadSeeker, size int64) error {
+	f, err := ioutil.TempFile(s.dir, ".tmp-"+name)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(f, r); err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filepath.Join(s.dir, name))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// List list backup files
+func (s *LocalSink) List() ([]Object, error) {
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var all []Object
+	for _, f := range files {
+		if f.Mode().IsRegular() {
+			all = append(all, Object{Name: f.Name(), Size: f.Size(), Time: f.ModTime().UTC()})
+		}
+	}
+	return all, nil
+}
+
+// Delete delete backup file
+func (s *LocalSink) Delete(name string) error {
+	return os.Remove(filepath.Join(s.dir, filepath.Base(name)))
+}
@@ -0,0 +1,148 @@
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type (
+	// S3Config S3 compatible object storage settings
+	S3Config struct {
+		// Endpoint storage URL; default: AWS S3 endpoint of region
+		Endpoint string
+		// Region bucket region; default: us-east-1
+		Region string
+		// Bucket bucket name
+		Bucket string
+		// Prefix object key prefix (folder)
+		Prefix string
+		// AccessKey access key ID
+		AccessKey string
+		// SecretKey secret access key
+		SecretKey string
+	}
+
+	// S3Sink store backups in S3 compatible object storage (AWS S3, MinIO, ...), using path style requests
+	// signed with AWS SigV4
+	S3Sink struct {
+		cfg  S3Config
+		http *http.Client
+		now  func() time.Time
+	}
+
+	listBucketResult struct {
+		Contents []struct {
+			Key          string    `xml:"Key"`
+			LastModified time.Time `xml:"LastModified"`
+			Size         int64     `xml:"Size"`
+		} `xml:"Contents"`
+		IsTruncated           bool   `xml:"IsTruncated"`
+		NextContinuationToken string `xml:"NextContinuationToken"`
+	}
+)
+
+// NewS3Sink create S3 sink
+func NewS3Sink(cfg S3Config) (*S3Sink, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("S3 bucket is required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+	cfg.Endpoint = strings.TrimSuffix(cfg.Endpoint, "/")
+	if cfg.Prefix != "" && !strings.HasSuffix(cfg.Prefix, "/") {
+		cfg.Prefix += "/"
+	}
+	return &S3Sink{cfg: cfg, http: &http.Client{Timeout: 10 * time.Minute}, now: time.Now}, nil
+}
+
+// do send signed request to bucket; non 2xx response is returned as error
+func (s *S3Sink) do(method, key string, query url.Values, body io.Reader, size int64, payloadHash string) (*http.Response, error) {
+	u, err := url.Parse(s.cfg.Endpoint + "/" + s.cfg.Bucket + "/" + key)
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery = query.Encode()
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	signV4(req, payloadHash, s.cfg.AccessKey, s.cfg.SecretKey, s.cfg.Region, "s3", s.now())
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("S3 %s %s failed (%d): %s", method, u.Path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
+
+// Put upload backup object
+func (s *S3Sink) Put(name string, r io.ReadSeeker, size int64) error {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	resp, err := s.do(http.MethodPut, s.cfg.Prefix+name, nil, r, size, hex.EncodeToString(h.Sum(nil)))
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// List list objects under prefix (ListObjectsV2)
+func (s *S3Sink) List() ([]Object, error) {
+	var all []Object
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {s.cfg.Prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := s.do(http.MethodGet, "", query, nil, 0, emptyPayloadHash)
+		if err != nil {
+			return nil, err
+		}
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("bad S3 list response: %v", err)
+		}
+		for _, c := range result.Contents {
+			all = append(all, Object{Name: strings.TrimPrefix(c.Key, s.cfg.Prefix), Size: c.Size, Time: c.LastModified})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return all, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// Delete delete backup object
+func (s *S3Sink) Delete(name string) error {
+	resp, err := s.do(http.MethodDelete, s.cfg.Prefix+name, nil, nil, 0, emptyPayloadHash)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
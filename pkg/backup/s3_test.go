@@ -0,0 +1,118 @@
+package backup
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_signV4(t *testing.T) {
+	// AWS SigV4 documentation example
+	req, _ := http.NewRequest(http.MethodGet, "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	signV4(req, emptyPayloadHash, "AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "us-east-1", "iam", now)
+	assert.Equal(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, "+
+		"SignedHeaders=content-type;host;x-amz-date, "+
+		"Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7", req.Header.Get("Authorization"))
+}
+
+// fakeS3 minimal S3 compatible server (put, delete and list-type=2 list, in single bucket); verifies request
+// signatures
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	bucket  string
+	secret  string
+	now     time.Time
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	// verify signature: sign received request again
+	check, _ := http.NewRequest(r.Method, "http://"+r.Host+r.URL.RequestURI(), nil)
+	for _, name := range []string{"X-Amz-Content-Sha256"} {
+		check.Header.Set(name, r.Header.Get(name))
+	}
+	signV4(check, r.Header.Get("X-Amz-Content-Sha256"), "access", f.secret, "us-east-1", "s3", f.now)
+	if check.Header.Get("Authorization") != r.Header.Get("Authorization") {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, "<Error><Code>SignatureDoesNotMatch</Code></Error>")
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, "/"+f.bucket+"/") {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, "/"+f.bucket+"/")
+	switch r.Method {
+	case http.MethodPut:
+		f.objects[key], _ = ioutil.ReadAll(r.Body)
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodGet:
+		// list objects, 2 per page
+		prefix := r.URL.Query().Get("prefix")
+		var keys []string
+		for k := range f.objects {
+			if strings.HasPrefix(k, prefix) && k > r.URL.Query().Get("continuation-token") {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		truncated := len(keys) > 2
+		if truncated {
+			keys = keys[:2]
+		}
+		fmt.Fprint(w, "<ListBucketResult>")
+		for _, k := range keys {
+			fmt.Fprintf(w, "<Contents><Key>%s</Key><LastModified>2018-01-01T00:00:00.000Z</LastModified><Size>%d</Size></Contents>", k, len(f.objects[k]))
+		}
+		if truncated {
+			fmt.Fprintf(w, "<IsTruncated>true</IsTruncated><NextContinuationToken>%s</NextContinuationToken>", keys[1])
+		}
+		fmt.Fprint(w, "</ListBucketResult>")
+	}
+}
+
+func TestS3Sink(t *testing.T) {
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := &fakeS3{objects: map[string][]byte{"other/file": []byte("x")}, bucket: "backups", secret: "secret", now: now}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	s, err := NewS3Sink(S3Config{Endpoint: server.URL, Bucket: "backups", Prefix: "cronus", AccessKey: "access", SecretKey: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.now = func() time.Time { return now }
+	for _, name := range []string{"a", "b", "c"} {
+		assert.NoError(t, s.Put(name, bytes.NewReader([]byte("data-"+name)), 6))
+	}
+	assert.Equal(t, []byte("data-b"), fake.objects["cronus/b"])
+	all, err := s.List()
+	assert.NoError(t, err)
+	assert.Equal(t, []Object{
+		{Name: "a", Size: 6, Time: now},
+		{Name: "b", Size: 6, Time: now},
+		{Name: "c", Size: 6, Time: now},
+	}, all)
+	assert.NoError(t, s.Delete("a"))
+	assert.Equal(t, 3, len(fake.objects))
+	// bad credentials
+	s.cfg.SecretKey = "other"
+	err = s.Put("d", bytes.NewReader([]byte("data-d")), 6)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "SignatureDoesNotMatch")
+}
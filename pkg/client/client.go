@@ -0,0 +1,247 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codefresh-io/cronus/pkg/transfer"
+	"github.com/codefresh-io/cronus/pkg/types"
+)
+
+type (
+	// Client cronus REST API client
+	Client struct {
+		url   string
+		token string
+		http  *http.Client
+	}
+
+	// APIError cronus API error response
+	APIError struct {
+		StatusCode int
+		Message    string
+	}
+
+	// ListOptions event list filters
+	ListOptions struct {
+		// Account events of account
+		Account string
+		// Status events with status
+		Status string
+		// Due events with next fire time within duration (ordered by next fire time)
+		Due time.Duration
+	}
+
+	// SubscribeOptions event subscription options
+	SubscribeOptions struct {
+		// Jitter random delay window
+		Jitter string
+		// Concurrency concurrency policy (Allow, Forbid, Replace)
+		Concurrency string
+		// Until event end time (RFC3339)
+		Until string
+		// Dialect cron expression dialect (crontab or seconds)
+		Dialect string
+	}
+)
+
+// NewClient create cronus API client; token (optional) is sent as bearer token
+func NewClient(serverURL, token string) *Client {
+	if !strings.HasPrefix(serverURL, "http://") && !strings.HasPrefix(serverURL, "https://") {
+		serverURL = "http://" + serverURL
+	}
+	return &Client{
+		url:   strings.TrimSuffix(serverURL, "/"),
+		token: token,
+		http:  &http.Client{Timeout: time.Minute},
+	}
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("cronus API error (%d): %s", e.StatusCode, e.Message)
+}
+
+// do send API request; non 2xx response is returned as APIError
+func (c *Client) do(method, path string, query url.Values, body io.Reader, contentType string) (*http.Response, error) {
+	u := c.url + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		data, _ := ioutil.ReadAll(resp.Body)
+		var msg struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &msg) == nil && msg.Error != "" {
+			apiErr.Message = msg.Error
+		} else {
+			apiErr.Message = strings.TrimSpace(string(data))
+		}
+		return nil, apiErr
+	}
+	return resp, nil
+}
+
+// EscapeURI URL encode event URI as path parameter: all characters, except unreserved ones, are percent
+// encoded (including ':', '/' and space)
+func EscapeURI(uri string) string {
+	return strings.Replace(url.QueryEscape(uri), "+", "%20", -1)
+}
+
+// call send API request and decode JSON response into out (if not nil)
+func (c *Client) call(method, path string, query url.Values, out interface{}) error {
+	resp, err := c.do(method, path, query, nil, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		return nil
+	}
+	if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("bad %s %s response: %v", method, path, err)
+	}
+	return nil
+}
+
+// ListEvents list events, filtered by options
+func (c *Client) ListEvents(opts ListOptions) ([]types.Event, error) {
+	query := url.Values{}
+	if opts.Account != "" {
+		query.Set("account", opts.Account)
+	}
+	if opts.Status != "" {
+		query.Set("status", opts.Status)
+	}
+	if opts.Due > 0 {
+		query.Set("due", opts.Due.String())
+	}
+	var events []types.Event
+	err := c.call(http.MethodGet, "/events", query, &events)
+	return events, err
+}
+
+// GetEvent get event
+func (c *Client) GetEvent(uri string) (*types.Event, error) {
+	var event types.Event
+	if err := c.call(http.MethodGet, "/event/"+EscapeURI(uri), nil, &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// Subscribe subscribe to event (with secret, passed to Hermes on trigger)
+func (c *Client) Subscribe(uri, secret string, opts SubscribeOptions) (*types.Event, error) {
+	query := url.Values{}
+	if opts.Jitter != "" {
+		query.Set("jitter", opts.Jitter)
+	}
+	if opts.Concurrency != "" {
+		query.Set("concurrency", opts.Concurrency)
+	}
+	if opts.Until != "" {
+		query.Set("until", opts.Until)
+	}
+	if opts.Dialect != "" {
+		query.Set("dialect", opts.Dialect)
+	}
+	var event types.Event
+	if err := c.call(http.MethodPost, "/event/"+EscapeURI(uri)+"/"+EscapeURI(secret)+"/", query, &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// Unsubscribe unsubscribe from event
+func (c *Client) Unsubscribe(uri string) error {
+	return c.call(http.MethodDelete, "/event/"+EscapeURI(uri)+"/", nil, nil)
+}
+
+// Trigger trigger event now
+func (c *Client) Trigger(uri string) error {
+	return c.call(http.MethodPost, "/trigger/"+EscapeURI(uri), nil, nil)
+}
+
+// Pause pause event
+func (c *Client) Pause(uri string) error {
+	return c.call(http.MethodPost, "/pause/"+EscapeURI(uri), nil, nil)
+}
+
+// Resume resume paused event
+func (c *Client) Resume(uri string) error {
+	return c.call(http.MethodPost, "/resume/"+EscapeURI(uri), nil, nil)
+}
+
+// History get last event fire records (newest first)
+func (c *Client) History(uri string, limit int) ([]types.HistoryRecord, error) {
+	var records []types.HistoryRecord
+	err := c.call(http.MethodGet, "/history/"+EscapeURI(uri), url.Values{"limit": {strconv.Itoa(limit)}}, &records)
+	return records, err
+}
+
+// Export write export document (of account, if not empty) in json or ndjson format
+func (c *Client) Export(account, format string, w io.Writer) error {
+	query := url.Values{"format": {format}}
+	if account != "" {
+		query.Set("account", account)
+	}
+	resp, err := c.do(http.MethodGet, "/export", query, nil, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// DeleteAccountEvents delete all account events; in dry run mode events are only listed
+func (c *Client) DeleteAccountEvents(account string, dryRun bool) (*types.AccountEvents, error) {
+	query := url.Values{"dry-run": {strconv.FormatBool(dryRun)}}
+	var result types.AccountEvents
+	if err := c.call(http.MethodDelete, "/accounts/"+url.PathEscape(account)+"/events", query, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Import import export document (in json or ndjson format) with merge or replace mode
+func (c *Client) Import(r io.Reader, format, mode string, dryRun bool) (*transfer.Report, error) {
+	query := url.Values{
+		"format":  {format},
+		"mode":    {mode},
+		"dry-run": {strconv.FormatBool(dryRun)},
+	}
+	resp, err := c.do(http.MethodPost, "/import", query, r, transfer.ContentType(format))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var report transfer.Report
+	if err = json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return nil, fmt.Errorf("bad import report: %v", err)
+	}
+	return &report, nil
+}
@@ -0,0 +1,117 @@
+package client
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codefresh-io/cronus/pkg/transfer"
+	"github.com/codefresh-io/cronus/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient(t *testing.T) {
+	var got *http.Request
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		data, _ := ioutil.ReadAll(r.Body)
+		body = string(data)
+		switch r.URL.Path {
+		case "/export":
+			w.Write([]byte(`{"version":1}`))
+		case "/import":
+			w.Write([]byte(`{"mode":"merge","added":["uri"],"unchanged":1}`))
+		case "/accounts/acc/1/events":
+			w.Write([]byte(`{"account":"acc/1","dryRun":true,"events":[{"expression":"0 0 4 * * *","message":"m","account":"acc/1"}]}`))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error":"missing 'admin' scope"}`))
+		}
+	}))
+	defer server.Close()
+	c := NewClient(server.URL+"/", "token")
+
+	var buf bytes.Buffer
+	assert.NoError(t, c.Export("acc1", transfer.FormatNDJSON, &buf))
+	assert.Equal(t, `{"version":1}`, buf.String())
+	assert.Equal(t, "Bearer token", got.Header.Get("Authorization"))
+	assert.Equal(t, "acc1", got.URL.Query().Get("account"))
+	assert.Equal(t, "ndjson", got.URL.Query().Get("format"))
+
+	report, err := c.Import(bytes.NewBufferString("doc"), transfer.FormatJSON, transfer.ModeMerge, true)
+	assert.NoError(t, err)
+	assert.Equal(t, &transfer.Report{Mode: "merge", Added: []string{"uri"}, Unchanged: 1}, report)
+	assert.Equal(t, http.MethodPost, got.Method)
+	assert.Equal(t, "true", got.URL.Query().Get("dry-run"))
+	assert.Equal(t, "application/json", got.Header.Get("Content-Type"))
+	assert.Equal(t, "doc", body)
+
+	result, err := c.DeleteAccountEvents("acc/1", true)
+	assert.NoError(t, err)
+	assert.Equal(t, &types.AccountEvents{Account: "acc/1", DryRun: true, Events: []types.Event{{Expression: "0 0 4 * * *", Message: "m", Account: "acc/1"}}}, result)
+	assert.Equal(t, http.MethodDelete, got.Method)
+	assert.Equal(t, "/accounts/acc%2F1/events", got.URL.RawPath)
+	assert.Equal(t, "true", got.URL.Query().Get("dry-run"))
+
+	_, err = c.do(http.MethodGet, "/other", nil, nil, "")
+	assert.Equal(t, &APIError{StatusCode: http.StatusForbidden, Message: "missing 'admin' scope"}, err)
+}
+
+func TestEscapeURI(t *testing.T) {
+	assert.Equal(t, "cron%3Acodefresh%3A0%200%20%2A%2F4%20%2A%20%2A%20%2A%3Amsg%3Aacc", EscapeURI("cron:codefresh:0 0 */4 * * *:msg:acc"))
+}
+
+func TestClient_Events(t *testing.T) {
+	uri := "cron:codefresh:0 0 4 * * *:msg:acc1"
+	escaped := EscapeURI(uri)
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Method+" "+r.URL.EscapedPath()+"?"+r.URL.RawQuery)
+		switch {
+		case r.URL.Path == "/events":
+			w.Write([]byte(`[{"expression":"0 0 4 * * *","message":"msg","account":"acc1"}]`))
+		case strings.HasPrefix(r.URL.Path, "/history/"):
+			w.Write([]byte(`[{"uri":"` + uri + `","time":"2020-01-01T04:00:00Z","result":"triggered"}]`))
+		case r.Method == http.MethodGet, strings.HasPrefix(r.URL.Path, "/event/"):
+			w.Write([]byte(`{"expression":"0 0 4 * * *","message":"msg","account":"acc1","status":"active"}`))
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+	c := NewClient(server.URL, "")
+	event := types.Event{Expression: "0 0 4 * * *", Message: "msg", Account: "acc1"}
+
+	events, err := c.ListEvents(ListOptions{Account: "acc1", Due: time.Hour})
+	assert.NoError(t, err)
+	assert.Equal(t, []types.Event{event}, events)
+	event.Status = types.StatusActive
+	e, err := c.GetEvent(uri)
+	assert.NoError(t, err)
+	assert.Equal(t, &event, e)
+	e, err = c.Subscribe(uri, "s/1", SubscribeOptions{Concurrency: "Forbid", Dialect: "seconds"})
+	assert.NoError(t, err)
+	assert.Equal(t, &event, e)
+	assert.NoError(t, c.Unsubscribe(uri))
+	assert.NoError(t, c.Trigger(uri))
+	assert.NoError(t, c.Pause(uri))
+	assert.NoError(t, c.Resume(uri))
+	records, err := c.History(uri, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.HistoryRecord{{URI: uri, Time: time.Date(2020, 1, 1, 4, 0, 0, 0, time.UTC), Result: types.ResultTriggered}}, records)
+	assert.Equal(t, []string{
+		"GET /events?account=acc1&due=1h0m0s",
+		"GET /event/" + escaped + "?",
+		"POST /event/" + escaped + "/s%2F1/?concurrency=Forbid&dialect=seconds",
+		"DELETE /event/" + escaped + "/?",
+		"POST /trigger/" + escaped + "?",
+		"POST /pause/" + escaped + "?",
+		"POST /resume/" + escaped + "?",
+		"GET /history/" + escaped + "?limit=5",
+	}, got)
+}
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	yaml "gopkg.in/yaml.v2"
+)
+
+type (
+	// Config cronus server config file; unset values keep command line flag values
+	Config struct {
+		Hermes Hermes `yaml:"hermes"`
+		Limits Limits `yaml:"limits"`
+		Log    Log    `yaml:"log"`
+	}
+
+	// Hermes Hermes service endpoint and API token
+	Hermes struct {
+		URL   string `yaml:"url"`
+		Token string `yaml:"token"`
+	}
+
+	// Limits minimal cron interval and outbound trigger limits
+	Limits struct {
+		// MinInterval minimal allowed cron interval, like 1m
+		MinInterval *time.Duration `yaml:"min_interval"`
+		// Workers max number of concurrent Hermes triggers
+		Workers *int `yaml:"workers"`
+		// Rate max Hermes trigger rate (triggers per second, 0 - unlimited)
+		Rate *float64 `yaml:"rate"`
+		// Burst Hermes trigger rate limit burst
+		Burst *int `yaml:"burst"`
+		// QueueSize max number of queued triggers (0 - unlimited)
+		QueueSize *int `yaml:"queue_size"`
+	}
+
+	// Log logging settings
+	Log struct {
+		// Level log level (debug, info, warning, error, fatal, panic)
+		Level string `yaml:"level"`
+		// JSON produce log in Codefresh JSON format
+		JSON *bool `yaml:"json"`
+	}
+)
+
+// Load read and validate config file; unknown keys are rejected
+func Load(file string) (*Config, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err = yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid config file '%s': %v", file, err)
+	}
+	if err = cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file '%s': %v", file, err)
+	}
+	return &cfg, nil
+}
+
+// Validate check config values
+func (c *Config) Validate() error {
+	if c.Hermes.URL != "" {
+		u, err := url.Parse(c.Hermes.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("hermes.url '%s' should be http or https URL", c.Hermes.URL)
+		}
+	}
+	if l := c.Limits; l.MinInterval != nil && *l.MinInterval < 0 {
+		return errors.New("limits.min_interval should not be negative")
+	}
+	if l := c.Limits; l.Workers != nil && *l.Workers <= 0 {
+		return errors.New("limits.workers should be positive")
+	}
+	if l := c.Limits; l.Rate != nil && *l.Rate < 0 {
+		return errors.New("limits.rate should not be negative")
+	}
+	if l := c.Limits; l.Burst != nil && *l.Burst <= 0 {
+		return errors.New("limits.burst should be positive")
+	}
+	if l := c.Limits; l.QueueSize != nil && *l.QueueSize < 0 {
+		return errors.New("limits.queue_size should not be negative")
+	}
+	if c.Log.Level != "" {
+		if _, err := log.ParseLevel(c.Log.Level); err != nil {
+			return fmt.Errorf("log.level: %v", err)
+		}
+	}
+	return nil
+}
+
+// Watcher reload config file on signal (SIGHUP) or when file is changed
+type Watcher struct {
+	file     string
+	interval time.Duration
+	modTime  time.Time
+	size     int64
+	onChange func(*Config)
+}
+
+// NewWatcher create config file watcher; file is polled every interval (0 - only reload on signal)
+func NewWatcher(file string, interval time.Duration, onChange func(*Config)) *Watcher {
+	w := &Watcher{file: file, interval: interval, onChange: onChange}
+	w.changed()
+	return w
+}
+
+// changed check and remember file modification time and size
+func (w *Watcher) changed() bool {
+	info, err := os.Stat(w.file)
+	if err != nil {
+		return false
+	}
+	changed := !info.ModTime().Equal(w.modTime) || info.Size() != w.size
+	w.modTime, w.size = info.ModTime(), info.Size()
+	return changed
+}
+
+// Reload load config file and pass it to change handler; invalid config is reported and ignored
+func (w *Watcher) Reload() error {
+	cfg, err := Load(w.file)
+	if err != nil {
+		log.WithError(err).Error("failed to reload config file, keeping current config")
+		return err
+	}
+	log.WithField("config", w.file).Info("config file reloaded")
+	w.onChange(cfg)
+	return nil
+}
+
+// Run reload config on signal or file change, until stopped
+func (w *Watcher) Run(signals <-chan os.Signal, stop <-chan struct{}) {
+	var poll <-chan time.Time
+	if w.interval > 0 {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+	for {
+		select {
+		case <-stop:
+			return
+		case sig := <-signals:
+			log.WithField("signal", sig).Info("reloading config file")
+			w.changed()
+			w.Reload()
+		case <-poll:
+			if w.changed() {
+				w.Reload()
+			}
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfig(t *testing.T, file, content string) {
+	if err := ioutil.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	minute, ten, rate := time.Minute, 10, 2.5
+	tests := []struct {
+		name    string
+		content string
+		want    *Config
+		wantErr string
+	}{
+		{
+			name: "full config",
+			content: `
+hermes:
+  url: https://hermes:9011
+  token: secret
+limits:
+  min_interval: 1m
+  workers: 10
+  rate: 2.5
+log:
+  level: info
+`,
+			want: &Config{
+				Hermes: Hermes{URL: "https://hermes:9011", Token: "secret"},
+				Limits: Limits{MinInterval: &minute, Workers: &ten, Rate: &rate},
+				Log:    Log{Level: "info"},
+			},
+		},
+		{
+			name:    "empty config",
+			content: "",
+			want:    &Config{},
+		},
+		{
+			name:    "unknown key",
+			content: "limits:\n  quota: 10\n",
+			wantErr: "field quota not found",
+		},
+		{
+			name:    "bad hermes url",
+			content: "hermes:\n  url: ftp://hermes\n",
+			wantErr: "hermes.url 'ftp://hermes' should be http or https URL",
+		},
+		{
+			name:    "zero workers",
+			content: "limits:\n  workers: 0\n",
+			wantErr: "limits.workers should be positive",
+		},
+		{
+			name:    "negative rate",
+			content: "limits:\n  rate: -1\n",
+			wantErr: "limits.rate should not be negative",
+		},
+		{
+			name:    "bad log level",
+			content: "log:\n  level: loud\n",
+			wantErr: "log.level",
+		},
+	}
+	dir, err := ioutil.TempDir("", "cronus-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(dir, "cronus.yaml")
+			writeConfig(t, file, tt.content)
+			got, err := Load(file)
+			if tt.wantErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tt.wantErr)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWatcher_Run(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cronus-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "cronus.yaml")
+	writeConfig(t, file, "log:\n  level: info\n")
+
+	changes := make(chan *Config, 10)
+	w := NewWatcher(file, 10*time.Millisecond, func(cfg *Config) { changes <- cfg })
+	signals := make(chan os.Signal, 1)
+	stop := make(chan struct{})
+	defer close(stop)
+	go w.Run(signals, stop)
+
+	next := func() *Config {
+		select {
+		case cfg := <-changes:
+			return cfg
+		case <-time.After(2 * time.Second):
+			t.Fatal("config was not reloaded")
+			return nil
+		}
+	}
+	// file change
+	writeConfig(t, file, "log:\n  level: debug\n")
+	assert.Equal(t, "debug", next().Log.Level)
+	// signal
+	signals <- syscall.SIGHUP
+	assert.Equal(t, "debug", next().Log.Level)
+	// invalid config is ignored
+	writeConfig(t, file, "log:\n  level: loud\n")
+	signals <- syscall.SIGHUP
+	select {
+	case cfg := <-changes:
+		t.Errorf("invalid config applied: %+v", cfg)
+	case <-time.After(100 * time.Millisecond):
+	}
+	assert.Error(t, w.Reload())
+}
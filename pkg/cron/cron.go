@@ -1,11 +1,17 @@
 package cron
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/codefresh-io/cronus/pkg/cronexp"
 	"github.com/codefresh-io/cronus/pkg/hermes"
+	"github.com/codefresh-io/cronus/pkg/schedule"
 	"github.com/codefresh-io/cronus/pkg/types"
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/robfig/cron.v2"
@@ -15,17 +21,31 @@ type (
 	//CronJobEngine basic interface to underlying cron job engine
 	CronJobEngine interface {
 		Start()
+		Stop()
 		AddJob(spec string, cmd cron.Job) (cron.EntryID, error)
 		Remove(id cron.EntryID)
+		Entries() []cron.Entry
 	}
 
 	// Runner CRON runner
 	Runner struct {
-		hermesSvc hermes.Service
-		store     types.EventStore
-		cron      CronJobEngine
-		jobs      *sync.Map
-		limit     time.Duration
+		hermesSvc  hermes.Service
+		store      types.EventStore
+		cron       CronJobEngine
+		cronguru   cronexp.Service
+		jobs       *sync.Map
+		limit      time.Duration
+		limitMu    sync.RWMutex
+		inflight   *inflight
+		history    *historyBuffer
+		dispatcher *Dispatcher
+		stop       chan struct{}
+		stopOnce   sync.Once
+		ready      int32
+		running    int32
+		mu         sync.Mutex
+		statusMu   sync.Mutex
+		reconciled *ReconcileReport
 	}
 
 	// JobManager job manager interface to add/remove running jobs
@@ -33,6 +53,7 @@ type (
 		AddCronJob(e types.Event) error
 		RemoveCronJob(uri string) error
 		TriggerEvent(e types.Event) error
+		FireEvent(e types.Event, scheduled time.Time, delay time.Duration) error
 	}
 
 	// TriggerJob struct that keeps event and triggers cron job execution
@@ -45,27 +66,44 @@ type (
 // Run implements cron.Job interface
 func (job *TriggerJob) Run() {
 	log.Debug("running cron job")
-	err := job.manager.TriggerEvent(job.event)
-	if err != nil {
+	// cron engine runs jobs at whole seconds: truncate to get scheduled fire time
+	scheduled := time.Now().Truncate(time.Second)
+	// delay trigger within jitter window, if requested
+	var delay time.Duration
+	if window, _ := schedule.ParseJitter(job.event.Jitter); window > 0 {
+		delay = schedule.Jitter(window)
+		log.WithField("delay", delay).Debug("delaying cron job by jitter")
+	}
+	err := job.manager.FireEvent(job.event, scheduled, delay)
+	if err == ErrSkipped || err == ErrStopped || err == ErrExpired {
+		log.WithError(err).WithField("event-uri", types.GetURI(job.event)).Warn("cron job skipped")
+	} else if err != nil {
 		log.WithError(err).Error("failed to trigger event pipelines")
 	}
 }
 
 // NewCronRunner create new CRON runner with default cron job engine
-func NewCronRunner(store types.EventStore, svc hermes.Service, limit int64) *Runner {
-	return NewCronRunnerFull(store, svc, cron.New(), limit)
+// dispatcher (optional) bounds and rate limits outbound triggers
+func NewCronRunner(store types.EventStore, svc hermes.Service, dispatcher *Dispatcher, limit time.Duration) *Runner {
+	return NewCronRunnerFull(store, svc, cron.New(), dispatcher, limit)
 }
 
 // NewCronRunnerFull create new CRON runner with pluggable cron job engine
-func NewCronRunnerFull(store types.EventStore, svc hermes.Service, cron CronJobEngine, limit int64) *Runner {
+func NewCronRunnerFull(store types.EventStore, svc hermes.Service, cron CronJobEngine, dispatcher *Dispatcher, limit time.Duration) *Runner {
 	log.Debug("creating new cron runner")
 	runner := new(Runner)
 	runner.hermesSvc = svc
 	runner.store = store
 	runner.cron = cron
-	runner.limit = time.Duration(limit) * time.Second
+	runner.cronguru = cronexp.NewCronExpression()
+	runner.dispatcher = dispatcher
+	runner.limit = limit
 	runner.jobs = new(sync.Map)
+	runner.inflight = newInflight()
+	runner.history = newHistoryBuffer(store)
+	runner.stop = make(chan struct{})
 	runner.init()
+	go runner.history.run(historyFlushInterval, runner.stop)
 	return runner
 }
 
@@ -90,6 +128,108 @@ func checkValidInterval(expression string, limit time.Duration) (bool, time.Dura
 	return true, interval
 }
 
+// eventSpec get cron spec for event, normalized to 6 fields, with H tokens expanded by event URI hash
+func eventSpec(e types.Event) (string, error) {
+	return types.EventSpec(e)
+}
+
+// validateEvent validate event schedule and options, returns cron spec (with H tokens expanded)
+func (r *Runner) validateEvent(e types.Event) (string, error) {
+	return ValidateEvent(e, r.Limit())
+}
+
+// Limit minimal allowed cron interval
+func (r *Runner) Limit() time.Duration {
+	r.limitMu.RLock()
+	defer r.limitMu.RUnlock()
+	return r.limit
+}
+
+// SetLimit change minimal allowed cron interval; new limit applies to new and rescheduled events
+func (r *Runner) SetLimit(limit time.Duration) {
+	r.limitMu.Lock()
+	defer r.limitMu.Unlock()
+	r.limit = limit
+}
+
+// ValidateEvent validate event schedule (with minimal interval limit) and options, returns cron spec
+// (with H tokens expanded)
+func ValidateEvent(e types.Event, limit time.Duration) (string, error) {
+	// expand H tokens
+	spec, err := eventSpec(e)
+	if err != nil {
+		log.WithError(err).Error("failed to expand cron expression")
+		return "", fmt.Errorf("invalid cron expression: %v", err)
+	}
+	// report wrong field, before parsing
+	if err = cronexp.LintExpression(spec, e.Expression, e.Dialect); err != nil {
+		log.WithError(err).Error("invalid cron expression")
+		return "", fmt.Errorf("invalid cron expression: %v", err)
+	}
+	// check cron
+	ok, interval := checkValidInterval(spec, limit)
+	if !ok {
+		// skip short interval
+		log.Error("invalid interval")
+		return "", errors.New("invalid cron expression or too short interval")
+	}
+	// jitter window should be shorter than cron interval
+	jitter, err := schedule.ParseJitter(e.Jitter)
+	if err != nil {
+		log.WithError(err).Error("invalid jitter")
+		return "", err
+	}
+	// validate concurrency policy
+	if !types.ValidConcurrencyPolicy(e.ConcurrencyPolicy) {
+		log.WithField("policy", e.ConcurrencyPolicy).Error("invalid concurrency policy")
+		return "", fmt.Errorf("invalid concurrency policy '%s'", e.ConcurrencyPolicy)
+	}
+	// validate end time
+	if _, err := endTime(e); err != nil {
+		log.WithError(err).Error("invalid end time")
+		return "", err
+	}
+	if expired(e, time.Now()) {
+		return "", ErrExpired
+	}
+	if jitter >= interval {
+		log.WithFields(log.Fields{
+			"jitter":   jitter,
+			"interval": interval,
+		}).Error("jitter window is too long")
+		return "", errors.New("jitter window should be shorter than cron interval")
+	}
+	return spec, nil
+}
+
+// scheduleEvent validate event and add it to cron job engine
+func (r *Runner) scheduleEvent(e types.Event) (cron.EntryID, error) {
+	spec, err := r.validateEvent(e)
+	if err != nil {
+		return 0, err
+	}
+	job, err := r.cron.AddJob(spec, &TriggerJob{manager: r, event: e})
+	if err != nil {
+		log.WithError(err).Error("failed to create a new cron job")
+		return 0, errors.New("failed to create a new cron job")
+	}
+	return job, nil
+}
+
+// markInvalid mark stored event as unschedulable, with reason
+func (r *Runner) markInvalid(e types.Event, reason string) error {
+	if e.Status == types.StatusInvalid && e.LastError == reason {
+		return nil
+	}
+	log.WithFields(log.Fields{
+		"event-uri": types.GetURI(e),
+		"reason":    reason,
+	}).Warn("marking event as unschedulable")
+	e.Status = types.StatusInvalid
+	e.LastError = reason
+	return r.store.StoreEvent(e)
+}
+
 func (r *Runner) init() {
 	log.Debug("initializing cron runner")
 	// get all stored events
@@ -106,31 +246,113 @@ func (r *Runner) init() {
 			"account":     e.Account,
 			"description": e.Description,
 		}).Debug("creating a cron job based on event spec")
-		if ok, interval := checkValidInterval(e.Expression, r.limit); !ok {
-			// skip
-			log.WithFields(log.Fields{
-				"interval": interval.String(),
-				"cron":     e.Expression,
-			}).Warn("too short interval")
+		// skip paused and finished events
+		if !types.Schedulable(e.Status) {
+			continue
+		}
+		uri := types.GetURI(e)
+		job, err := r.scheduleEvent(e)
+		if err == ErrExpired {
+			e.Status = types.StatusExpired
+			if err := r.store.StoreEvent(e); err != nil {
+				log.WithError(err).WithField("event-uri", uri).Error("failed to update event status")
+			}
 			continue
 		}
-		job, err := r.cron.AddJob(e.Expression, &TriggerJob{manager: r, event: e})
 		if err != nil {
-			log.WithError(err).Warn("failed to create a new cron job")
+			// keep event in store, marked as unschedulable
+			if err := r.markInvalid(e, err.Error()); err != nil {
+				log.WithError(err).WithField("event-uri", uri).Error("failed to update event status")
+			}
+			continue
+		}
+		// pending or previously invalid event is scheduled now; do not schedule it, if status is not stored
+		if e.Status == types.StatusPending || e.Status == types.StatusInvalid {
+			e.Status = types.StatusActive
+			e.LastError = ""
+			if err := r.store.StoreEvent(e); err != nil {
+				log.WithError(err).WithField("event-uri", uri).Error("failed to update event status")
+				r.cron.Remove(job)
+				continue
+			}
 		}
 		// store job ID
-		r.jobs.Store(types.GetURI(e), job)
+		r.jobs.Store(uri, job)
 	}
 	// start CRON job runner
 	r.cron.Start()
+	atomic.StoreInt32(&r.running, 1)
+	atomic.StoreInt32(&r.ready, 1)
+}
+
+// Initialized check if runner has loaded stored events
+func (r *Runner) Initialized() bool {
+	return atomic.LoadInt32(&r.ready) == 1
 }
 
-// TriggerEvent trigger event
+// Running check if cron scheduler is running
+func (r *Runner) Running() bool {
+	return atomic.LoadInt32(&r.running) == 1
+}
+
+// TriggerEvent trigger event now, following event concurrency policy; returns ErrSkipped if trigger was skipped
 func (r *Runner) TriggerEvent(e types.Event) error {
+	return r.FireEvent(e, time.Now(), 0)
+}
+
+// FireEvent trigger scheduled event after delay (jitter), following event concurrency policy; delayed trigger
+// is registered as running, so concurrency policy and runner stop see it; queued triggers are ordered by
+// scheduled time
+func (r *Runner) FireEvent(e types.Event, scheduled time.Time, delay time.Duration) error {
 	log.WithFields(log.Fields{
 		"cron":    e.Expression,
 		"message": e.Message,
 	}).Debug("triggering cron event")
+	uri := types.GetURI(e)
+	fired := time.Now()
+
+	// check event end time
+	if expired(e, fired) {
+		if err := r.finish(uri, types.StatusExpired); err != nil {
+			log.WithError(err).WithField("event-uri", uri).Error("failed to expire event")
+		}
+		return ErrExpired
+	}
+
+	// check running triggers
+	ctx, done, err := r.inflight.begin(uri, e.ConcurrencyPolicy)
+	if err == ErrStopped {
+		return err
+	}
+	if err != nil {
+		r.history.add(types.HistoryRecord{
+			URI:    uri,
+			Time:   fired,
+			Result: types.ResultSkipped,
+			Reason: fmt.Sprintf("%s concurrency policy: %v", e.ConcurrencyPolicy, err),
+		})
+		return err
+	}
+	defer done()
+
+	// delay trigger within jitter window
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+			fired = time.Now()
+		case <-r.stop:
+			log.WithField("event-uri", uri).Warn("cron job canceled: runner is stopped")
+			return ErrStopped
+		case <-ctx.Done():
+			r.history.add(types.HistoryRecord{
+				URI:    uri,
+				Time:   fired,
+				Result: types.ResultCanceled,
+				Reason: "replaced by newer trigger",
+			})
+			return ctx.Err()
+		}
+	}
 
 	// create normalized event
 	event := hermes.NewNormalizedEvent()
@@ -144,31 +366,130 @@ func (r *Runner) TriggerEvent(e types.Event) error {
 
 	// attempt to invoke trigger
 	log.Debug("invoke hermes API to trigger event")
-	return r.hermesSvc.TriggerEvent(types.GetURI(e), event)
+	err = r.invoke(ctx, scheduled, uri, event)
+	// record trigger result
+	record := types.HistoryRecord{
+		URI:      uri,
+		Time:     fired,
+		Duration: time.Since(fired),
+		Result:   types.ResultTriggered,
+	}
+	if err != nil {
+		record.Result = types.ResultFailed
+		record.Reason = err.Error()
+		if ctx.Err() == context.Canceled {
+			record.Result = types.ResultCanceled
+			record.Reason = "replaced by newer trigger"
+			select {
+			case <-r.stop:
+				record.Reason = "canceled on runner stop"
+			default:
+			}
+		}
+	}
+	r.history.add(record)
+	// update event status, unless trigger was canceled
+	if record.Result != types.ResultCanceled {
+		r.recordOutcome(e, err)
+	}
+	return err
+}
+
+// Stop gracefully stop cron runner: stop scheduler and dispatcher, wait for queued and running triggers (until
+// context is done, then running triggers are canceled and waited for) and flush fire history; canceled triggers
+// are not retried
+func (r *Runner) Stop(ctx context.Context) error {
+	log.Debug("stopping cron runner")
+	var err error
+	r.stopOnce.Do(func() {
+		// stop scheduling new cron jobs
+		r.cron.Stop()
+		atomic.StoreInt32(&r.running, 0)
+		// cancel pending (jitter) cron jobs and stop history flush loop
+		close(r.stop)
+		// stop queueing triggers and wait for queued ones
+		if r.dispatcher != nil {
+			r.dispatcher.Stop()
+			r.waitDispatcher(ctx)
+		}
+		// wait for running triggers
+		if err = r.inflight.drain(ctx); err != nil {
+			log.WithError(err).Warn("canceled running triggers on runner stop")
+		}
+		// write buffered history records
+		if ferr := r.history.flush(); ferr != nil && err == nil {
+			err = ferr
+		}
+	})
+	return err
+}
+
+// waitDispatcher wait for queued and running dispatcher triggers, until context is done
+func (r *Runner) waitDispatcher(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		r.dispatcher.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.WithField("queued", r.dispatcher.QueueDepth()).Warn("stopped waiting for queued triggers")
+	}
+}
+
+// invoke call Hermes, through dispatcher queue if configured
+func (r *Runner) invoke(ctx context.Context, scheduled time.Time, uri string, event *hermes.NormalizedEvent) error {
+	if r.dispatcher == nil {
+		return r.hermesSvc.TriggerEvent(ctx, uri, event)
+	}
+	errc := make(chan error, 1)
+	err := r.dispatcher.Submit(scheduled, func() {
+		// trigger may be canceled while waiting in queue
+		if err := ctx.Err(); err != nil {
+			errc <- err
+			return
+		}
+		errc <- r.hermesSvc.TriggerEvent(ctx, uri, event)
+	})
+	if err != nil {
+		log.WithError(err).WithField("event-uri", uri).Error("failed to queue trigger")
+		return err
+	}
+	// do not wait in queue for canceled trigger
+	select {
+	case err = <-errc:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// GetHistory get last event fire history records, newest first
+func (r *Runner) GetHistory(uri string, limit int) ([]types.HistoryRecord, error) {
+	// write buffered records first
+	r.history.flush()
+	return r.store.GetHistory(uri, limit)
 }
 
 // AddCronJob add new CRON job
 func (r *Runner) AddCronJob(e types.Event) error {
 	log.WithField("event", e).Debug("adding new cron job")
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	uri := types.GetURI(e)
 	_, ok := r.jobs.Load(uri)
 	if ok {
 		log.Warn("trying to add already existing cron job")
 		return errors.New("this cron job already exist")
 	}
-	// check cron
-	if ok, _ := checkValidInterval(e.Expression, r.limit); !ok {
-		// skip short interval
-		log.Error("invalid interval")
-		return errors.New("invalid cron expression or too short interval")
-	}
-	// add cron job to job runner
-	job, err := r.cron.AddJob(e.Expression, &TriggerJob{manager: r, event: e})
+	// validate and add cron job to job runner
+	job, err := r.scheduleEvent(e)
 	if err != nil {
-		log.WithError(err).Error("failed to create a new cron job")
-		return errors.New("failed to create a new cron job")
+		return err
 	}
 	// store cron event into persistent store
+	e.Status = types.StatusActive
 	err = r.store.StoreEvent(e)
 	if err != nil {
 		// remove cron job from job runner
@@ -181,18 +502,69 @@ func (r *Runner) AddCronJob(e types.Event) error {
 	return nil
 }
 
-// RemoveCronJob remove CRON job
+// RemoveCronJob remove CRON job (if scheduled) and delete event from store; paused, finished and invalid
+// events are not scheduled, and are only deleted from store
 func (r *Runner) RemoveCronJob(uri string) error {
 	log.WithField("event-uri", uri).Debug("removing cron job")
-	job, ok := r.jobs.Load(uri)
-	if !ok {
-		log.Error("cron job not found")
-		return errors.New("cron job not found")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// block status updates of running triggers, so deleted event is not stored back
+	r.statusMu.Lock()
+	defer r.statusMu.Unlock()
+	if job, ok := r.jobs.Load(uri); ok {
+		// remove cron job from job runner
+		r.cron.Remove(job.(cron.EntryID))
+		r.jobs.Delete(uri)
 	}
-	// remove cron job from job runner
-	r.cron.Remove(job.(cron.EntryID))
-	// store job ID to global jobs map
-	r.jobs.Delete(uri)
 	// remove cron event from persistent store
-	return r.store.DeleteEvent(uri)
+	if err := r.store.DeleteEvent(uri); err != nil {
+		log.WithError(err).WithField("event-uri", uri).Error("failed to delete event")
+		return err
+	}
+	return nil
+}
+
+// RemoveAccountJobs remove CRON jobs of all account events and delete them from store (in single store
+// transaction); removed events are returned sorted by URI; in dry run mode account events are only returned
+func (r *Runner) RemoveAccountJobs(account string, dryRun bool) ([]types.Event, error) {
+	log.WithFields(log.Fields{
+		"account": account,
+		"dry-run": dryRun,
+	}).Debug("removing account cron jobs")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// block status updates of running triggers, so deleted events are not stored back
+	r.statusMu.Lock()
+	defer r.statusMu.Unlock()
+	var removed []types.Event
+	var err error
+	if dryRun {
+		removed, err = r.store.GetAccountEvents(account)
+	} else {
+		// write buffered records first, so no history is left for deleted events
+		r.history.flush()
+		removed, err = r.store.DeleteAccountEvents(account)
+	}
+	if err != nil {
+		log.WithError(err).WithField("account", account).Error("failed to remove account events")
+		return nil, err
+	}
+	sort.Slice(removed, func(i, j int) bool {
+		return types.GetURI(removed[i]) < types.GetURI(removed[j])
+	})
+	if dryRun {
+		return removed, nil
+	}
+	for _, e := range removed {
+		uri := types.GetURI(e)
+		if job, ok := r.jobs.Load(uri); ok {
+			r.cron.Remove(job.(cron.EntryID))
+			r.jobs.Delete(uri)
+		}
+	}
+	log.WithFields(log.Fields{
+		"account": account,
+		"events":  len(removed),
+	}).Info("account events removed")
+	return removed, nil
 }
@@ -1,19 +1,22 @@
 package cron
 
 import (
+	"context"
 	"errors"
 	"io"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/codefresh-io/cronus/pkg/backend"
 	"github.com/codefresh-io/cronus/pkg/hermes"
 	"github.com/codefresh-io/cronus/pkg/types"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	cron "gopkg.in/robfig/cron.v2"
 )
 
-// StoreMock mock
+// StoreMock mock; used by tests that inject store errors or check exact store calls, other tests use memory store
 type StoreMock struct {
 	mock.Mock
 }
@@ -28,6 +31,14 @@ func (m *StoreMock) DeleteEvent(uri string) error {
 	return args.Error(0)
 }
 
+func (m *StoreMock) DeleteAccountEvents(account string) ([]types.Event, error) {
+	args := m.Called(account)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]types.Event), args.Error(1)
+}
+
 func (m *StoreMock) GetEvent(uri string) (*types.Event, error) {
 	args := m.Called(uri)
 	if args.Get(0) == nil {
@@ -44,6 +55,30 @@ func (m *StoreMock) GetAllEvents() ([]types.Event, error) {
 	return args.Get(0).([]types.Event), args.Error(1)
 }
 
+func (m *StoreMock) GetAccountEvents(account string) ([]types.Event, error) {
+	args := m.Called(account)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]types.Event), args.Error(1)
+}
+
+func (m *StoreMock) GetStatusEvents(status string) ([]types.Event, error) {
+	args := m.Called(status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]types.Event), args.Error(1)
+}
+
+func (m *StoreMock) GetDueEvents(now, until time.Time) ([]types.Event, error) {
+	args := m.Called(now, until)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]types.Event), args.Error(1)
+}
+
 func (m *StoreMock) GetDBStats() (int, error) {
 	args := m.Called()
 	return args.Int(0), args.Error(1)
@@ -54,6 +89,24 @@ func (m *StoreMock) BackupDB(w io.Writer) (int, error) {
 	return args.Int(0), args.Error(1)
 }
 
+func (m *StoreMock) Close() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *StoreMock) AddHistory(records []types.HistoryRecord) error {
+	args := m.Called(records)
+	return args.Error(0)
+}
+
+func (m *StoreMock) GetHistory(uri string, limit int) ([]types.HistoryRecord, error) {
+	args := m.Called(uri, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]types.HistoryRecord), args.Error(1)
+}
+
 // CronJobEngineMock
 type CronJobEngineMock struct {
 	mock.Mock
@@ -63,6 +116,10 @@ func (m *CronJobEngineMock) Start() {
 	m.Called()
 }
 
+func (m *CronJobEngineMock) Stop() {
+	m.Called()
+}
+
 func (m *CronJobEngineMock) AddJob(spec string, cmd cron.Job) (cron.EntryID, error) {
 	args := m.Called(spec, cmd)
 	return cron.EntryID(args.Int(0)), args.Error(1)
@@ -72,19 +129,31 @@ func (m *CronJobEngineMock) Remove(id cron.EntryID) {
 	m.Called(id)
 }
 
+func (m *CronJobEngineMock) Entries() []cron.Entry {
+	args := m.Called()
+	return args.Get(0).([]cron.Entry)
+}
+
 // HermesMock
 type HermesMock struct {
 	mock.Mock
 }
 
-func (m *HermesMock) TriggerEvent(eventURI string, event *hermes.NormalizedEvent) error {
+func (m *HermesMock) Ping(ctx context.Context) error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *HermesMock) TriggerEvent(ctx context.Context, eventURI string, event *hermes.NormalizedEvent) error {
 	args := m.Called(eventURI, event)
 	return args.Error(0)
 }
 
 func TestNewCronRunnerFull(t *testing.T) {
 	type expected struct {
-		events []types.Event
+		events    []types.Event
+		storeErr  error
+		scheduled bool
 	}
 	tests := []struct {
 		name     string
@@ -111,6 +180,50 @@ func TestNewCronRunnerFull(t *testing.T) {
 						Help:        "help",
 					},
 				},
+				scheduled: true,
+			},
+		},
+		{
+			name: "pending event",
+			expected: expected{
+				events: []types.Event{
+					{
+						Expression: "5 4 * * *",
+						Message:    "test-message-1",
+						Secret:     "1234",
+						Status:     "pending",
+					},
+				},
+				scheduled: true,
+			},
+		},
+		{
+			name: "fail to store pending event status",
+			expected: expected{
+				events: []types.Event{
+					{
+						Expression: "5 4 * * *",
+						Message:    "test-message-1",
+						Secret:     "1234",
+						Status:     "pending",
+					},
+				},
+				storeErr: errors.New("store error"),
+			},
+		},
+		{
+			name: "fail to store expired event status",
+			expected: expected{
+				events: []types.Event{
+					{
+						Expression: "5 4 * * *",
+						Message:    "test-message-1",
+						Secret:     "1234",
+						Status:     "active",
+						Until:      "2000-01-01T00:00:00Z",
+					},
+				},
+				storeErr: errors.New("store error"),
 			},
 		},
 	}
@@ -121,15 +234,34 @@ func TestNewCronRunnerFull(t *testing.T) {
 			hermesMock := &HermesMock{}
 			// mock store
 			storeMock.On("GetAllEvents").Return(tt.expected.events, nil)
-			// mock cron engine calls
 			for i, e := range tt.expected.events {
-				cronJobMock.On("AddJob", e.Expression, mock.Anything).Return(i, nil)
+				switch {
+				case e.Until != "":
+					stored := e
+					stored.Status = types.StatusExpired
+					storeMock.On("StoreEvent", stored).Return(tt.expected.storeErr)
+					continue
+				case e.Status == types.StatusPending:
+					stored := e
+					stored.Status = types.StatusActive
+					storeMock.On("StoreEvent", stored).Return(tt.expected.storeErr)
+					if tt.expected.storeErr != nil {
+						// job added before status is stored, is removed
+						cronJobMock.On("Remove", cron.EntryID(i)).Once()
+					}
+				}
+				// 5 fields expression is scheduled normalized to 6 fields
+				cronJobMock.On("AddJob", "0 "+e.Expression, mock.Anything).Return(i, nil)
 			}
 			// mock start
 			cronJobMock.On("Start")
 			// invoke
-			NewCronRunnerFull(storeMock, hermesMock, cronJobMock, 5)
+			r := NewCronRunnerFull(storeMock, hermesMock, cronJobMock, nil, 5*time.Second)
 			// assert
+			for _, e := range tt.expected.events {
+				_, scheduled := r.jobs.Load(types.GetURI(e))
+				assert.Equal(t, tt.expected.scheduled, scheduled)
+			}
 			storeMock.AssertExpectations(t)
 			cronJobMock.AssertExpectations(t)
 			hermesMock.AssertExpectations(t)
@@ -142,9 +274,10 @@ func TestRunner_triggerEvent(t *testing.T) {
 		e types.Event
 	}
 	tests := []struct {
-		name    string
-		args    args
-		wantErr bool
+		name       string
+		args       args
+		wantErr    bool
+		wantStored *types.Event
 	}{
 		{
 			name: "trigger event",
@@ -186,13 +319,50 @@ func TestRunner_triggerEvent(t *testing.T) {
 				},
 			},
 			wantErr: true,
+			wantStored: &types.Event{
+				Expression:   "5 4 * * *",
+				Message:      "test-message-1",
+				Secret:       "1234",
+				Description:  "At 04:05",
+				Status:       "failing",
+				Help:         "help",
+				FailureCount: 1,
+				LastError:    "Test Error",
+			},
+		},
+		{
+			name: "recover failing event",
+			args: args{
+				e: types.Event{
+					Expression:   "5 4 * * *",
+					Message:      "test-message-1",
+					Secret:       "1234",
+					Description:  "At 04:05",
+					Status:       "failing",
+					Help:         "help",
+					FailureCount: 2,
+					LastError:    "Test Error",
+				},
+			},
+			wantStored: &types.Event{
+				Expression:  "5 4 * * *",
+				Message:     "test-message-1",
+				Secret:      "1234",
+				Description: "At 04:05",
+				Status:      "active",
+				Help:        "help",
+			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			hermesMock := &HermesMock{}
+			storeMock := &StoreMock{}
 			r := &Runner{
 				hermesSvc: hermesMock,
+				store:     storeMock,
+				inflight:  newInflight(),
+				history:   newHistoryBuffer(storeMock),
 			}
 			// mock hermes call
 			call := hermesMock.On("TriggerEvent", types.GetURI(tt.args.e), mock.AnythingOfType("*hermes.NormalizedEvent"))
@@ -201,10 +371,17 @@ func TestRunner_triggerEvent(t *testing.T) {
 			} else {
 				call.Return(nil)
 			}
+			// mock store calls
+			stored := tt.args.e
+			storeMock.On("GetEvent", types.GetURI(tt.args.e)).Return(&stored, nil)
+			if tt.wantStored != nil {
+				storeMock.On("StoreEvent", *tt.wantStored).Return(nil)
+			}
 			// invoke
 			r.TriggerEvent(tt.args.e)
 			// assert
 			hermesMock.AssertExpectations(t)
+			storeMock.AssertExpectations(t)
 		})
 	}
 }
@@ -305,8 +482,8 @@ func TestRunner_AddCronJob(t *testing.T) {
 				r.jobs.Store(types.GetURI(tt.args.e), 1)
 				goto Invoke
 			}
-			// mock cron job
-			call = cronMock.On("AddJob", tt.args.e.Expression, mock.Anything)
+			// mock cron job (5 fields expression is normalized to 6 fields)
+			call = cronMock.On("AddJob", "0 "+tt.args.e.Expression, mock.Anything)
 			if tt.wantAddJobErr {
 				call.Return(0, errors.New("failed to create a new cron job"))
 				goto Invoke
@@ -333,6 +510,147 @@ func TestRunner_AddCronJob(t *testing.T) {
 	}
 }
 
+func TestRunner_AddCronJob_Schedule(t *testing.T) {
+	tests := []struct {
+		name    string
+		event   types.Event
+		spec    string
+		wantErr bool
+	}{
+		{
+			name: "expand H tokens",
+			event: types.Event{
+				Expression: "0 H 4 * * *",
+				Message:    "test-message-1",
+				Secret:     "1234",
+			},
+			spec: "0 45 4 * * *",
+		},
+		{
+			name: "jitter window",
+			event: types.Event{
+				Expression: "0 0 4 * * *",
+				Message:    "test-message-1",
+				Secret:     "1234",
+				Jitter:     "10m",
+			},
+			spec: "0 0 4 * * *",
+		},
+		{
+			name: "jitter window longer than interval",
+			event: types.Event{
+				Expression: "0 */5 * * * *",
+				Message:    "test-message-1",
+				Secret:     "1234",
+				Jitter:     "10m",
+			},
+			wantErr: true,
+		},
+		{
+			name: "bad jitter window",
+			event: types.Event{
+				Expression: "0 0 4 * * *",
+				Message:    "test-message-1",
+				Secret:     "1234",
+				Jitter:     "soon",
+			},
+			wantErr: true,
+		},
+		{
+			name: "end time",
+			event: types.Event{
+				Expression: "0 0 4 * * *",
+				Message:    "test-message-1",
+				Secret:     "1234",
+				Until:      "2099-01-01T00:00:00Z",
+			},
+			spec: "0 0 4 * * *",
+		},
+		{
+			name: "end time has passed",
+			event: types.Event{
+				Expression: "0 0 4 * * *",
+				Message:    "test-message-1",
+				Secret:     "1234",
+				Until:      "2018-01-01T00:00:00Z",
+			},
+			wantErr: true,
+		},
+		{
+			name: "bad end time",
+			event: types.Event{
+				Expression: "0 0 4 * * *",
+				Message:    "test-message-1",
+				Secret:     "1234",
+				Until:      "tomorrow",
+			},
+			wantErr: true,
+		},
+		{
+			name: "crontab expression",
+			event: types.Event{
+				Expression: "@crontab 30 2 * * 7",
+				Message:    "test-message-1",
+				Secret:     "1234",
+			},
+			spec: "0 30 2 * * 0",
+		},
+		{
+			name: "5 fields in seconds dialect",
+			event: types.Event{
+				Expression: "30 2 * * *",
+				Message:    "test-message-1",
+				Secret:     "1234",
+				Dialect:    "seconds",
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown dialect",
+			event: types.Event{
+				Expression: "30 2 * * *",
+				Message:    "test-message-1",
+				Secret:     "1234",
+				Dialect:    "quartz",
+			},
+			wantErr: true,
+		},
+		{
+			name: "zero step",
+			event: types.Event{
+				Expression: "0 */0 4 * * *",
+				Message:    "test-message-1",
+				Secret:     "1234",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storeMock := &StoreMock{}
+			cronMock := &CronJobEngineMock{}
+			r := &Runner{
+				store: storeMock,
+				cron:  cronMock,
+				jobs:  new(sync.Map),
+				limit: time.Minute,
+			}
+			if !tt.wantErr {
+				cronMock.On("AddJob", tt.spec, mock.Anything).Return(1, nil)
+				// scheduled event is stored as active
+				stored := tt.event
+				stored.Status = types.StatusActive
+				storeMock.On("StoreEvent", stored).Return(nil)
+			}
+			if err := r.AddCronJob(tt.event); (err != nil) != tt.wantErr {
+				t.Errorf("Runner.AddCronJob() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			storeMock.AssertExpectations(t)
+			cronMock.AssertExpectations(t)
+		})
+	}
+}
+
 func TestRunner_RemoveCronJob(t *testing.T) {
 	type args struct {
 		uri string
@@ -370,6 +688,7 @@ func TestRunner_RemoveCronJob(t *testing.T) {
 			}
 			// add job to map in no error expected
 			if tt.wantjobNotExistErr {
+				storeMock.On("DeleteEvent", tt.args.uri).Return(types.ErrEventNotFound)
 				goto Invoke
 			} else {
 				r.jobs.Store(tt.args.uri, cron.EntryID(1))
@@ -395,6 +714,73 @@ func TestRunner_RemoveCronJob(t *testing.T) {
 	}
 }
 
+func TestRunner_RemoveAccountJobs(t *testing.T) {
+	e1 := types.Event{Expression: "0 0 5 * * *", Message: "b", Account: "acc1", Secret: "1234", Status: types.StatusActive}
+	e2 := types.Event{Expression: "0 0 4 * * *", Message: "a", Account: "acc1", Secret: "1234", Status: types.StatusPaused}
+	e3 := types.Event{Expression: "0 0 4 * * *", Message: "c", Account: "acc2", Secret: "1234", Status: types.StatusActive}
+	tests := []struct {
+		name       string
+		account    string
+		dryRun     bool
+		want       []string
+		wantStored []string
+	}{
+		{
+			name:       "remove account events",
+			account:    "acc1",
+			want:       []string{"a", "b"},
+			wantStored: []string{"c"},
+		},
+		{
+			name:       "dry run",
+			account:    "acc1",
+			dryRun:     true,
+			want:       []string{"a", "b"},
+			wantStored: []string{"a", "b", "c"},
+		},
+		{
+			name:       "unknown account",
+			account:    "acc3",
+			want:       []string{},
+			wantStored: []string{"a", "b", "c"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, _ := backend.NewMemoryEventStore("")
+			cronMock := &CronJobEngineMock{}
+			cronMock.On("Start")
+			for _, e := range []types.Event{e1, e2, e3} {
+				store.StoreEvent(e)
+			}
+			cronMock.On("AddJob", e1.Expression, mock.Anything).Return(1, nil)
+			cronMock.On("AddJob", e3.Expression, mock.Anything).Return(3, nil)
+			r := NewCronRunnerFull(store, &HermesMock{}, cronMock, nil, time.Minute)
+			if tt.account == "acc1" && !tt.dryRun {
+				cronMock.On("Remove", cron.EntryID(1)).Once()
+			}
+			got, err := r.RemoveAccountJobs(tt.account, tt.dryRun)
+			assert.NoError(t, err)
+			removed := make([]string, 0)
+			for _, e := range got {
+				removed = append(removed, e.Message)
+			}
+			assert.Equal(t, tt.want, removed)
+			all, _ := store.GetAllEvents()
+			var stored []string
+			for _, e := range all {
+				stored = append(stored, e.Message)
+			}
+			assert.ElementsMatch(t, tt.wantStored, stored)
+			_, scheduled := r.jobs.Load(types.GetURI(e1))
+			assert.Equal(t, tt.account != "acc1" || tt.dryRun, scheduled)
+			_, scheduled = r.jobs.Load(types.GetURI(e3))
+			assert.True(t, scheduled)
+			cronMock.AssertExpectations(t)
+		})
+	}
+}
+
 func Test_checkValidInterval(t *testing.T) {
 	const (
 		limit    = 5 * time.Minute
@@ -446,3 +832,334 @@ func Test_checkValidInterval(t *testing.T) {
 		})
 	}
 }
+
+// blockingHermes hermes stub blocking until released or canceled
+type blockingHermes struct {
+	started chan struct{}
+	release chan struct{}
+	// cancelDelay time to return after cancellation
+	cancelDelay time.Duration
+}
+
+func (h *blockingHermes) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (h *blockingHermes) TriggerEvent(ctx context.Context, eventURI string, event *hermes.NormalizedEvent) error {
+	h.started <- struct{}{}
+	select {
+	case <-h.release:
+		return nil
+	case <-ctx.Done():
+		time.Sleep(h.cancelDelay)
+		return ctx.Err()
+	}
+}
+
+func TestRunner_TriggerEvent_ConcurrencyPolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      string
+		wantSecond  error
+		wantResults []string
+	}{
+		{
+			name:        "allow overlapping triggers",
+			policy:      types.ConcurrencyAllow,
+			wantResults: []string{types.ResultTriggered, types.ResultTriggered},
+		},
+		{
+			name:        "forbid overlapping triggers",
+			policy:      types.ConcurrencyForbid,
+			wantSecond:  ErrSkipped,
+			wantResults: []string{types.ResultSkipped, types.ResultTriggered},
+		},
+		{
+			name:        "replace running trigger",
+			policy:      types.ConcurrencyReplace,
+			wantResults: []string{types.ResultCanceled, types.ResultTriggered},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &blockingHermes{started: make(chan struct{}, 2), release: make(chan struct{})}
+			storeMock := &StoreMock{}
+			storeMock.On("GetEvent", mock.Anything).Return(nil, types.ErrEventNotFound)
+			r := &Runner{
+				hermesSvc: h,
+				store:     storeMock,
+				inflight:  newInflight(),
+				history:   newHistoryBuffer(storeMock),
+			}
+			e := types.Event{
+				Expression:        "0 */5 * * * *",
+				Message:           "test-message-1",
+				Secret:            "1234",
+				ConcurrencyPolicy: tt.policy,
+			}
+			// first (slow) trigger
+			first := make(chan error)
+			go func() { first <- r.TriggerEvent(e) }()
+			<-h.started
+			// second trigger, while first is still running
+			second := make(chan error)
+			go func() { second <- r.TriggerEvent(e) }()
+			if tt.wantSecond != nil {
+				if err := <-second; err != tt.wantSecond {
+					t.Errorf("Runner.TriggerEvent() error = %v, want %v", err, tt.wantSecond)
+				}
+				close(h.release)
+				if err := <-first; err != nil {
+					t.Errorf("Runner.TriggerEvent() unexpected error = %v", err)
+				}
+			} else {
+				<-h.started
+				if tt.policy == types.ConcurrencyReplace {
+					if err := <-first; err != context.Canceled {
+						t.Errorf("Runner.TriggerEvent() error = %v, want %v", err, context.Canceled)
+					}
+				}
+				close(h.release)
+				if tt.policy != types.ConcurrencyReplace {
+					<-first
+				}
+				if err := <-second; err != nil {
+					t.Errorf("Runner.TriggerEvent() unexpected error = %v", err)
+				}
+			}
+			// assert history
+			var results []string
+			for _, rec := range r.history.records {
+				results = append(results, rec.Result)
+			}
+			assert.Equal(t, tt.wantResults, results)
+			assert.Equal(t, 0, r.inflight.count())
+		})
+	}
+}
+
+func TestRunner_FireEvent_Jitter(t *testing.T) {
+	store, _ := backend.NewMemoryEventStore("")
+	r := &Runner{
+		hermesSvc: &HermesMock{},
+		store:     store,
+		cron:      &CronJobEngineMock{},
+		inflight:  newInflight(),
+		history:   newHistoryBuffer(store),
+		stop:      make(chan struct{}),
+	}
+	e := types.Event{
+		Expression:        "0 */5 * * * *",
+		Message:           "test-message-1",
+		Secret:            "1234",
+		ConcurrencyPolicy: types.ConcurrencyForbid,
+	}
+	// delayed trigger is running for concurrency policy
+	delayed := make(chan error)
+	go func() { delayed <- r.FireEvent(e, time.Now(), time.Hour) }()
+	for r.inflight.count() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, ErrSkipped, r.TriggerEvent(e))
+	// and is canceled on runner stop, without waiting for the delay
+	r.cron.(*CronJobEngineMock).On("Stop")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, r.Stop(ctx))
+	assert.Equal(t, ErrStopped, <-delayed)
+}
+
+// orderHermes hermes stub recording triggered event messages; first trigger blocks until released
+type orderHermes struct {
+	mu       sync.Mutex
+	messages []string
+	started  chan struct{}
+	release  chan struct{}
+}
+
+func (h *orderHermes) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (h *orderHermes) TriggerEvent(ctx context.Context, eventURI string, event *hermes.NormalizedEvent) error {
+	h.mu.Lock()
+	h.messages = append(h.messages, event.Variables["message"])
+	first := len(h.messages) == 1
+	h.mu.Unlock()
+	if first {
+		h.started <- struct{}{}
+		<-h.release
+	}
+	return nil
+}
+
+func TestRunner_FireEvent_ScheduledOrder(t *testing.T) {
+	store, _ := backend.NewMemoryEventStore("")
+	h := &orderHermes{started: make(chan struct{}), release: make(chan struct{})}
+	d := NewDispatcher(1, 0, 1, 0)
+	defer d.Stop()
+	r := &Runner{
+		hermesSvc:  h,
+		store:      store,
+		inflight:   newInflight(),
+		history:    newHistoryBuffer(store),
+		dispatcher: d,
+		stop:       make(chan struct{}),
+	}
+	event := func(message string) types.Event {
+		return types.Event{Expression: "0 */5 * * * *", Message: message, Secret: "1234"}
+	}
+	now := time.Now().Truncate(time.Second)
+	var wg sync.WaitGroup
+	fire := func(message string, scheduled time.Time) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.FireEvent(event(message), scheduled, 0)
+		}()
+	}
+	// busy worker
+	fire("first", now)
+	<-h.started
+	// queued triggers run by scheduled time, not by submit order
+	fire("later", now.Add(2*time.Second))
+	for d.QueueDepth() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	fire("earlier", now.Add(time.Second))
+	for d.QueueDepth() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	close(h.release)
+	wg.Wait()
+	assert.Equal(t, []string{"first", "earlier", "later"}, h.messages)
+}
+
+func TestRunner_Stop_Dispatcher(t *testing.T) {
+	store, _ := backend.NewMemoryEventStore("")
+	h := &orderHermes{started: make(chan struct{}), release: make(chan struct{})}
+	d := NewDispatcher(1, 0, 1, 0)
+	cronMock := &CronJobEngineMock{}
+	cronMock.On("Stop")
+	r := &Runner{
+		hermesSvc:  h,
+		store:      store,
+		cron:       cronMock,
+		inflight:   newInflight(),
+		history:    newHistoryBuffer(store),
+		dispatcher: d,
+		stop:       make(chan struct{}),
+	}
+	event := func(message string) types.Event {
+		return types.Event{Expression: "0 */5 * * * *", Message: message, Secret: "1234"}
+	}
+	// running and queued triggers
+	go r.TriggerEvent(event("running"))
+	<-h.started
+	go r.TriggerEvent(event("queued"))
+	for d.QueueDepth() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(h.release)
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, r.Stop(ctx))
+	// queued trigger ran before stop returned, history is flushed, dispatcher rejects new triggers
+	assert.Equal(t, []string{"running", "queued"}, h.messages)
+	for _, message := range []string{"running", "queued"} {
+		records, _ := store.GetHistory(types.GetURI(event(message)), 0)
+		if assert.Len(t, records, 1, message) {
+			assert.Equal(t, types.ResultTriggered, records[0].Result)
+		}
+	}
+	assert.Equal(t, ErrDispatcherStopped, d.Submit(time.Now(), func() {}))
+	select {
+	case <-d.done:
+	case <-time.After(time.Second):
+		t.Error("dispatch loop is still running")
+	}
+	cronMock.AssertExpectations(t)
+}
+
+func TestRunner_Stop(t *testing.T) {
+	tests := []struct {
+		name        string
+		timeout     time.Duration
+		cancelDelay time.Duration
+		release     bool
+		wantErr     bool
+		wantResult  string
+	}{
+		{
+			name:       "wait for running trigger",
+			timeout:    time.Second,
+			release:    true,
+			wantResult: types.ResultTriggered,
+		},
+		{
+			name:       "cancel running trigger on timeout",
+			timeout:    50 * time.Millisecond,
+			wantErr:    true,
+			wantResult: types.ResultCanceled,
+		},
+		{
+			name:        "wait for canceled trigger to return",
+			timeout:     50 * time.Millisecond,
+			cancelDelay: 1200 * time.Millisecond,
+			wantErr:     true,
+			wantResult:  types.ResultCanceled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &blockingHermes{started: make(chan struct{}, 1), release: make(chan struct{}), cancelDelay: tt.cancelDelay}
+			storeMock := &StoreMock{}
+			cronMock := &CronJobEngineMock{}
+			r := &Runner{
+				hermesSvc: h,
+				store:     storeMock,
+				cron:      cronMock,
+				inflight:  newInflight(),
+				history:   newHistoryBuffer(storeMock),
+				stop:      make(chan struct{}),
+			}
+			e := types.Event{
+				Expression: "0 */5 * * * *",
+				Message:    "test-message-1",
+				Secret:     "1234",
+			}
+			cronMock.On("Stop")
+			if tt.release {
+				// completed trigger updates event status
+				storeMock.On("GetEvent", types.GetURI(e)).Return(nil, types.ErrEventNotFound)
+			}
+			storeMock.On("AddHistory", mock.MatchedBy(func(records []types.HistoryRecord) bool {
+				return len(records) == 1 && records[0].Result == tt.wantResult
+			})).Return(nil)
+			// start trigger
+			go r.TriggerEvent(e)
+			<-h.started
+			if tt.release {
+				go func() {
+					time.Sleep(20 * time.Millisecond)
+					close(h.release)
+				}()
+			}
+			// invoke
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+			if err := r.Stop(ctx); (err != nil) != tt.wantErr {
+				t.Errorf("Runner.Stop() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			// new triggers are rejected
+			if err := r.TriggerEvent(e); err != ErrStopped {
+				t.Errorf("Runner.TriggerEvent() error = %v, want %v", err, ErrStopped)
+			}
+			cronMock.AssertExpectations(t)
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
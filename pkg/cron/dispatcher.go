@@ -0,0 +1,258 @@
+package cron
+
+import (
+	"container/heap"
+	"errors"
+	"expvar"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type (
+	// task queued trigger
+	task struct {
+		scheduled time.Time
+		enqueued  time.Time
+		seq       uint64
+		run       func()
+	}
+
+	// taskQueue priority queue of tasks, ordered by scheduled time (FIFO for same time)
+	taskQueue []*task
+
+	// Dispatcher bounded worker pool with token-bucket rate limit, between cron jobs and Hermes
+	Dispatcher struct {
+		mu      sync.Mutex
+		cond    *sync.Cond
+		queue   taskQueue
+		seq     uint64
+		size    int
+		limiter *rateLimiter
+		slots   chan struct{}
+		stopped bool
+		wg      sync.WaitGroup
+		done    chan struct{}
+	}
+
+	// rateLimiter token bucket rate limiter
+	rateLimiter struct {
+		mu     sync.Mutex
+		rate   float64
+		burst  float64
+		tokens float64
+		last   time.Time
+	}
+)
+
+// ErrQueueFull trigger queue is full
+var ErrQueueFull = errors.New("trigger queue is full")
+
+// ErrDispatcherStopped dispatcher does not accept new triggers
+var ErrDispatcherStopped = errors.New("trigger dispatcher is stopped")
+
+// dispatcher metrics, exposed through expvar
+var (
+	metrics           = expvar.NewMap("dispatcher")
+	metricQueueDepth  = new(expvar.Int)
+	metricRunning     = new(expvar.Int)
+	metricDispatched  = new(expvar.Int)
+	metricRejected    = new(expvar.Int)
+	metricWaitTotal   = new(expvar.Float)
+	metricWaitLast    = new(expvar.Float)
+	metricWaitMaximum = new(expvar.Float)
+)
+
+func init() {
+	metrics.Set("queue_depth", metricQueueDepth)
+	metrics.Set("running", metricRunning)
+	metrics.Set("dispatched_total", metricDispatched)
+	metrics.Set("rejected_total", metricRejected)
+	metrics.Set("wait_seconds_total", metricWaitTotal)
+	metrics.Set("wait_seconds_last", metricWaitLast)
+	metrics.Set("wait_seconds_max", metricWaitMaximum)
+}
+
+func (q taskQueue) Len() int { return len(q) }
+func (q taskQueue) Less(i, j int) bool {
+	if q[i].scheduled.Equal(q[j].scheduled) {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].scheduled.Before(q[j].scheduled)
+}
+func (q taskQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *taskQueue) Push(x interface{}) { *q = append(*q, x.(*task)) }
+func (q *taskQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	t := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return t
+}
+
+// NewDispatcher create and start new trigger dispatcher
+// workers - max concurrent triggers; rate - triggers per second (0 - unlimited); burst - rate limit burst;
+// size - max queue size (0 - unlimited)
+func NewDispatcher(workers int, rate float64, burst int, size int) *Dispatcher {
+	log.WithFields(log.Fields{
+		"workers": workers,
+		"rate":    rate,
+		"burst":   burst,
+		"size":    size,
+	}).Debug("creating trigger dispatcher")
+	if workers <= 0 {
+		workers = 1
+	}
+	if burst <= 0 {
+		burst = 1
+	}
+	d := &Dispatcher{
+		size:    size,
+		limiter: newRateLimiter(rate, burst),
+		slots:   make(chan struct{}, workers),
+		done:    make(chan struct{}),
+	}
+	d.cond = sync.NewCond(&d.mu)
+	go d.dispatch()
+	return d
+}
+
+// Submit queue trigger function; triggers with earlier scheduled time run first
+func (d *Dispatcher) Submit(scheduled time.Time, run func()) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.stopped {
+		return ErrDispatcherStopped
+	}
+	if d.size > 0 && len(d.queue) >= d.size {
+		metricRejected.Add(1)
+		return ErrQueueFull
+	}
+	d.seq++
+	heap.Push(&d.queue, &task{scheduled: scheduled, enqueued: time.Now(), seq: d.seq, run: run})
+	d.wg.Add(1)
+	metricQueueDepth.Set(int64(len(d.queue)))
+	d.cond.Signal()
+	return nil
+}
+
+// Stop stop accepting new triggers; queued triggers are still dispatched, then dispatch loop exits
+func (d *Dispatcher) Stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.stopped = true
+	d.cond.Broadcast()
+}
+
+// Wait wait for all queued and running triggers to complete
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// SetRate change trigger rate limit (triggers per second, 0 - unlimited) and burst
+func (d *Dispatcher) SetRate(rate float64, burst int) {
+	if burst <= 0 {
+		burst = 1
+	}
+	log.WithFields(log.Fields{
+		"rate":  rate,
+		"burst": burst,
+	}).Info("changing trigger rate limit")
+	d.limiter.set(rate, burst)
+}
+
+// QueueDepth number of queued triggers
+func (d *Dispatcher) QueueDepth() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.queue)
+}
+
+// dispatch run queued tasks: take worker slot, wait for rate limit token and run earliest task;
+// returns when dispatcher is stopped and queue is empty
+func (d *Dispatcher) dispatch() {
+	defer close(d.done)
+	for {
+		d.slots <- struct{}{}
+		d.mu.Lock()
+		for len(d.queue) == 0 && !d.stopped {
+			d.cond.Wait()
+		}
+		if len(d.queue) == 0 {
+			d.mu.Unlock()
+			<-d.slots
+			return
+		}
+		d.mu.Unlock()
+		d.limiter.wait()
+		d.mu.Lock()
+		t := heap.Pop(&d.queue).(*task)
+		metricQueueDepth.Set(int64(len(d.queue)))
+		d.mu.Unlock()
+		// update metrics
+		wait := time.Since(t.enqueued).Seconds()
+		metricDispatched.Add(1)
+		metricWaitTotal.Add(wait)
+		metricWaitLast.Set(wait)
+		if wait > metricWaitMaximum.Value() {
+			metricWaitMaximum.Set(wait)
+		}
+		metricRunning.Add(1)
+		go func() {
+			defer func() {
+				metricRunning.Add(-1)
+				<-d.slots
+				d.wg.Done()
+			}()
+			t.run()
+		}()
+	}
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	return &rateLimiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// set change rate and burst; available tokens are kept (up to new burst)
+func (l *rateLimiter) set(rate float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate, l.burst = rate, float64(burst)
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+}
+
+// wait block until token is available and take it; zero rate means no limit
+func (l *rateLimiter) wait() {
+	for {
+		delay, ok := l.take()
+		if ok {
+			return
+		}
+		time.Sleep(delay)
+	}
+}
+
+// take take token, if available; otherwise returns time to wait for the next token
+func (l *rateLimiter) take() (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	if l.rate <= 0 {
+		l.last = now
+		return 0, true
+	}
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0, true
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second)), false
+}
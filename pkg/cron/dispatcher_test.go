@@ -0,0 +1,135 @@
+package cron
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDispatcher_Order(t *testing.T) {
+	d := NewDispatcher(1, 0, 1, 0)
+	// block single worker
+	release := make(chan struct{})
+	started := make(chan struct{})
+	if err := d.Submit(time.Now(), func() { close(started); <-release }); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	// queue tasks in reverse scheduled order
+	var mu sync.Mutex
+	var got []int
+	base := time.Now()
+	for i := 5; i > 0; i-- {
+		i := i
+		if err := d.Submit(base.Add(time.Duration(i)*time.Second), func() {
+			mu.Lock()
+			got = append(got, i)
+			mu.Unlock()
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assert.Equal(t, 5, d.QueueDepth())
+	close(release)
+	d.Wait()
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, got)
+	assert.Equal(t, 0, d.QueueDepth())
+}
+
+func TestDispatcher_Workers(t *testing.T) {
+	const workers = 3
+	d := NewDispatcher(workers, 0, 1, 0)
+	var running, max int32
+	for i := 0; i < 20; i++ {
+		d.Submit(time.Now(), func() {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				m := atomic.LoadInt32(&max)
+				if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+		})
+	}
+	d.Wait()
+	if max > workers {
+		t.Errorf("Dispatcher ran %d concurrent tasks, want at most %d", max, workers)
+	}
+}
+
+func TestDispatcher_RateLimit(t *testing.T) {
+	// 5 tasks, burst 1, 50 per second: at least 4 * 20ms
+	d := NewDispatcher(10, 50, 1, 0)
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		d.Submit(time.Now(), func() {})
+	}
+	d.Wait()
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("Dispatcher is not rate limited: 5 tasks took %v", elapsed)
+	}
+}
+
+func TestDispatcher_QueueFull(t *testing.T) {
+	d := NewDispatcher(1, 0, 1, 1)
+	release := make(chan struct{})
+	// first task is running, second is queued
+	started := make(chan struct{})
+	d.Submit(time.Now(), func() { close(started); <-release })
+	<-started
+	if err := d.Submit(time.Now(), func() {}); err != nil {
+		t.Errorf("Dispatcher.Submit() unexpected error = %v", err)
+	}
+	if err := d.Submit(time.Now(), func() {}); err != ErrQueueFull {
+		t.Errorf("Dispatcher.Submit() error = %v, want %v", err, ErrQueueFull)
+	}
+	close(release)
+	d.Wait()
+}
+
+func TestDispatcher_Stop(t *testing.T) {
+	d := NewDispatcher(1, 0, 1, 0)
+	ran := make(chan struct{}, 1)
+	if err := d.Submit(time.Now(), func() { ran <- struct{}{} }); err != nil {
+		t.Fatal(err)
+	}
+	d.Stop()
+	if err := d.Submit(time.Now(), func() {}); err != ErrDispatcherStopped {
+		t.Errorf("Dispatcher.Submit() error = %v, want %v", err, ErrDispatcherStopped)
+	}
+	// queued trigger still runs, then dispatch loop exits
+	d.Wait()
+	assert.Len(t, ran, 1)
+	select {
+	case <-d.done:
+	case <-time.After(time.Second):
+		t.Error("Dispatcher.Stop() dispatch loop is still running")
+	}
+}
+
+func TestDispatcher_SetRate(t *testing.T) {
+	// one trigger per 10 seconds
+	d := NewDispatcher(1, 0.1, 1, 0)
+	d.SetRate(0, 1)
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		d.Submit(time.Now(), func() {})
+	}
+	d.Wait()
+	assert.True(t, time.Since(start) < time.Second, "unlimited rate is applied")
+	// lower burst drops extra tokens
+	l := newRateLimiter(10, 10)
+	l.set(10, 2)
+	_, ok := l.take()
+	assert.True(t, ok)
+	_, ok = l.take()
+	assert.True(t, ok)
+	delay, ok := l.take()
+	assert.False(t, ok)
+	assert.True(t, delay > 0)
+}
@@ -0,0 +1,187 @@
+package cron
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/codefresh-io/cronus/pkg/cronexp"
+	"github.com/codefresh-io/cronus/pkg/schedule"
+	cron "gopkg.in/robfig/cron.v2"
+)
+
+type (
+	// Explanation cron expression explanation: wrong field, description, next fire times, interval and
+	// suggested fixes
+	Explanation struct {
+		Expression string `json:"expression"`
+		// Spec cron spec with H tokens expanded
+		Spec        string              `json:"spec,omitempty"`
+		Valid       bool                `json:"valid"`
+		Error       string              `json:"error,omitempty"`
+		Field       *cronexp.FieldError `json:"field,omitempty"`
+		Description string              `json:"description,omitempty"`
+		Next        []time.Time         `json:"next,omitempty"`
+		// Interval interval between next two fire times, checked against limit
+		Interval string `json:"interval,omitempty"`
+		// MinInterval shortest interval between next fire times
+		MinInterval string   `json:"minInterval,omitempty"`
+		Limit       string   `json:"limit"`
+		Notes       []string `json:"notes,omitempty"`
+		Suggestions []string `json:"suggestions,omitempty"`
+	}
+)
+
+// intervalSamples number of fire times to detect shortest interval
+const intervalSamples = 100
+
+// Explain explain cron expression of dialect (with H tokens expanded by event URI) against minimal interval
+// limit, listing count next fire times
+func Explain(expression, dialect, uri string, limit time.Duration, count int) Explanation {
+	return explain(expression, dialect, uri, limit, count, time.Now())
+}
+
+// Explain explain cron expression against runner minimal interval limit
+func (r *Runner) Explain(expression, dialect, uri string, count int) Explanation {
+	return Explain(expression, dialect, uri, r.Limit(), count)
+}
+
+func explain(expression, dialect, uri string, limit time.Duration, count int, now time.Time) Explanation {
+	ex := Explanation{Expression: expression, Limit: limit.String()}
+	prefixed := strings.HasPrefix(strings.TrimSpace(expression), cronexp.CrontabPrefix)
+	ex.Suggestions = suggest(strings.TrimPrefix(strings.TrimSpace(expression), cronexp.CrontabPrefix))
+	// normalize dialect and expand H tokens
+	spec, err := cronexp.Normalize(expression, dialect)
+	if err != nil {
+		ex.Error = fmt.Sprintf("invalid cron expression: %v", err)
+		return ex
+	}
+	if _, expr := splitTimeZone(expression); dialect == cronexp.DialectAuto && !prefixed && len(strings.Fields(expr)) == 5 {
+		ex.Notes = append(ex.Notes, "5 fields are read as Unix crontab 'minute hour day-of-month month day-of-week', at second 0; add '@crontab' prefix or set crontab dialect to make it explicit")
+	}
+	if schedule.IsHashed(spec) {
+		if uri == "" {
+			uri = expression
+			ex.Notes = append(ex.Notes, "H values depend on event URI; set event URI to get exact fire times")
+		}
+	}
+	spec, err = schedule.Expand(spec, uri)
+	if err != nil {
+		ex.Error = fmt.Sprintf("invalid cron expression: %v", err)
+		return ex
+	}
+	if spec != expression {
+		ex.Spec = spec
+	}
+	if err = cronexp.LintExpression(spec, expression, dialect); err != nil {
+		ex.Error = fmt.Sprintf("invalid cron expression: %v", err)
+		if fe, ok := err.(*cronexp.FieldError); ok {
+			ex.Field = fe
+			if strings.Contains(fe.Reason, "not supported") {
+				ex.Suggestions = append(ex.Suggestions, "'L', 'W' and '#' (Quartz syntax) are not supported: list values explicitly")
+			}
+		}
+		return ex
+	}
+	ex.Description, _ = cronexp.Describe(spec)
+	sch, err := cron.Parse(spec)
+	if err != nil {
+		ex.Error = fmt.Sprintf("invalid cron expression: %v", err)
+		return ex
+	}
+	// same check as event validation: interval between next two fire times
+	next := sch.Next(now)
+	interval := sch.Next(next).Sub(next)
+	ex.Interval = interval.String()
+	shortest := interval
+	for i, t := 0, now; i < intervalSamples; i++ {
+		n := sch.Next(t)
+		if n.IsZero() {
+			break
+		}
+		if i < count {
+			ex.Next = append(ex.Next, n)
+		}
+		if i > 0 && n.Sub(t) < shortest {
+			shortest = n.Sub(t)
+		}
+		t = n
+	}
+	ex.MinInterval = shortest.String()
+	if interval < limit {
+		ex.Error = fmt.Sprintf("too short interval: %v, minimal allowed interval is %v", interval, limit)
+		ex.Suggestions = append(ex.Suggestions, shortIntervalSuggestions(spec, limit)...)
+		return ex
+	}
+	if shortest < limit {
+		ex.Notes = append(ex.Notes, fmt.Sprintf("some fire times are only %v apart, shorter than allowed limit of %v", shortest, limit))
+	}
+	ex.Valid = true
+	return ex
+}
+
+// splitTimeZone split TZ= prefix from cron spec
+func splitTimeZone(spec string) (string, string) {
+	if !strings.HasPrefix(spec, "TZ=") {
+		return "", spec
+	}
+	i := strings.Index(spec, " ")
+	if i < 0 {
+		return "", spec
+	}
+	return spec[:i], strings.TrimSpace(spec[i:])
+}
+
+// suggest suggest fixes for common mistakes
+func suggest(expression string) []string {
+	var suggestions []string
+	if strings.HasPrefix(expression, "CRON_TZ=") {
+		suggestions = append(suggestions, "use 'TZ=' prefix for time zone, instead of 'CRON_TZ='")
+	}
+	_, expr := splitTimeZone(expression)
+	if strings.HasPrefix(expr, "@") {
+		if _, err := cronexp.Describe(expr); err != nil && !strings.HasPrefix(expr, "@every ") {
+			suggestions = append(suggestions, "use one of predefined schedules: @yearly, @annually, @monthly, @weekly, @daily, @midnight, @hourly or @every <duration>")
+		}
+		return suggestions
+	}
+	tokens := strings.Fields(expr)
+	switch {
+	case len(tokens) == 7:
+		suggestions = append(suggestions, fmt.Sprintf("year field is not supported: remove it - '%s'", strings.Join(tokens[:6], " ")))
+	case len(tokens) < 5:
+		suggestions = append(suggestions, "use 6 fields 'second minute hour day-of-month month day-of-week' or 5 fields Unix crontab syntax")
+	}
+	// crontab day of week accepts 7 for Sunday
+	if len(tokens) == 6 {
+		dow := tokens[5]
+		for _, v := range strings.FieldsFunc(dow, func(r rune) bool { return r == ',' || r == '-' }) {
+			if v == "7" {
+				suggestions = append(suggestions, "day of week is 0-6: use 0 (or SUN) for Sunday")
+				break
+			}
+		}
+	}
+	return suggestions
+}
+
+// shortIntervalSuggestions suggest fixes for too short interval
+func shortIntervalSuggestions(spec string, limit time.Duration) []string {
+	tz, expr := splitTimeZone(spec)
+	tokens := strings.Fields(expr)
+	var suggestions []string
+	if len(tokens) == 6 && !isNumber(tokens[0]) {
+		fixed := strings.TrimSpace(tz + " 0 " + strings.Join(tokens[1:], " "))
+		suggestions = append(suggestions, fmt.Sprintf("seconds field '%s' fires more than once a minute: use '%s' to fire at second 0", tokens[0], fixed))
+	}
+	return append(suggestions, fmt.Sprintf("use less frequent schedule, like '@every %v'", limit))
+}
+
+func isNumber(token string) bool {
+	for _, r := range token {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return token != ""
+}
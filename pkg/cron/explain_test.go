@@ -0,0 +1,171 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/codefresh-io/cronus/pkg/cronexp"
+	"github.com/codefresh-io/cronus/pkg/schedule"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplain(t *testing.T) {
+	now := time.Date(2020, 1, 1, 10, 0, 0, 0, time.Local)
+	tests := []struct {
+		name       string
+		expression string
+		dialect    string
+		uri        string
+		want       Explanation
+	}{
+		{
+			name:       "valid expression",
+			expression: "0 30 2 * * *",
+			want: Explanation{
+				Expression:  "0 30 2 * * *",
+				Valid:       true,
+				Description: "At 02:30:00, every day",
+				Next:        []time.Time{time.Date(2020, 1, 2, 2, 30, 0, 0, time.Local), time.Date(2020, 1, 3, 2, 30, 0, 0, time.Local)},
+				Interval:    "24h0m0s",
+				MinInterval: "24h0m0s",
+				Limit:       "1m0s",
+			},
+		},
+		{
+			name:       "5-field expression",
+			expression: "30 2 * * *",
+			want: Explanation{
+				Expression:  "30 2 * * *",
+				Spec:        "0 30 2 * * *",
+				Valid:       true,
+				Description: "At 02:30:00, every day",
+				Next:        []time.Time{time.Date(2020, 1, 2, 2, 30, 0, 0, time.Local), time.Date(2020, 1, 3, 2, 30, 0, 0, time.Local)},
+				Interval:    "24h0m0s",
+				MinInterval: "24h0m0s",
+				Limit:       "1m0s",
+				Notes:       []string{"5 fields are read as Unix crontab 'minute hour day-of-month month day-of-week', at second 0; add '@crontab' prefix or set crontab dialect to make it explicit"},
+			},
+		},
+		{
+			name:       "crontab prefix",
+			expression: "@crontab 30 2 * * 7",
+			want: Explanation{
+				Expression:  "@crontab 30 2 * * 7",
+				Spec:        "0 30 2 * * 0",
+				Valid:       true,
+				Description: "At 02:30:00, on Sunday",
+				Next:        []time.Time{time.Date(2020, 1, 5, 2, 30, 0, 0, time.Local), time.Date(2020, 1, 12, 2, 30, 0, 0, time.Local)},
+				Interval:    "168h0m0s",
+				MinInterval: "168h0m0s",
+				Limit:       "1m0s",
+			},
+		},
+		{
+			name:       "seconds dialect with 5 fields",
+			expression: "30 2 * * *",
+			dialect:    "seconds",
+			want: Explanation{
+				Expression: "30 2 * * *",
+				Error:      "invalid cron expression: expression should have 6 fields (seconds first), found 5",
+				Limit:      "1m0s",
+			},
+		},
+		{
+			name:       "too short interval",
+			expression: "*/10 * * * * *",
+			want: Explanation{
+				Expression:  "*/10 * * * * *",
+				Error:       "too short interval: 10s, minimal allowed interval is 1m0s",
+				Description: "Every 10 seconds",
+				Next:        []time.Time{now.Add(10 * time.Second), now.Add(20 * time.Second)},
+				Interval:    "10s",
+				MinInterval: "10s",
+				Limit:       "1m0s",
+				Suggestions: []string{
+					"seconds field '*/10' fires more than once a minute: use '0 * * * * *' to fire at second 0",
+					"use less frequent schedule, like '@every 1m0s'",
+				},
+			},
+		},
+		{
+			name:       "short interval only between some fire times",
+			expression: "0 0,30 10 * * *",
+			want: Explanation{
+				Expression:  "0 0,30 10 * * *",
+				Valid:       true,
+				Description: "At second 0, at minutes 0 and 30, at hour 10, every day",
+				Next:        []time.Time{now.Add(30 * time.Minute), now.Add(24 * time.Hour)},
+				Interval:    "23h30m0s",
+				MinInterval: "30m0s",
+				Limit:       "1h0m0s",
+				Notes:       []string{"some fire times are only 30m0s apart, shorter than allowed limit of 1h0m0s"},
+			},
+		},
+		{
+			name:       "wrong field",
+			expression: "0 0 0 * * 7",
+			want: Explanation{
+				Expression:  "0 0 0 * * 7",
+				Error:       "invalid cron expression: day of week field '7' (position 6): 7 is out of range 0-6",
+				Field:       &cronexp.FieldError{Position: 6, Field: "day of week", Value: "7", Reason: "7 is out of range 0-6"},
+				Limit:       "1m0s",
+				Suggestions: []string{"day of week is 0-6: use 0 (or SUN) for Sunday"},
+			},
+		},
+		{
+			name:       "wrong field of 5-field expression",
+			expression: "61 2 * * *",
+			want: Explanation{
+				Expression: "61 2 * * *",
+				Spec:       "0 61 2 * * *",
+				Error:      "invalid cron expression: minute field '61' (position 1): 61 is out of range 0-59",
+				Field:      &cronexp.FieldError{Position: 1, Field: "minute", Value: "61", Reason: "61 is out of range 0-59"},
+				Limit:      "1m0s",
+				Notes:      []string{"5 fields are read as Unix crontab 'minute hour day-of-month month day-of-week', at second 0; add '@crontab' prefix or set crontab dialect to make it explicit"},
+			},
+		},
+		{
+			name:       "wrong field of crontab expression",
+			expression: "@crontab 0 2 32 * *",
+			want: Explanation{
+				Expression: "@crontab 0 2 32 * *",
+				Spec:       "0 0 2 32 * *",
+				Error:      "invalid cron expression: day of month field '32' (position 3): 32 is out of range 1-31",
+				Field:      &cronexp.FieldError{Position: 3, Field: "day of month", Value: "32", Reason: "32 is out of range 1-31"},
+				Limit:      "1m0s",
+			},
+		},
+		{
+			name:       "year field",
+			expression: "0 0 0 1 1 * 2021",
+			want: Explanation{
+				Expression:  "0 0 0 1 1 * 2021",
+				Error:       "invalid cron expression: expected 5 or 6 fields, found 7",
+				Limit:       "1m0s",
+				Suggestions: []string{"year field is not supported: remove it - '0 0 0 1 1 *'"},
+			},
+		},
+		{
+			name:       "hashed expression with event URI",
+			expression: "0 H 3 * * *",
+			uri:        "cron:codefresh:0 H 3 * * *:nightly:acc1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit := time.Minute
+			if tt.want.Limit != "" {
+				limit, _ = time.ParseDuration(tt.want.Limit)
+			}
+			got := explain(tt.expression, tt.dialect, tt.uri, limit, 2, now)
+			if tt.uri != "" {
+				spec, _ := schedule.Expand(tt.expression, tt.uri)
+				assert.Equal(t, spec, got.Spec)
+				assert.True(t, got.Valid)
+				assert.Empty(t, got.Notes)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
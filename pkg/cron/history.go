@@ -0,0 +1,62 @@
+package cron
+
+import (
+	"sync"
+	"time"
+
+	"github.com/codefresh-io/cronus/pkg/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// historyFlushInterval how often buffered history records are written to store
+const historyFlushInterval = 10 * time.Second
+
+type (
+	// historyBuffer buffers fire history records and writes them to store in batches
+	historyBuffer struct {
+		mu      sync.Mutex
+		records []types.HistoryRecord
+		store   types.EventStore
+	}
+)
+
+func newHistoryBuffer(store types.EventStore) *historyBuffer {
+	return &historyBuffer{store: store}
+}
+
+// add buffer history record
+func (h *historyBuffer) add(r types.HistoryRecord) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r)
+}
+
+// flush write buffered records to store
+func (h *historyBuffer) flush() error {
+	h.mu.Lock()
+	records := h.records
+	h.records = nil
+	h.mu.Unlock()
+	if len(records) == 0 {
+		return nil
+	}
+	if err := h.store.AddHistory(records); err != nil {
+		log.WithError(err).WithField("records", len(records)).Error("failed to store history records")
+		return err
+	}
+	return nil
+}
+
+// run flush buffered records periodically, until stop is closed
+func (h *historyBuffer) run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			h.flush()
+		case <-stop:
+			return
+		}
+	}
+}
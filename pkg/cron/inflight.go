@@ -0,0 +1,125 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/codefresh-io/cronus/pkg/types"
+)
+
+type (
+	// invocation single running trigger
+	invocation struct {
+		cancel context.CancelFunc
+	}
+
+	// inflight tracker of running triggers, per event URI
+	inflight struct {
+		mu      sync.Mutex
+		running map[string][]*invocation
+		closed  bool
+		wg      sync.WaitGroup
+	}
+)
+
+// ErrSkipped trigger skipped due to concurrency policy
+var ErrSkipped = errors.New("previous trigger is still running")
+
+// ErrStopped runner is stopped and does not accept new triggers
+var ErrStopped = errors.New("cron runner is stopped")
+
+func newInflight() *inflight {
+	return &inflight{running: make(map[string][]*invocation)}
+}
+
+// begin register new trigger invocation, following event concurrency policy
+// returns invocation context and done function, which must be called when trigger completes;
+// returns ErrSkipped when trigger should be skipped and ErrStopped when tracker is drained
+func (t *inflight) begin(uri, policy string) (context.Context, func(), error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return nil, nil, ErrStopped
+	}
+	running := t.running[uri]
+	if len(running) > 0 {
+		switch policy {
+		case types.ConcurrencyForbid:
+			return nil, nil, ErrSkipped
+		case types.ConcurrencyReplace:
+			for _, inv := range running {
+				inv.cancel()
+			}
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	inv := &invocation{cancel: cancel}
+	t.running[uri] = append(running, inv)
+	t.wg.Add(1)
+	return ctx, func() { t.done(uri, inv) }, nil
+}
+
+// done unregister completed trigger invocation
+func (t *inflight) done(uri string, inv *invocation) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	inv.cancel()
+	running := t.running[uri]
+	for i, r := range running {
+		if r == inv {
+			running = append(running[:i], running[i+1:]...)
+			break
+		}
+	}
+	if len(running) == 0 {
+		delete(t.running, uri)
+	} else {
+		t.running[uri] = running
+	}
+	t.wg.Done()
+}
+
+// count number of running triggers
+func (t *inflight) count() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n := 0
+	for _, running := range t.running {
+		n += len(running)
+	}
+	return n
+}
+
+// drain stop accepting new triggers and wait for running triggers to complete;
+// running triggers are canceled if context is done first, and are waited for to return
+func (t *inflight) drain(ctx context.Context) error {
+	t.mu.Lock()
+	t.closed = true
+	t.mu.Unlock()
+	drained := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		t.cancelAll()
+		// canceled triggers record history before they return
+		<-drained
+		return ctx.Err()
+	}
+}
+
+// cancelAll cancel all running triggers
+func (t *inflight) cancelAll() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, running := range t.running {
+		for _, inv := range running {
+			inv.cancel()
+		}
+	}
+}
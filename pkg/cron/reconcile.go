@@ -0,0 +1,173 @@
+package cron
+
+import (
+	"time"
+
+	"github.com/codefresh-io/cronus/pkg/types"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/robfig/cron.v2"
+)
+
+type (
+	// InvalidEvent stored event that cannot be scheduled
+	InvalidEvent struct {
+		URI    string `json:"uri"`
+		Reason string `json:"reason"`
+	}
+
+	// ReconcileReport result of store and scheduler reconciliation
+	ReconcileReport struct {
+		// Time reconciliation time
+		Time time.Time `json:"time"`
+		// Events number of stored events
+		Events int `json:"events"`
+		// Jobs number of scheduled jobs after reconciliation
+		Jobs int `json:"jobs"`
+		// Scheduled stored events, missing from scheduler, that were (re)scheduled
+		Scheduled []string `json:"scheduled,omitempty"`
+		// Removed scheduled jobs without stored (or schedulable) event, that were removed
+		Removed []string `json:"removed,omitempty"`
+		// Orphaned number of cron engine entries not linked to any event, that were removed
+		Orphaned int `json:"orphaned,omitempty"`
+		// Invalid stored events that cannot be scheduled (marked with invalid status)
+		Invalid []InvalidEvent `json:"invalid,omitempty"`
+		// Error reconciliation error
+		Error string `json:"error,omitempty"`
+	}
+)
+
+// Reconcile diff stored events against scheduled jobs and cron engine entries and repair discrepancies:
+// schedule missing events, remove jobs without stored event (or of paused and finished events)
+// and orphaned engine entries, and mark unschedulable events with invalid status
+func (r *Runner) Reconcile() *ReconcileReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reconcile()
+}
+
+// Reload remove all scheduled jobs and schedule stored events again; use after store content is replaced
+// (restore), since changed events may be stored under URIs of scheduled jobs
+func (r *Runner) Reload() *ReconcileReport {
+	log.Debug("reloading stored events")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs.Range(func(key, value interface{}) bool {
+		r.cron.Remove(value.(cron.EntryID))
+		r.jobs.Delete(key)
+		return true
+	})
+	return r.reconcile()
+}
+
+// reconcile reconcile store and scheduler; caller should hold r.mu
+func (r *Runner) reconcile() *ReconcileReport {
+	log.Debug("reconciling stored events with scheduler")
+	report := &ReconcileReport{Time: time.Now()}
+	defer func() { r.reconciled = report }()
+
+	events, err := r.store.GetAllEvents()
+	if err != nil {
+		log.WithError(err).Error("failed to load stored events")
+		report.Error = err.Error()
+		return report
+	}
+	report.Events = len(events)
+	// engine entries
+	entries := make(map[cron.EntryID]bool)
+	for _, entry := range r.cron.Entries() {
+		entries[entry.ID] = true
+	}
+	// stored events: schedule missing ones
+	stored := make(map[string]bool)
+	for _, e := range events {
+		uri := types.GetURI(e)
+		// paused and finished events should not be scheduled
+		if !types.Schedulable(e.Status) {
+			continue
+		}
+		stored[uri] = true
+		if job, ok := r.jobs.Load(uri); ok && entries[job.(cron.EntryID)] {
+			continue
+		}
+		job, err := r.scheduleEvent(e)
+		if err == ErrExpired {
+			delete(stored, uri)
+			e.Status = types.StatusExpired
+			if err = r.store.StoreEvent(e); err != nil {
+				log.WithError(err).WithField("event-uri", uri).Error("failed to update event status")
+			}
+			continue
+		}
+		if err != nil {
+			report.Invalid = append(report.Invalid, InvalidEvent{URI: uri, Reason: err.Error()})
+			r.jobs.Delete(uri)
+			if err = r.markInvalid(e, err.Error()); err != nil {
+				log.WithError(err).WithField("event-uri", uri).Error("failed to mark event as unschedulable")
+			}
+			continue
+		}
+		r.jobs.Store(uri, job)
+		entries[job] = true
+		report.Scheduled = append(report.Scheduled, uri)
+		// event is schedulable again
+		if e.Status == types.StatusPending || e.Status == types.StatusInvalid {
+			e.Status = types.StatusActive
+			e.LastError = ""
+			if err = r.store.StoreEvent(e); err != nil {
+				log.WithError(err).WithField("event-uri", uri).Error("failed to update event status")
+			}
+		}
+	}
+	// scheduled jobs: remove jobs without stored event
+	linked := make(map[cron.EntryID]bool)
+	r.jobs.Range(func(key, value interface{}) bool {
+		uri, job := key.(string), value.(cron.EntryID)
+		if !stored[uri] {
+			r.cron.Remove(job)
+			r.jobs.Delete(uri)
+			delete(entries, job)
+			report.Removed = append(report.Removed, uri)
+			return true
+		}
+		linked[job] = true
+		report.Jobs++
+		return true
+	})
+	// engine entries: remove orphaned entries
+	for id := range entries {
+		if !linked[id] {
+			r.cron.Remove(id)
+			report.Orphaned++
+		}
+	}
+	if len(report.Scheduled) > 0 || len(report.Removed) > 0 || report.Orphaned > 0 || len(report.Invalid) > 0 {
+		log.WithFields(log.Fields{
+			"scheduled": len(report.Scheduled),
+			"removed":   len(report.Removed),
+			"orphaned":  report.Orphaned,
+			"invalid":   len(report.Invalid),
+		}).Warn("store and scheduler discrepancies found")
+	}
+	return report
+}
+
+// LastReconcile last reconciliation report; nil if reconciliation never ran
+func (r *Runner) LastReconcile() *ReconcileReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reconciled
+}
+
+// RunReconcile reconcile periodically, until runner is stopped
+func (r *Runner) RunReconcile(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.Reconcile()
+		case <-r.stop:
+			return
+		}
+	}
+}
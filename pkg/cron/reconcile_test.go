@@ -0,0 +1,250 @@
+package cron
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/codefresh-io/cronus/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	cron "gopkg.in/robfig/cron.v2"
+)
+
+func TestRunner_Reconcile(t *testing.T) {
+	valid := types.Event{
+		Expression: "0 0 4 * * *",
+		Message:    "test-message-1",
+		Secret:     "1234",
+		Status:     types.StatusActive,
+	}
+	tooShort := types.Event{
+		Expression: "*/5 * * * * *",
+		Message:    "test-message-2",
+		Secret:     "1234",
+		Status:     types.StatusActive,
+	}
+	recovered := types.Event{
+		Expression: "0 0 5 * * *",
+		Message:    "test-message-3",
+		Secret:     "1234",
+		Status:     types.StatusInvalid,
+		LastError:  "failed to create a new cron job",
+	}
+	paused := types.Event{
+		Expression: "0 0 6 * * *",
+		Message:    "test-message-4",
+		Secret:     "1234",
+		Status:     types.StatusPaused,
+	}
+	ended := types.Event{
+		Expression: "0 0 7 * * *",
+		Message:    "test-message-5",
+		Secret:     "1234",
+		Status:     types.StatusActive,
+		Until:      "2018-01-01T00:00:00Z",
+	}
+	tests := []struct {
+		name      string
+		events    []types.Event
+		jobs      map[string]cron.EntryID
+		entries   []cron.EntryID
+		setup     func(storeMock *StoreMock, cronMock *CronJobEngineMock)
+		want      ReconcileReport
+		wantJobs  map[string]cron.EntryID
+		wantError bool
+	}{
+		{
+			name:     "nothing to repair",
+			events:   []types.Event{valid},
+			jobs:     map[string]cron.EntryID{types.GetURI(valid): 1},
+			entries:  []cron.EntryID{1},
+			want:     ReconcileReport{Events: 1, Jobs: 1},
+			wantJobs: map[string]cron.EntryID{types.GetURI(valid): 1},
+		},
+		{
+			name:    "schedule missing event",
+			events:  []types.Event{valid},
+			entries: []cron.EntryID{},
+			setup: func(storeMock *StoreMock, cronMock *CronJobEngineMock) {
+				cronMock.On("AddJob", valid.Expression, mock.Anything).Return(2, nil)
+			},
+			want:     ReconcileReport{Events: 1, Jobs: 1, Scheduled: []string{types.GetURI(valid)}},
+			wantJobs: map[string]cron.EntryID{types.GetURI(valid): 2},
+		},
+		{
+			name:    "reschedule job missing from engine",
+			events:  []types.Event{valid},
+			jobs:    map[string]cron.EntryID{types.GetURI(valid): 1},
+			entries: []cron.EntryID{},
+			setup: func(storeMock *StoreMock, cronMock *CronJobEngineMock) {
+				cronMock.On("AddJob", valid.Expression, mock.Anything).Return(2, nil)
+			},
+			want:     ReconcileReport{Events: 1, Jobs: 1, Scheduled: []string{types.GetURI(valid)}},
+			wantJobs: map[string]cron.EntryID{types.GetURI(valid): 2},
+		},
+		{
+			name:    "remove job without stored event",
+			events:  []types.Event{},
+			jobs:    map[string]cron.EntryID{types.GetURI(valid): 1},
+			entries: []cron.EntryID{1},
+			setup: func(storeMock *StoreMock, cronMock *CronJobEngineMock) {
+				cronMock.On("Remove", cron.EntryID(1))
+			},
+			want:     ReconcileReport{Removed: []string{types.GetURI(valid)}},
+			wantJobs: map[string]cron.EntryID{},
+		},
+		{
+			name:    "remove orphaned engine entry",
+			events:  []types.Event{valid},
+			jobs:    map[string]cron.EntryID{types.GetURI(valid): 1},
+			entries: []cron.EntryID{1, 7},
+			setup: func(storeMock *StoreMock, cronMock *CronJobEngineMock) {
+				cronMock.On("Remove", cron.EntryID(7))
+			},
+			want:     ReconcileReport{Events: 1, Jobs: 1, Orphaned: 1},
+			wantJobs: map[string]cron.EntryID{types.GetURI(valid): 1},
+		},
+		{
+			name:    "mark unschedulable event",
+			events:  []types.Event{tooShort},
+			entries: []cron.EntryID{},
+			setup: func(storeMock *StoreMock, cronMock *CronJobEngineMock) {
+				marked := tooShort
+				marked.Status = types.StatusInvalid
+				marked.LastError = "invalid cron expression or too short interval"
+				storeMock.On("StoreEvent", marked).Return(nil)
+			},
+			want: ReconcileReport{
+				Events:  1,
+				Invalid: []InvalidEvent{{URI: types.GetURI(tooShort), Reason: "invalid cron expression or too short interval"}},
+			},
+			wantJobs: map[string]cron.EntryID{},
+		},
+		{
+			name:    "recover previously unschedulable event",
+			events:  []types.Event{recovered},
+			entries: []cron.EntryID{},
+			setup: func(storeMock *StoreMock, cronMock *CronJobEngineMock) {
+				cronMock.On("AddJob", recovered.Expression, mock.Anything).Return(3, nil)
+				active := recovered
+				active.Status = types.StatusActive
+				active.LastError = ""
+				storeMock.On("StoreEvent", active).Return(nil)
+			},
+			want:     ReconcileReport{Events: 1, Jobs: 1, Scheduled: []string{types.GetURI(recovered)}},
+			wantJobs: map[string]cron.EntryID{types.GetURI(recovered): 3},
+		},
+		{
+			name:    "remove job of paused event",
+			events:  []types.Event{paused},
+			jobs:    map[string]cron.EntryID{types.GetURI(paused): 4},
+			entries: []cron.EntryID{4},
+			setup: func(storeMock *StoreMock, cronMock *CronJobEngineMock) {
+				cronMock.On("Remove", cron.EntryID(4))
+			},
+			want:     ReconcileReport{Events: 1, Removed: []string{types.GetURI(paused)}},
+			wantJobs: map[string]cron.EntryID{},
+		},
+		{
+			name:    "mark expired event",
+			events:  []types.Event{ended},
+			entries: []cron.EntryID{},
+			setup: func(storeMock *StoreMock, cronMock *CronJobEngineMock) {
+				marked := ended
+				marked.Status = types.StatusExpired
+				storeMock.On("StoreEvent", marked).Return(nil)
+			},
+			want:     ReconcileReport{Events: 1},
+			wantJobs: map[string]cron.EntryID{},
+		},
+		{
+			name: "fail to load events",
+			setup: func(storeMock *StoreMock, cronMock *CronJobEngineMock) {
+				storeMock.ExpectedCalls = nil
+				storeMock.On("GetAllEvents").Return(nil, errors.New("Test Error"))
+			},
+			want:      ReconcileReport{Error: "Test Error"},
+			wantJobs:  map[string]cron.EntryID{},
+			wantError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storeMock := &StoreMock{}
+			cronMock := &CronJobEngineMock{}
+			r := &Runner{
+				store: storeMock,
+				cron:  cronMock,
+				jobs:  new(sync.Map),
+				limit: time.Minute,
+			}
+			for uri, job := range tt.jobs {
+				r.jobs.Store(uri, job)
+			}
+			storeMock.On("GetAllEvents").Return(tt.events, nil)
+			var entries []cron.Entry
+			for _, id := range tt.entries {
+				entries = append(entries, cron.Entry{ID: id})
+			}
+			if !tt.wantError {
+				cronMock.On("Entries").Return(entries)
+			}
+			if tt.setup != nil {
+				tt.setup(storeMock, cronMock)
+			}
+			// invoke
+			got := r.Reconcile()
+			got.Time = time.Time{}
+			assert.Equal(t, tt.want, *got)
+			assert.Equal(t, got, r.LastReconcile())
+			// assert jobs
+			jobs := make(map[string]cron.EntryID)
+			r.jobs.Range(func(key, value interface{}) bool {
+				jobs[key.(string)] = value.(cron.EntryID)
+				return true
+			})
+			assert.Equal(t, tt.wantJobs, jobs)
+			storeMock.AssertExpectations(t)
+			cronMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRunner_Reload(t *testing.T) {
+	restored := types.Event{
+		Expression: "0 0 4 * * *",
+		Message:    "test-message-1",
+		Secret:     "5678",
+		Jitter:     "5m",
+		Status:     types.StatusActive,
+	}
+	storeMock := &StoreMock{}
+	cronMock := &CronJobEngineMock{}
+	r := &Runner{
+		store: storeMock,
+		cron:  cronMock,
+		jobs:  new(sync.Map),
+		limit: time.Minute,
+	}
+	// job of event with same URI and other options is scheduled
+	uri := types.GetURI(restored)
+	r.jobs.Store(uri, cron.EntryID(1))
+	r.jobs.Store("cron:codefresh:0 0 5 * * *:deleted", cron.EntryID(2))
+	storeMock.On("GetAllEvents").Return([]types.Event{restored}, nil)
+	cronMock.On("Remove", cron.EntryID(1)).Once()
+	cronMock.On("Remove", cron.EntryID(2)).Once()
+	cronMock.On("Entries").Return([]cron.Entry{})
+	cronMock.On("AddJob", restored.Expression, mock.MatchedBy(func(job *TriggerJob) bool {
+		return job.event.Secret == restored.Secret && job.event.Jitter == restored.Jitter
+	})).Return(3, nil)
+	// invoke
+	got := r.Reload()
+	assert.Equal(t, []string{uri}, got.Scheduled)
+	assert.Equal(t, 1, got.Jobs)
+	job, _ := r.jobs.Load(uri)
+	assert.Equal(t, cron.EntryID(3), job)
+	storeMock.AssertExpectations(t)
+	cronMock.AssertExpectations(t)
+}
@@ -0,0 +1,180 @@
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/codefresh-io/cronus/pkg/types"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/robfig/cron.v2"
+)
+
+// ErrExpired event end time has passed
+var ErrExpired = errors.New("event end time has passed")
+
+// endTime get event end time; zero time if not set
+func endTime(e types.Event) (time.Time, error) {
+	if e.Until == "" {
+		return time.Time{}, nil
+	}
+	until, err := time.Parse(time.RFC3339, e.Until)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid end time '%s': should be RFC3339 time", e.Until)
+	}
+	return until, nil
+}
+
+// expired check if event end time has passed
+func expired(e types.Event, now time.Time) bool {
+	until, err := endTime(e)
+	return err == nil && !until.IsZero() && now.After(until)
+}
+
+// completed check if event has no fire time left before its end time
+func completed(e types.Event, now time.Time) bool {
+	until, err := endTime(e)
+	if err != nil || until.IsZero() {
+		return false
+	}
+	spec, err := eventSpec(e)
+	if err != nil {
+		return false
+	}
+	sch, err := cron.Parse(spec)
+	if err != nil {
+		return false
+	}
+	next := sch.Next(now)
+	return next.IsZero() || next.After(until)
+}
+
+// setStatus change event status, following allowed status transitions
+func setStatus(e *types.Event, status string) error {
+	if !types.CanTransition(e.Status, status) {
+		return fmt.Errorf("cannot change event status from '%s' to '%s'", e.Status, status)
+	}
+	e.Status = status
+	return nil
+}
+
+// updateEvent read stored event, apply update and store event back, if changed; deleted event is not updated
+func (r *Runner) updateEvent(uri string, update func(e *types.Event) bool) error {
+	r.statusMu.Lock()
+	defer r.statusMu.Unlock()
+	e, err := r.store.GetEvent(uri)
+	if err == types.ErrEventNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !update(e) {
+		return nil
+	}
+	return r.store.StoreEvent(*e)
+}
+
+// recordOutcome update event status and consecutive failure counter after trigger
+func (r *Runner) recordOutcome(e types.Event, err error) {
+	uri := types.GetURI(e)
+	uerr := r.updateEvent(uri, func(e *types.Event) bool {
+		if err == nil {
+			if e.FailureCount == 0 && e.LastError == "" && e.Status == types.StatusActive {
+				return false
+			}
+			e.FailureCount = 0
+			e.LastError = ""
+			// keep status of event paused while trigger was running
+			if e.Status != types.StatusPaused {
+				setStatus(e, types.StatusActive)
+			}
+			return true
+		}
+		e.FailureCount++
+		e.LastError = err.Error()
+		setStatus(e, types.StatusFailing)
+		return true
+	})
+	if uerr != nil {
+		log.WithError(uerr).WithField("event-uri", uri).Error("failed to update event status")
+	}
+	// event fired for the last time
+	if completed(e, time.Now()) {
+		if err := r.finish(uri, types.StatusCompleted); err != nil {
+			log.WithError(err).WithField("event-uri", uri).Error("failed to complete event")
+		}
+	}
+}
+
+// finish remove event job from scheduler and set final event status (expired or completed)
+func (r *Runner) finish(uri string, status string) error {
+	log.WithFields(log.Fields{
+		"event-uri": uri,
+		"status":    status,
+	}).Info("event is finished")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if job, ok := r.jobs.Load(uri); ok {
+		r.cron.Remove(job.(cron.EntryID))
+		r.jobs.Delete(uri)
+	}
+	return r.updateEvent(uri, func(e *types.Event) bool {
+		return setStatus(e, status) == nil
+	})
+}
+
+// PauseCronJob remove event job from scheduler, keeping event in store with paused status
+func (r *Runner) PauseCronJob(uri string) error {
+	log.WithField("event-uri", uri).Debug("pausing cron job")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, err := r.store.GetEvent(uri)
+	if err != nil {
+		log.WithError(err).Error("failed to get event")
+		return err
+	}
+	if !types.CanTransition(e.Status, types.StatusPaused) {
+		return fmt.Errorf("cannot pause %s event", e.Status)
+	}
+	if job, ok := r.jobs.Load(uri); ok {
+		r.cron.Remove(job.(cron.EntryID))
+		r.jobs.Delete(uri)
+	}
+	return r.updateEvent(uri, func(e *types.Event) bool {
+		return setStatus(e, types.StatusPaused) == nil
+	})
+}
+
+// ResumeCronJob schedule paused event again
+func (r *Runner) ResumeCronJob(uri string) error {
+	log.WithField("event-uri", uri).Debug("resuming cron job")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, err := r.store.GetEvent(uri)
+	if err != nil {
+		log.WithError(err).Error("failed to get event")
+		return err
+	}
+	if e.Status != types.StatusPaused {
+		return fmt.Errorf("cannot resume %s event", e.Status)
+	}
+	job, err := r.scheduleEvent(*e)
+	if err == ErrExpired {
+		if uerr := r.updateEvent(uri, func(e *types.Event) bool {
+			return setStatus(e, types.StatusExpired) == nil
+		}); uerr != nil {
+			return uerr
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	r.jobs.Store(uri, job)
+	return r.updateEvent(uri, func(e *types.Event) bool {
+		e.FailureCount = 0
+		e.LastError = ""
+		return setStatus(e, types.StatusActive) == nil
+	})
+}
@@ -0,0 +1,400 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/codefresh-io/cronus/pkg/backend"
+	"github.com/codefresh-io/cronus/pkg/hermes"
+	"github.com/codefresh-io/cronus/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	cron "gopkg.in/robfig/cron.v2"
+)
+
+// getTestEvent stored event
+func getTestEvent(t *testing.T, store types.EventStore, uri string) *types.Event {
+	e, err := store.GetEvent(uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestRunner_PauseCronJob(t *testing.T) {
+	event := types.Event{
+		Expression: "0 0 4 * * *",
+		Message:    "test-message-1",
+		Secret:     "1234",
+		Status:     types.StatusFailing,
+	}
+	tests := []struct {
+		name    string
+		status  string
+		job     bool
+		missing bool
+		wantErr bool
+	}{
+		{
+			name:   "pause scheduled event",
+			status: types.StatusFailing,
+			job:    true,
+		},
+		{
+			name:   "pause invalid event",
+			status: types.StatusInvalid,
+		},
+		{
+			name:    "cannot pause expired event",
+			status:  types.StatusExpired,
+			wantErr: true,
+		},
+		{
+			name:    "event not found",
+			missing: true,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, _ := backend.NewMemoryEventStore("")
+			cronMock := &CronJobEngineMock{}
+			r := &Runner{
+				store: store,
+				cron:  cronMock,
+				jobs:  new(sync.Map),
+			}
+			uri := types.GetURI(event)
+			e := event
+			e.Status = tt.status
+			if !tt.missing {
+				assert.NoError(t, store.StoreEvent(e))
+			}
+			if tt.job {
+				r.jobs.Store(uri, cron.EntryID(1))
+				cronMock.On("Remove", cron.EntryID(1))
+			}
+			if err := r.PauseCronJob(uri); (err != nil) != tt.wantErr {
+				t.Errorf("Runner.PauseCronJob() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if _, ok := r.jobs.Load(uri); ok && !tt.wantErr {
+				t.Error("Runner.PauseCronJob() job is still scheduled")
+			}
+			if !tt.missing {
+				want := e
+				if !tt.wantErr {
+					want.Status = types.StatusPaused
+				}
+				assert.Equal(t, &want, getTestEvent(t, store, uri))
+			}
+			cronMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRunner_ResumeCronJob(t *testing.T) {
+	tests := []struct {
+		name       string
+		event      types.Event
+		addJobErr  error
+		wantStatus string
+		wantErr    bool
+	}{
+		{
+			name: "resume paused event",
+			event: types.Event{
+				Expression:   "0 0 4 * * *",
+				Message:      "test-message-1",
+				Secret:       "1234",
+				Status:       types.StatusPaused,
+				FailureCount: 3,
+				LastError:    "Test Error",
+			},
+			wantStatus: types.StatusActive,
+		},
+		{
+			name: "resume expired event",
+			event: types.Event{
+				Expression: "0 0 4 * * *",
+				Message:    "test-message-1",
+				Secret:     "1234",
+				Status:     types.StatusPaused,
+				Until:      "2018-01-01T00:00:00Z",
+			},
+			wantStatus: types.StatusExpired,
+			wantErr:    true,
+		},
+		{
+			name: "cannot resume active event",
+			event: types.Event{
+				Expression: "0 0 4 * * *",
+				Message:    "test-message-1",
+				Secret:     "1234",
+				Status:     types.StatusActive,
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail to schedule event",
+			event: types.Event{
+				Expression: "0 0 4 * * *",
+				Message:    "test-message-1",
+				Secret:     "1234",
+				Status:     types.StatusPaused,
+			},
+			addJobErr: errors.New("Test Error"),
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, _ := backend.NewMemoryEventStore("")
+			cronMock := &CronJobEngineMock{}
+			r := &Runner{
+				store: store,
+				cron:  cronMock,
+				jobs:  new(sync.Map),
+				limit: time.Minute,
+			}
+			uri := types.GetURI(tt.event)
+			assert.NoError(t, store.StoreEvent(tt.event))
+			if tt.event.Status == types.StatusPaused && tt.event.Until == "" {
+				cronMock.On("AddJob", tt.event.Expression, mock.Anything).Return(1, tt.addJobErr)
+			}
+			if err := r.ResumeCronJob(uri); (err != nil) != tt.wantErr {
+				t.Errorf("Runner.ResumeCronJob() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if _, ok := r.jobs.Load(uri); ok != (tt.wantStatus == types.StatusActive) {
+				t.Errorf("Runner.ResumeCronJob() job scheduled = %v", ok)
+			}
+			want := tt.event
+			if tt.wantStatus != "" {
+				want.Status = tt.wantStatus
+				if tt.wantStatus == types.StatusActive {
+					want.FailureCount = 0
+					want.LastError = ""
+				}
+			}
+			assert.Equal(t, &want, getTestEvent(t, store, uri))
+			cronMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRunner_TriggerEvent_EndTime(t *testing.T) {
+	tests := []struct {
+		name       string
+		until      time.Time
+		wantErr    error
+		wantStatus string
+	}{
+		{
+			name:       "expired event",
+			until:      time.Now().Add(-time.Hour),
+			wantErr:    ErrExpired,
+			wantStatus: types.StatusExpired,
+		},
+		{
+			name:       "last trigger completes event",
+			until:      time.Now().Add(time.Second),
+			wantStatus: types.StatusCompleted,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hermesMock := &HermesMock{}
+			store, _ := backend.NewMemoryEventStore("")
+			cronMock := &CronJobEngineMock{}
+			r := &Runner{
+				hermesSvc: hermesMock,
+				store:     store,
+				cron:      cronMock,
+				jobs:      new(sync.Map),
+				inflight:  newInflight(),
+				history:   newHistoryBuffer(store),
+			}
+			// yearly event: next fire time is after end time
+			e := types.Event{
+				Expression: "0 0 4 1 1 *",
+				Message:    "test-message-1",
+				Secret:     "1234",
+				Status:     types.StatusActive,
+				Until:      tt.until.Format(time.RFC3339),
+			}
+			uri := types.GetURI(e)
+			r.jobs.Store(uri, cron.EntryID(1))
+			cronMock.On("Remove", cron.EntryID(1))
+			if tt.wantErr == nil {
+				hermesMock.On("TriggerEvent", uri, mock.AnythingOfType("*hermes.NormalizedEvent")).Return(nil)
+			}
+			assert.NoError(t, store.StoreEvent(e))
+			if err := r.TriggerEvent(e); err != tt.wantErr {
+				t.Errorf("Runner.TriggerEvent() error = %v, want %v", err, tt.wantErr)
+			}
+			if _, ok := r.jobs.Load(uri); ok {
+				t.Error("Runner.TriggerEvent() finished event is still scheduled")
+			}
+			assert.Equal(t, tt.wantStatus, getTestEvent(t, store, uri).Status)
+			hermesMock.AssertExpectations(t)
+			cronMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRunner_StatusLifecycle(t *testing.T) {
+	store, err := backend.NewMemoryEventStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hermesMock := &HermesMock{}
+	cronMock := &CronJobEngineMock{}
+	cronMock.On("Start")
+	cronMock.On("AddJob", "0 0 4 * * *", mock.Anything).Return(1, nil).Once()
+	cronMock.On("Remove", cron.EntryID(1))
+	cronMock.On("AddJob", "0 0 4 * * *", mock.Anything).Return(2, nil).Once()
+	r := NewCronRunnerFull(store, hermesMock, cronMock, nil, time.Minute)
+	e := types.Event{
+		Expression: "0 0 4 * * *",
+		Message:    "test-message-1",
+		Secret:     "1234",
+		Status:     types.StatusPending,
+	}
+	uri := types.GetURI(e)
+	status := func() *types.Event {
+		stored, err := store.GetEvent(uri)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return stored
+	}
+	// scheduled
+	if err := r.AddCronJob(e); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, types.StatusActive, status().Status)
+	// two failed triggers
+	hermesMock.On("TriggerEvent", uri, mock.Anything).Return(errors.New("Test Error")).Twice()
+	r.TriggerEvent(e)
+	r.TriggerEvent(e)
+	assert.Equal(t, types.StatusFailing, status().Status)
+	assert.Equal(t, 2, status().FailureCount)
+	assert.Equal(t, "Test Error", status().LastError)
+	// paused
+	assert.NoError(t, r.PauseCronJob(uri))
+	assert.Equal(t, types.StatusPaused, status().Status)
+	// resumed: failure counter is reset
+	assert.NoError(t, r.ResumeCronJob(uri))
+	assert.Equal(t, types.StatusActive, status().Status)
+	assert.Equal(t, 0, status().FailureCount)
+	// successful trigger
+	hermesMock.On("TriggerEvent", uri, mock.Anything).Return(nil).Once()
+	assert.NoError(t, r.TriggerEvent(e))
+	assert.Equal(t, types.StatusActive, status().Status)
+	hermesMock.AssertExpectations(t)
+	cronMock.AssertExpectations(t)
+}
+
+func TestRunner_RemoveCronJob_Unscheduled(t *testing.T) {
+	tests := []struct {
+		name       string
+		event      types.Event
+		setup      func(r *Runner, uri string)
+		wantStatus string
+	}{
+		{
+			name:  "paused event",
+			event: types.Event{Expression: "0 0 4 * * *", Message: "paused", Secret: "1234", Status: types.StatusActive},
+			setup: func(r *Runner, uri string) {
+				assert.NoError(t, r.PauseCronJob(uri))
+			},
+			wantStatus: types.StatusPaused,
+		},
+		{
+			name: "expired event",
+			event: types.Event{Expression: "0 0 4 * * *", Message: "expired", Secret: "1234", Status: types.StatusActive,
+				Until: time.Now().Add(-time.Hour).Format(time.RFC3339)},
+			wantStatus: types.StatusExpired,
+		},
+		{
+			name:       "invalid event",
+			event:      types.Event{Expression: "*/5 * * * * *", Message: "invalid", Secret: "1234", Status: types.StatusActive},
+			wantStatus: types.StatusInvalid,
+		},
+		{
+			name:  "invalid event after reconcile",
+			event: types.Event{Expression: "*/5 * * * * *", Message: "invalid", Secret: "1234", Status: types.StatusActive},
+			setup: func(r *Runner, uri string) {
+				r.cron.(*CronJobEngineMock).On("Entries").Return([]cron.Entry{})
+				assert.Len(t, r.Reconcile().Invalid, 1)
+			},
+			wantStatus: types.StatusInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, _ := backend.NewMemoryEventStore("")
+			store.StoreEvent(tt.event)
+			cronMock := &CronJobEngineMock{}
+			cronMock.On("Start")
+			cronMock.On("AddJob", tt.event.Expression, mock.Anything).Return(1, nil)
+			cronMock.On("Remove", cron.EntryID(1))
+			r := NewCronRunnerFull(store, &HermesMock{}, cronMock, nil, time.Minute)
+			uri := types.GetURI(tt.event)
+			if tt.setup != nil {
+				tt.setup(r, uri)
+			}
+			stored, err := store.GetEvent(uri)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.wantStatus, stored.Status)
+			}
+			// unsubscribe
+			assert.NoError(t, r.RemoveCronJob(uri))
+			_, err = store.GetEvent(uri)
+			assert.Equal(t, types.ErrEventNotFound, err)
+		})
+	}
+}
+
+// failingHermes hermes stub failing trigger, after it is released
+type failingHermes struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (h *failingHermes) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (h *failingHermes) TriggerEvent(ctx context.Context, eventURI string, event *hermes.NormalizedEvent) error {
+	h.started <- struct{}{}
+	<-h.release
+	return errors.New("Test Error")
+}
+
+func TestRunner_RemoveCronJob_RunningTrigger(t *testing.T) {
+	store, _ := backend.NewMemoryEventStore("")
+	h := &failingHermes{started: make(chan struct{}), release: make(chan struct{})}
+	cronMock := &CronJobEngineMock{}
+	cronMock.On("Start")
+	cronMock.On("AddJob", "0 0 4 * * *", mock.Anything).Return(1, nil)
+	cronMock.On("Remove", cron.EntryID(1))
+	r := NewCronRunnerFull(store, h, cronMock, nil, time.Minute)
+	e := types.Event{Expression: "0 0 4 * * *", Message: "test-message-1", Secret: "1234"}
+	uri := types.GetURI(e)
+	assert.NoError(t, r.AddCronJob(e))
+	// unsubscribe while trigger is running
+	done := make(chan error)
+	go func() { done <- r.TriggerEvent(e) }()
+	<-h.started
+	assert.NoError(t, r.RemoveCronJob(uri))
+	close(h.release)
+	assert.Error(t, <-done)
+	// failed trigger does not store deleted event back
+	_, err := store.GetEvent(uri)
+	assert.Equal(t, types.ErrEventNotFound, err)
+	events, _ := store.GetAllEvents()
+	assert.Empty(t, events)
+}
@@ -0,0 +1,144 @@
+package cron
+
+import (
+	"sort"
+	"time"
+
+	"github.com/codefresh-io/cronus/pkg/transfer"
+	"github.com/codefresh-io/cronus/pkg/types"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/robfig/cron.v2"
+)
+
+// Export export stored events (of account, if not empty) into portable document, sorted by URI
+func (r *Runner) Export(account string) (*transfer.Document, error) {
+	all, err := r.accountEvents(account)
+	if err != nil {
+		log.WithError(err).Error("failed to load stored events")
+		return nil, err
+	}
+	doc := &transfer.Document{Version: transfer.Version, Time: time.Now().UTC(), Account: account, Events: []types.Event{}}
+	for _, e := range all {
+		doc.Events = append(doc.Events, transfer.Portable(e))
+	}
+	sort.Slice(doc.Events, func(i, j int) bool {
+		return types.GetURI(doc.Events[i]) < types.GetURI(doc.Events[j])
+	})
+	return doc, nil
+}
+
+// accountEvents get stored events of account; all events for empty account
+func (r *Runner) accountEvents(account string) ([]types.Event, error) {
+	if account == "" {
+		return r.store.GetAllEvents()
+	}
+	return r.store.GetAccountEvents(account)
+}
+
+// Import import events from document: events are validated against current limits as new subscriptions;
+// invalid events are reported and skipped; in dry run mode changes are reported, but not applied
+func (r *Runner) Import(doc *transfer.Document, mode string, dryRun bool) (*transfer.Report, error) {
+	if err := transfer.ValidMode(mode); err != nil {
+		return nil, err
+	}
+	all, err := r.accountEvents(doc.Account)
+	if err != nil {
+		log.WithError(err).Error("failed to load stored events")
+		return nil, err
+	}
+	existing := make(map[string]types.Event)
+	for _, e := range all {
+		existing[types.GetURI(e)] = e
+	}
+	report := &transfer.Report{Mode: mode, DryRun: dryRun}
+	imported := make(map[string]bool)
+	for _, e := range doc.Events {
+		e = transfer.Portable(e)
+		uri := types.GetURI(e)
+		imported[uri] = true
+		if doc.Account != "" && e.Account != doc.Account {
+			report.Invalid = append(report.Invalid, transfer.Invalid{URI: uri, Reason: "event does not belong to exported account"})
+			continue
+		}
+		old, exists := existing[uri]
+		if exists && transfer.Portable(old) == e {
+			report.Unchanged++
+			continue
+		}
+		if _, err = r.validateEvent(e); err != nil {
+			report.Invalid = append(report.Invalid, transfer.Invalid{URI: uri, Reason: err.Error()})
+			continue
+		}
+		if !dryRun {
+			if err = r.importEvent(e); err != nil {
+				report.Invalid = append(report.Invalid, transfer.Invalid{URI: uri, Reason: err.Error()})
+				continue
+			}
+		}
+		if exists {
+			report.Updated = append(report.Updated, uri)
+		} else {
+			report.Added = append(report.Added, uri)
+		}
+	}
+	if mode == transfer.ModeReplace {
+		for uri := range existing {
+			if imported[uri] {
+				continue
+			}
+			if !dryRun {
+				if err = r.RemoveCronJob(uri); err != nil {
+					log.WithError(err).WithField("event-uri", uri).Error("failed to remove event")
+					return report, err
+				}
+			}
+			report.Removed = append(report.Removed, uri)
+		}
+		sort.Strings(report.Removed)
+	}
+	log.WithFields(log.Fields{
+		"mode":      mode,
+		"dry-run":   dryRun,
+		"added":     len(report.Added),
+		"updated":   len(report.Updated),
+		"removed":   len(report.Removed),
+		"unchanged": report.Unchanged,
+		"invalid":   len(report.Invalid),
+	}).Info("events imported")
+	return report, nil
+}
+
+// importEvent schedule (unless paused) and store new or changed event, replacing existing job; description
+// and help, not kept in export document, are set as on subscription
+func (r *Runner) importEvent(e types.Event) error {
+	types.Describe(&e, r.cronguru)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// block status updates of running triggers, so imported event is not overwritten with stale status
+	r.statusMu.Lock()
+	defer r.statusMu.Unlock()
+	uri := types.GetURI(e)
+	var job cron.EntryID
+	if e.Status != types.StatusPaused {
+		var err error
+		if job, err = r.scheduleEvent(e); err != nil {
+			return err
+		}
+		e.Status = types.StatusActive
+	}
+	if err := r.store.StoreEvent(e); err != nil {
+		log.WithError(err).Error("failed to store event")
+		if job != 0 {
+			r.cron.Remove(job)
+		}
+		return err
+	}
+	if old, ok := r.jobs.Load(uri); ok {
+		r.cron.Remove(old.(cron.EntryID))
+		r.jobs.Delete(uri)
+	}
+	if job != 0 {
+		r.jobs.Store(uri, job)
+	}
+	return nil
+}
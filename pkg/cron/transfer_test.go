@@ -0,0 +1,152 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/codefresh-io/cronus/pkg/backend"
+	"github.com/codefresh-io/cronus/pkg/transfer"
+	"github.com/codefresh-io/cronus/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	cron "gopkg.in/robfig/cron.v2"
+)
+
+func TestRunner_Import(t *testing.T) {
+	kept := types.Event{Expression: "0 0 4 * * *", Message: "kept", Account: "acc1", Secret: "1234", Status: types.StatusActive}
+	changed := types.Event{Expression: "0 0 5 * * *", Message: "changed", Account: "acc1", Secret: "1234", Status: types.StatusActive}
+	missing := types.Event{Expression: "0 0 6 * * *", Message: "missing", Account: "acc1", Secret: "1234", Status: types.StatusActive}
+	other := types.Event{Expression: "0 0 7 * * *", Message: "other", Account: "acc2", Secret: "1234", Status: types.StatusActive}
+	updated := changed
+	updated.Secret = "5678"
+	added := types.Event{Expression: "0 0 8 * * *", Message: "added", Account: "acc1", Secret: "1234"}
+	paused := types.Event{Expression: "0 0 9 * * *", Message: "paused", Account: "acc1", Secret: "1234", Status: types.StatusPaused}
+	tooShort := types.Event{Expression: "*/5 * * * * *", Message: "short", Account: "acc1", Secret: "1234"}
+	wrongAccount := types.Event{Expression: "0 0 4 * * *", Message: "wrong", Account: "acc2", Secret: "1234"}
+	doc := &transfer.Document{
+		Version: transfer.Version,
+		Account: "acc1",
+		Events:  []types.Event{kept, updated, added, paused, tooShort, wrongAccount},
+	}
+	tests := []struct {
+		name       string
+		mode       string
+		dryRun     bool
+		setup      func(cronMock *CronJobEngineMock)
+		want       transfer.Report
+		wantStored []string
+	}{
+		{
+			name: "merge",
+			mode: transfer.ModeMerge,
+			setup: func(cronMock *CronJobEngineMock) {
+				cronMock.On("AddJob", updated.Expression, mock.Anything).Return(10, nil)
+				cronMock.On("Remove", cron.EntryID(2))
+				cronMock.On("AddJob", added.Expression, mock.Anything).Return(11, nil)
+			},
+			want: transfer.Report{
+				Mode:      transfer.ModeMerge,
+				Added:     []string{types.GetURI(added), types.GetURI(paused)},
+				Updated:   []string{types.GetURI(updated)},
+				Unchanged: 1,
+			},
+			wantStored: []string{"kept", "changed", "missing", "other", "added", "paused"},
+		},
+		{
+			name: "replace",
+			mode: transfer.ModeReplace,
+			setup: func(cronMock *CronJobEngineMock) {
+				cronMock.On("AddJob", updated.Expression, mock.Anything).Return(10, nil)
+				cronMock.On("Remove", cron.EntryID(2))
+				cronMock.On("AddJob", added.Expression, mock.Anything).Return(11, nil)
+				cronMock.On("Remove", cron.EntryID(3))
+			},
+			want: transfer.Report{
+				Mode:      transfer.ModeReplace,
+				Added:     []string{types.GetURI(added), types.GetURI(paused)},
+				Updated:   []string{types.GetURI(updated)},
+				Removed:   []string{types.GetURI(missing)},
+				Unchanged: 1,
+			},
+			wantStored: []string{"kept", "changed", "other", "added", "paused"},
+		},
+		{
+			name:   "dry run",
+			mode:   transfer.ModeReplace,
+			dryRun: true,
+			want: transfer.Report{
+				Mode:      transfer.ModeReplace,
+				DryRun:    true,
+				Added:     []string{types.GetURI(added), types.GetURI(paused)},
+				Updated:   []string{types.GetURI(updated)},
+				Removed:   []string{types.GetURI(missing)},
+				Unchanged: 1,
+			},
+			wantStored: []string{"kept", "changed", "missing", "other"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, _ := backend.NewMemoryEventStore("")
+			cronMock := &CronJobEngineMock{}
+			cronMock.On("Start")
+			for i, e := range []types.Event{kept, changed, missing, other} {
+				store.StoreEvent(e)
+				cronMock.On("AddJob", e.Expression, mock.Anything).Return(i+1, nil).Once()
+			}
+			r := NewCronRunnerFull(store, &HermesMock{}, cronMock, nil, time.Minute)
+			if tt.setup != nil {
+				tt.setup(cronMock)
+			}
+			got, err := r.Import(doc, tt.mode, tt.dryRun)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, 2, len(got.Invalid))
+			got.Invalid = nil
+			assert.Equal(t, tt.want, *got)
+			all, _ := store.GetAllEvents()
+			var stored []string
+			for _, e := range all {
+				stored = append(stored, e.Message)
+			}
+			assert.ElementsMatch(t, tt.wantStored, stored)
+			if !tt.dryRun {
+				e, _ := store.GetEvent(types.GetURI(updated))
+				assert.Equal(t, "5678", e.Secret)
+				assert.Equal(t, types.StatusActive, e.Status)
+				e, _ = store.GetEvent(types.GetURI(paused))
+				assert.Equal(t, types.StatusPaused, e.Status)
+				// description and help are rebuilt, as on subscription
+				_, err = time.Parse(time.RFC3339, e.Description)
+				assert.NoError(t, err, "description")
+				assert.NotEmpty(t, e.Help)
+				_, scheduled := r.jobs.Load(types.GetURI(paused))
+				assert.False(t, scheduled)
+			}
+			cronMock.AssertExpectations(t)
+		})
+	}
+	// bad mode
+	r := &Runner{}
+	_, err := r.Import(doc, "other", false)
+	assert.Error(t, err)
+}
+
+func TestRunner_Export(t *testing.T) {
+	store, _ := backend.NewMemoryEventStore("")
+	e1 := types.Event{Expression: "0 0 5 * * *", Message: "b", Account: "acc1", Secret: "1234", Status: types.StatusFailing, FailureCount: 2}
+	e2 := types.Event{Expression: "0 0 4 * * *", Message: "a", Account: "acc1", Secret: "1234", Status: types.StatusPaused}
+	e3 := types.Event{Expression: "0 0 4 * * *", Message: "c", Account: "acc2", Secret: "1234"}
+	for _, e := range []types.Event{e1, e2, e3} {
+		store.StoreEvent(e)
+	}
+	r := &Runner{store: store}
+	doc, err := r.Export("acc1")
+	assert.NoError(t, err)
+	assert.Equal(t, transfer.Version, doc.Version)
+	assert.Equal(t, "acc1", doc.Account)
+	assert.Equal(t, []types.Event{transfer.Portable(e2), transfer.Portable(e1)}, doc.Events)
+	doc, _ = r.Export("")
+	assert.Equal(t, 3, len(doc.Events))
+}
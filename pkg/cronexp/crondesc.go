@@ -22,6 +22,10 @@ func NewCronExpression() Service {
 
 func (expr *CronExpression) DescribeCronExpression(expression string) (string, error) {
 	log.WithField("expression", expression).Debug("describing cron expression")
+	// 5 fields crontab expression has no seconds field
+	if spec, err := Normalize(expression, DialectAuto); err == nil {
+		expression = spec
+	}
 
 	c := cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.DowOptional | cron.Descriptor)
 	s, err := c.Parse(expression)
@@ -55,3 +55,18 @@ func TestAPIEndpoint_DescribeCronExpression(t *testing.T) {
 		})
 	}
 }
+
+func TestCronExpression_DescribeCrontab(t *testing.T) {
+	// 5 fields expression is minute first, same as scheduled by cron engine
+	got, err := NewCronExpression().DescribeCronExpression("30 2 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, err := time.Parse(time.RFC3339, got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.Hour() != 2 || next.Minute() != 30 || next.Second() != 0 {
+		t.Errorf("DescribeCronExpression() = %v, want next 02:30:00", got)
+	}
+}
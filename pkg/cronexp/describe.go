@@ -0,0 +1,175 @@
+package cronexp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	monthTitles = []string{"", "January", "February", "March", "April", "May", "June",
+		"July", "August", "September", "October", "November", "December"}
+	dowTitles = []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+	// time field units, in 6-field order
+	units = []string{"second", "minute", "hour"}
+)
+
+// Describe describe cron spec (with H tokens expanded) in plain English, like "At 02:30:00, every day"
+func Describe(spec string) (string, error) {
+	if err := Lint(spec); err != nil {
+		return "", err
+	}
+	tz, expression, _ := splitSpec(spec)
+	var description string
+	switch {
+	case strings.HasPrefix(expression, everyDescriptor):
+		description = "every " + strings.TrimSpace(expression[len(everyDescriptor):])
+	case strings.HasPrefix(expression, "@"):
+		description = descriptors[expression]
+	default:
+		tokens := strings.Fields(expression)
+		if len(tokens) == 5 {
+			tokens = append([]string{"0"}, tokens...)
+		}
+		description = describeTime(tokens[:3])
+		// "every day" is implied for schedules that fire more than once a day
+		if days := describeDays(tokens[3], tokens[4], tokens[5]); days != "every day" || isNumber(tokens[2]) {
+			description += ", " + days
+		}
+	}
+	if tz != "" {
+		description += ", " + tz + " time"
+	}
+	return strings.ToUpper(description[:1]) + description[1:], nil
+}
+
+// describeTime describe second, minute and hour fields
+func describeTime(tokens []string) string {
+	second, serr := strconv.Atoi(tokens[0])
+	minute, merr := strconv.Atoi(tokens[1])
+	hour, herr := strconv.Atoi(tokens[2])
+	if serr == nil && merr == nil {
+		if herr == nil {
+			return fmt.Sprintf("at %02d:%02d:%02d", hour, minute, second)
+		}
+		if isAny(tokens[2]) {
+			return fmt.Sprintf("at %02d:%02d past every hour", minute, second)
+		}
+		return fmt.Sprintf("at %02d:%02d past the hour, %s", minute, second, describeField(tokens[2], units[2], nil))
+	}
+	var parts []string
+	for i, token := range tokens {
+		// "every minute" and "every hour" are implied, unless smaller unit is single value
+		if isAny(token) && i > 0 && !isNumber(tokens[i-1]) {
+			continue
+		}
+		parts = append(parts, describeField(token, units[i], nil))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// describeDays describe day of month, month and day of week fields
+func describeDays(dom, month, dow string) string {
+	var days []string
+	if !isAny(dom) {
+		days = append(days, describeField(dom, "day", nil)+" of the month")
+	}
+	if !isAny(dow) {
+		days = append(days, describeField(dow, "day of the week", dowTitles))
+	}
+	// day of month and day of week are matched with OR, when both are set
+	description := strings.Join(days, " or ")
+	if description == "" {
+		description = "every day"
+	}
+	if !isAny(month) {
+		description += ", " + describeField(month, "month", monthTitles)
+	}
+	return description
+}
+
+// describeField describe time unit field, like "every 15 minutes"
+func describeField(token, unit string, titles []string) string {
+	if isAny(token) {
+		return "every " + unit
+	}
+	if isNumber(token) || isName(token) {
+		return titlePrefix(unit) + title(token, titles)
+	}
+	if !strings.Contains(token, ",") {
+		rangeAndStep := strings.Split(token, "/")
+		if len(rangeAndStep) == 2 {
+			every := "every " + rangeAndStep[1] + " " + unit + "s"
+			switch low := strings.Split(rangeAndStep[0], "-"); {
+			case isAny(rangeAndStep[0]):
+				return every
+			case len(low) == 2:
+				return fmt.Sprintf("%s from %s through %s", every, title(low[0], titles), title(low[1], titles))
+			default:
+				return fmt.Sprintf("%s starting at %s", every, title(low[0], titles))
+			}
+		}
+		if low := strings.Split(token, "-"); len(low) == 2 {
+			return fmt.Sprintf("%s%s through %s", titlePrefix(unit+"s"), title(low[0], titles), title(low[1], titles))
+		}
+	}
+	items := strings.Split(token, ",")
+	for i, item := range items {
+		items[i] = title(item, titles)
+	}
+	if len(items) > 1 {
+		return fmt.Sprintf("%s%s and %s", titlePrefix(unit+"s"), strings.Join(items[:len(items)-1], ", "), items[len(items)-1])
+	}
+	return titlePrefix(unit) + items[0]
+}
+
+// titlePrefix prefix of field value: "on Monday", "in March", "on day 1", "at minute 5"
+func titlePrefix(unit string) string {
+	switch {
+	case strings.HasPrefix(unit, "day of the week"):
+		return "on "
+	case strings.HasPrefix(unit, "month"):
+		return "in "
+	case strings.HasPrefix(unit, "day"):
+		return "on " + unit + " "
+	default:
+		return "at " + unit + " "
+	}
+}
+
+// title get month or day of week title for number or name; other values are kept as is
+func title(value string, titles []string) string {
+	if titles == nil {
+		return value
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		names := dowNames
+		if len(titles) == len(monthTitles) {
+			names = monthNames
+		}
+		var ok bool
+		if n, ok = names[strings.ToLower(value)]; !ok {
+			return value
+		}
+	}
+	if n < 0 || n >= len(titles) {
+		return value
+	}
+	return titles[n]
+}
+
+func isAny(token string) bool {
+	return token == "*" || token == "?"
+}
+
+func isNumber(token string) bool {
+	_, err := strconv.Atoi(token)
+	return err == nil
+}
+
+func isName(token string) bool {
+	_, month := monthNames[strings.ToLower(token)]
+	_, dow := dowNames[strings.ToLower(token)]
+	return month || dow
+}
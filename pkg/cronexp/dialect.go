@@ -0,0 +1,111 @@
+package cronexp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// cron expression dialects
+const (
+	// DialectAuto 6 fields expression (seconds first) or 5 fields Unix crontab expression
+	DialectAuto = ""
+	// DialectCrontab 5 fields Unix crontab expression: minute hour day-of-month month day-of-week
+	DialectCrontab = "crontab"
+	// DialectSeconds 6 fields expression: second minute hour day-of-month month day-of-week
+	DialectSeconds = "seconds"
+	// CrontabPrefix expression prefix, selecting crontab dialect: "@crontab 30 2 * * *"
+	CrontabPrefix = "@crontab "
+)
+
+// ValidDialect check cron expression dialect name; empty dialect is auto
+func ValidDialect(dialect string) bool {
+	return dialect == DialectAuto || dialect == DialectCrontab || dialect == DialectSeconds
+}
+
+// Normalize convert cron expression of dialect into 6 fields (seconds first) spec, used by cron engine,
+// describer and validator; crontab expression fires at second 0 and accepts 7 for Sunday.
+// Expression with @crontab prefix is crontab expression; H tokens and time zone are kept.
+func Normalize(expression, dialect string) (string, error) {
+	if !ValidDialect(dialect) {
+		return "", fmt.Errorf("unknown cron dialect '%s': should be %s or %s", dialect, DialectCrontab, DialectSeconds)
+	}
+	tz, expr, prefixed, err := splitExpression(expression)
+	if err != nil {
+		return "", err
+	}
+	if prefixed {
+		if dialect == DialectSeconds {
+			return "", fmt.Errorf("'%s' prefix conflicts with %s dialect", strings.TrimSpace(CrontabPrefix), dialect)
+		}
+		dialect = DialectCrontab
+	}
+	if tz != "" {
+		tz = "TZ=" + tz + " "
+	}
+	// predefined schedules are the same in all dialects
+	if strings.HasPrefix(expr, "@") {
+		return tz + expr, nil
+	}
+	tokens := strings.Fields(expr)
+	switch {
+	case dialect == DialectCrontab && len(tokens) != 5:
+		return "", fmt.Errorf("crontab expression should have 5 fields, found %d", len(tokens))
+	case dialect == DialectSeconds && len(tokens) != 6:
+		return "", fmt.Errorf("expression should have 6 fields (seconds first), found %d", len(tokens))
+	case len(tokens) != 5:
+		// 6 fields expression or wrong number of fields (reported by validator)
+		return tz + strings.Join(tokens, " "), nil
+	}
+	tokens[4] = crontabDow(tokens[4])
+	return tz + "0 " + strings.Join(tokens, " "), nil
+}
+
+// splitExpression split time zone and @crontab prefix from cron expression; returns time zone, expression
+// and true, if expression has @crontab prefix
+func splitExpression(expression string) (string, string, bool, error) {
+	expression = strings.TrimSpace(expression)
+	// crontab prefix goes before or after time zone
+	prefixed := strings.HasPrefix(expression, CrontabPrefix)
+	tz, expr, err := splitSpec(strings.TrimPrefix(expression, CrontabPrefix))
+	if err != nil {
+		return "", "", false, err
+	}
+	if strings.HasPrefix(expr, CrontabPrefix) {
+		prefixed = true
+		expr = strings.TrimSpace(strings.TrimPrefix(expr, CrontabPrefix))
+	}
+	return tz, expr, prefixed, nil
+}
+
+// isCrontab true, if Normalize reads expression of dialect as crontab expression and adds seconds field
+func isCrontab(expression, dialect string) bool {
+	_, expr, prefixed, err := splitExpression(expression)
+	if err != nil || strings.HasPrefix(expr, "@") {
+		return false
+	}
+	switch {
+	case prefixed || dialect == DialectCrontab:
+		return true
+	case dialect == DialectSeconds:
+		return false
+	}
+	return len(strings.Fields(expr)) == 5
+}
+
+// crontabDow replace crontab Sunday (7) with 0 in day of week field
+func crontabDow(token string) string {
+	items := strings.Split(token, ",")
+	for i, item := range items {
+		switch {
+		case item == "7":
+			items[i] = "0"
+		case strings.HasSuffix(item, "-7") && !strings.Contains(item, "/"):
+			if item == "7-7" {
+				items[i] = "0"
+			} else {
+				items[i] = strings.TrimSuffix(item, "-7") + "-6,0"
+			}
+		}
+	}
+	return strings.Join(items, ",")
+}
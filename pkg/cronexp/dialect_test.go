@@ -0,0 +1,100 @@
+package cronexp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		dialect    string
+		want       string
+		wantErr    string
+	}{
+		{
+			name:       "6 fields",
+			expression: "0 30 2 * * *",
+			want:       "0 30 2 * * *",
+		},
+		{
+			name:       "5 fields",
+			expression: "30 2 * * *",
+			want:       "0 30 2 * * *",
+		},
+		{
+			name:       "crontab prefix",
+			expression: "@crontab 30 2 * * *",
+			want:       "0 30 2 * * *",
+		},
+		{
+			name:       "crontab prefix with time zone",
+			expression: "TZ=Asia/Tokyo @crontab 30 2 * * *",
+			want:       "TZ=Asia/Tokyo 0 30 2 * * *",
+		},
+		{
+			name:       "time zone after crontab prefix",
+			expression: "@crontab TZ=Asia/Tokyo 30 2 * * *",
+			want:       "TZ=Asia/Tokyo 0 30 2 * * *",
+		},
+		{
+			name:       "crontab Sunday",
+			expression: "30 2 * * 1,7",
+			dialect:    DialectCrontab,
+			want:       "0 30 2 * * 1,0",
+		},
+		{
+			name:       "crontab range to Sunday",
+			expression: "30 2 * * 5-7",
+			dialect:    DialectCrontab,
+			want:       "0 30 2 * * 5-6,0",
+		},
+		{
+			name:       "keep H tokens",
+			expression: "H H * * *",
+			want:       "0 H H * * *",
+		},
+		{
+			name:       "predefined schedule",
+			expression: "@crontab @daily",
+			want:       "@daily",
+		},
+		{
+			name:       "6 fields in crontab dialect",
+			expression: "0 30 2 * * *",
+			dialect:    DialectCrontab,
+			wantErr:    "crontab expression should have 5 fields, found 6",
+		},
+		{
+			name:       "5 fields in seconds dialect",
+			expression: "30 2 * * *",
+			dialect:    DialectSeconds,
+			wantErr:    "expression should have 6 fields (seconds first), found 5",
+		},
+		{
+			name:       "crontab prefix in seconds dialect",
+			expression: "@crontab 30 2 * * *",
+			dialect:    DialectSeconds,
+			wantErr:    "'@crontab' prefix conflicts with seconds dialect",
+		},
+		{
+			name:       "unknown dialect",
+			expression: "30 2 * * *",
+			dialect:    "quartz",
+			wantErr:    "unknown cron dialect 'quartz': should be crontab or seconds",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Normalize(tt.expression, tt.dialect)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
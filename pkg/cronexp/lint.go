@@ -0,0 +1,192 @@
+package cronexp
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// FieldError invalid cron expression field
+	FieldError struct {
+		// Position field position in expression (1 based, time zone is not counted)
+		Position int    `json:"position"`
+		Field    string `json:"field"`
+		Value    string `json:"value"`
+		Reason   string `json:"reason"`
+	}
+
+	// fieldBounds cron expression field bounds and value names
+	fieldBounds struct {
+		name     string
+		min, max int
+		names    map[string]int
+	}
+)
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s field '%s' (position %d): %s", e.Field, e.Value, e.Position, e.Reason)
+}
+
+var (
+	monthNames = map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}
+	dowNames = map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}
+	// field bounds, in 6-field (seconds first) order; same as cron engine bounds
+	fields = []fieldBounds{
+		{"second", 0, 59, nil},
+		{"minute", 0, 59, nil},
+		{"hour", 0, 23, nil},
+		{"day of month", 1, 31, nil},
+		{"month", 1, 12, monthNames},
+		{"day of week", 0, 6, dowNames},
+	}
+	// predefined schedules
+	descriptors = map[string]string{
+		"@yearly":   "once a year, at midnight on January 1",
+		"@annually": "once a year, at midnight on January 1",
+		"@monthly":  "once a month, at midnight on day 1 of the month",
+		"@weekly":   "once a week, at midnight on Sunday",
+		"@daily":    "once a day, at midnight",
+		"@midnight": "once a day, at midnight",
+		"@hourly":   "once an hour, at the beginning of the hour",
+	}
+)
+
+// everyDescriptor fixed interval schedule prefix
+const everyDescriptor = "@every "
+
+// splitSpec split cron spec into time zone and expression
+func splitSpec(spec string) (string, string, error) {
+	spec = strings.TrimSpace(spec)
+	if !strings.HasPrefix(spec, "TZ=") {
+		return "", spec, nil
+	}
+	i := strings.Index(spec, " ")
+	if i < 0 {
+		return "", "", errors.New("missing cron expression after time zone")
+	}
+	return spec[3:i], strings.TrimSpace(spec[i:]), nil
+}
+
+// Lint check cron spec (with H tokens expanded) the same way cron engine parses it; returns *FieldError,
+// if specific field is invalid
+func Lint(spec string) error {
+	tz, expression, err := splitSpec(spec)
+	if err != nil {
+		return err
+	}
+	if tz != "" {
+		if _, err = time.LoadLocation(tz); err != nil {
+			return fmt.Errorf("unknown time zone '%s'", tz)
+		}
+	}
+	if strings.HasPrefix(expression, "@") {
+		if _, ok := descriptors[expression]; ok {
+			return nil
+		}
+		if strings.HasPrefix(expression, everyDescriptor) {
+			d, err := time.ParseDuration(strings.TrimSpace(expression[len(everyDescriptor):]))
+			if err != nil {
+				return fmt.Errorf("bad @every duration: %v", err)
+			}
+			if d <= 0 {
+				return errors.New("@every duration should be positive")
+			}
+			return nil
+		}
+		return fmt.Errorf("unknown predefined schedule '%s'", expression)
+	}
+	tokens := strings.Fields(expression)
+	if len(tokens) != 5 && len(tokens) != 6 {
+		return fmt.Errorf("expected 5 or 6 fields, found %d", len(tokens))
+	}
+	// 5-field expression has no seconds field
+	offset := 6 - len(tokens)
+	for i, token := range tokens {
+		if reason := lintField(token, fields[i+offset]); reason != "" {
+			return &FieldError{Position: i + 1, Field: fields[i+offset].name, Value: token, Reason: reason}
+		}
+	}
+	return nil
+}
+
+// LintExpression lint spec normalized from expression of dialect (see Lint); wrong field position refers to
+// expression fields, not counting seconds field added to crontab expression
+func LintExpression(spec, expression, dialect string) error {
+	err := Lint(spec)
+	if fe, ok := err.(*FieldError); ok && isCrontab(expression, dialect) {
+		fe.Position--
+	}
+	return err
+}
+
+// lintField check field value: comma separated list of ranges; returns problem description
+func lintField(value string, f fieldBounds) string {
+	if strings.ContainsAny(value, "LW#") && !hasName(value, f.names) {
+		return "'L', 'W' and '#' are not supported"
+	}
+	for _, expr := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' }) {
+		rangeAndStep := strings.Split(expr, "/")
+		if len(rangeAndStep) > 2 {
+			return fmt.Sprintf("too many slashes in '%s'", expr)
+		}
+		lowAndHigh := strings.Split(rangeAndStep[0], "-")
+		if lowAndHigh[0] != "*" && lowAndHigh[0] != "?" {
+			if len(lowAndHigh) > 2 {
+				return fmt.Sprintf("too many hyphens in '%s'", expr)
+			}
+			start, reason := parseValue(lowAndHigh[0], f)
+			if reason != "" {
+				return reason
+			}
+			end := start
+			if len(lowAndHigh) == 2 {
+				if end, reason = parseValue(lowAndHigh[1], f); reason != "" {
+					return reason
+				}
+			}
+			if start > end {
+				return fmt.Sprintf("range start %d is beyond range end %d", start, end)
+			}
+		}
+		if len(rangeAndStep) == 2 {
+			step, err := strconv.Atoi(rangeAndStep[1])
+			if err != nil || step <= 0 {
+				return fmt.Sprintf("step '%s' should be a positive number", rangeAndStep[1])
+			}
+		}
+	}
+	return ""
+}
+
+// hasName check if value contains field value name (like WED), which may contain L, W or #
+func hasName(value string, names map[string]int) bool {
+	for _, token := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '-' || r == '/' }) {
+		if _, ok := names[strings.ToLower(token)]; !ok && strings.ContainsAny(token, "LW#") {
+			return false
+		}
+	}
+	return len(names) > 0
+}
+
+// parseValue parse field number or name, checking field bounds; returns problem description
+func parseValue(value string, f fieldBounds) (int, string) {
+	if n, ok := f.names[strings.ToLower(value)]; ok {
+		return n, ""
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Sprintf("'%s' is not a number", value)
+	}
+	if n < f.min || n > f.max {
+		return 0, fmt.Sprintf("%d is out of range %d-%d", n, f.min, f.max)
+	}
+	return n, ""
+}
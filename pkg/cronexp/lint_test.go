@@ -0,0 +1,157 @@
+package cronexp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name      string
+		spec      string
+		wantErr   string
+		wantField *FieldError
+	}{
+		{
+			name: "6-field expression",
+			spec: "0 30 2 * * MON-FRI",
+		},
+		{
+			name: "5-field expression",
+			spec: "30 2 * * *",
+		},
+		{
+			name: "predefined schedule with time zone",
+			spec: "TZ=Asia/Tokyo @daily",
+		},
+		{
+			name: "interval",
+			spec: "@every 1h30m",
+		},
+		{
+			name:      "out of range",
+			spec:      "0 0 24 * * *",
+			wantField: &FieldError{Position: 3, Field: "hour", Value: "24", Reason: "24 is out of range 0-23"},
+		},
+		{
+			name:      "5-field out of range",
+			spec:      "60 2 * * *",
+			wantField: &FieldError{Position: 1, Field: "minute", Value: "60", Reason: "60 is out of range 0-59"},
+		},
+		{
+			name:      "zero step",
+			spec:      "*/0 * * * * *",
+			wantField: &FieldError{Position: 1, Field: "second", Value: "*/0", Reason: "step '0' should be a positive number"},
+		},
+		{
+			name:      "reversed range",
+			spec:      "0 0 9 * 6-2 *",
+			wantField: &FieldError{Position: 5, Field: "month", Value: "6-2", Reason: "range start 6 is beyond range end 2"},
+		},
+		{
+			name:      "bad name",
+			spec:      "0 0 9 * * MONDAY",
+			wantField: &FieldError{Position: 6, Field: "day of week", Value: "MONDAY", Reason: "'MONDAY' is not a number"},
+		},
+		{
+			name:      "quartz last day",
+			spec:      "0 0 9 L * *",
+			wantField: &FieldError{Position: 4, Field: "day of month", Value: "L", Reason: "'L', 'W' and '#' are not supported"},
+		},
+		{
+			name:    "year field",
+			spec:    "0 0 9 1 * * 2020",
+			wantErr: "expected 5 or 6 fields, found 7",
+		},
+		{
+			name:    "unknown time zone",
+			spec:    "TZ=Mars/Olympus 0 0 9 * * *",
+			wantErr: "unknown time zone 'Mars/Olympus'",
+		},
+		{
+			name:    "unknown descriptor",
+			spec:    "@often",
+			wantErr: "unknown predefined schedule '@often'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Lint(tt.spec)
+			switch {
+			case tt.wantField != nil:
+				assert.Equal(t, tt.wantField, err)
+			case tt.wantErr != "":
+				assert.EqualError(t, err, tt.wantErr)
+			default:
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestLintExpression(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		dialect    string
+		want       *FieldError
+	}{
+		{
+			name:       "6-field expression",
+			expression: "0 61 2 * * *",
+			want:       &FieldError{Position: 2, Field: "minute", Value: "61", Reason: "61 is out of range 0-59"},
+		},
+		{
+			name:       "5-field expression",
+			expression: "61 2 * * *",
+			want:       &FieldError{Position: 1, Field: "minute", Value: "61", Reason: "61 is out of range 0-59"},
+		},
+		{
+			name:       "crontab prefix with time zone",
+			expression: "TZ=UTC @crontab 0 2 * 13 *",
+			want:       &FieldError{Position: 4, Field: "month", Value: "13", Reason: "13 is out of range 1-12"},
+		},
+		{
+			name:       "crontab dialect",
+			expression: "0 25 * * *",
+			dialect:    DialectCrontab,
+			want:       &FieldError{Position: 2, Field: "hour", Value: "25", Reason: "25 is out of range 0-23"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := Normalize(tt.expression, tt.dialect)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.want, LintExpression(spec, tt.expression, tt.dialect))
+		})
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"0 30 2 * * *", "At 02:30:00, every day"},
+		{"30 2 * * *", "At 02:30:00, every day"},
+		{"0 */5 * * * *", "At second 0, every 5 minutes"},
+		{"0 0 9-17 * * MON-FRI", "At 00:00 past the hour, at hours 9 through 17, on Monday through Friday"},
+		{"0 0 0 1,15 * 1", "At 00:00:00, on days 1 and 15 of the month or on Monday"},
+		{"0 0 0 * JAN,JUL *", "At 00:00:00, every day, in January and July"},
+		{"TZ=Asia/Tokyo 0 0 6 * * *", "At 06:00:00, every day, Asia/Tokyo time"},
+		{"@weekly", "Once a week, at midnight on Sunday"},
+		{"@every 1h30m", "Every 1h30m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := Describe(tt.spec)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+	_, err := Describe("0 0 24 * * *")
+	assert.Error(t, err)
+}
@@ -0,0 +1,125 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type (
+	// Check health check function; returns error if unhealthy
+	Check func() error
+
+	// Result single health check result
+	Result struct {
+		OK      bool   `json:"ok"`
+		Message string `json:"message,omitempty"`
+	}
+
+	// Report readiness report: overall status and breakdown of each check
+	Report struct {
+		Ready  bool              `json:"ready"`
+		Checks map[string]Result `json:"checks"`
+	}
+
+	// Checker set of named health checks
+	Checker struct {
+		mu     sync.Mutex
+		names  []string
+		checks map[string]Check
+	}
+
+	// Prober periodically probes remote service and remembers the last successful answer
+	Prober struct {
+		probe    func(ctx context.Context) error
+		interval time.Duration
+		timeout  time.Duration
+		mu       sync.Mutex
+		last     time.Time
+		lastErr  error
+	}
+)
+
+// NewChecker create empty health checker
+func NewChecker() *Checker {
+	return &Checker{checks: make(map[string]Check)}
+}
+
+// Add add named health check
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.checks[name]; !ok {
+		c.names = append(c.names, name)
+	}
+	c.checks[name] = check
+}
+
+// Run run all health checks
+func (c *Checker) Run() Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	report := Report{Ready: true, Checks: make(map[string]Result)}
+	for _, name := range c.names {
+		if err := c.checks[name](); err != nil {
+			log.WithError(err).WithField("check", name).Warn("health check failed")
+			report.Ready = false
+			report.Checks[name] = Result{OK: false, Message: err.Error()}
+			continue
+		}
+		report.Checks[name] = Result{OK: true}
+	}
+	return report
+}
+
+// NewProber create new prober: probe is invoked every interval, with timeout
+func NewProber(probe func(ctx context.Context) error, interval, timeout time.Duration) *Prober {
+	return &Prober{probe: probe, interval: interval, timeout: timeout}
+}
+
+// Run probe immediately and then periodically, until stop is closed
+func (p *Prober) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		p.Probe()
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Probe invoke probe once and record result
+func (p *Prober) Probe() {
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+	err := p.probe(ctx)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastErr = err
+	if err == nil {
+		p.last = time.Now()
+	}
+}
+
+// Check check that remote service answered probe within maxAge
+func (p *Prober) Check(maxAge time.Duration) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.last.IsZero() {
+		if p.lastErr != nil {
+			return fmt.Errorf("no successful probe yet: %v", p.lastErr)
+		}
+		return errors.New("no successful probe yet")
+	}
+	if age := time.Since(p.last); age > maxAge {
+		return fmt.Errorf("last successful probe %v ago: %v", age.Round(time.Second), p.lastErr)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestChecker_Run(t *testing.T) {
+	tests := []struct {
+		name   string
+		checks map[string]Check
+		want   Report
+	}{
+		{
+			name: "all checks pass",
+			checks: map[string]Check{
+				"store":  func() error { return nil },
+				"runner": func() error { return nil },
+			},
+			want: Report{
+				Ready: true,
+				Checks: map[string]Result{
+					"store":  {OK: true},
+					"runner": {OK: true},
+				},
+			},
+		},
+		{
+			name: "one check fails",
+			checks: map[string]Check{
+				"store":  func() error { return errors.New("database not open") },
+				"runner": func() error { return nil },
+			},
+			want: Report{
+				Ready: false,
+				Checks: map[string]Result{
+					"store":  {OK: false, Message: "database not open"},
+					"runner": {OK: true},
+				},
+			},
+		},
+		{
+			name:   "no checks",
+			checks: map[string]Check{},
+			want:   Report{Ready: true, Checks: map[string]Result{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewChecker()
+			for name, check := range tt.checks {
+				c.Add(name, check)
+			}
+			if got := c.Run(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Checker.Run() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProber_Check(t *testing.T) {
+	tests := []struct {
+		name     string
+		probeErr error
+		probe    bool
+		maxAge   time.Duration
+		wantErr  bool
+	}{
+		{
+			name:    "no probe yet",
+			maxAge:  time.Minute,
+			wantErr: true,
+		},
+		{
+			name:   "successful probe",
+			probe:  true,
+			maxAge: time.Minute,
+		},
+		{
+			name:     "failed probe",
+			probe:    true,
+			probeErr: errors.New("connection refused"),
+			maxAge:   time.Minute,
+			wantErr:  true,
+		},
+		{
+			name:    "stale probe",
+			probe:   true,
+			maxAge:  -time.Second,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewProber(func(ctx context.Context) error { return tt.probeErr }, time.Minute, time.Second)
+			if tt.probe {
+				p.Probe()
+			}
+			if err := p.Check(tt.maxAge); (err != nil) != tt.wantErr {
+				t.Errorf("Prober.Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package hermes
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ClientOptions Hermes HTTP client options; zero value is Go default HTTP client without timeouts
+type ClientOptions struct {
+	// ConnectTimeout TCP connect and TLS handshake timeout (0 - no timeout)
+	ConnectTimeout time.Duration
+	// RequestTimeout whole request timeout, including response body read (0 - no timeout)
+	RequestTimeout time.Duration
+	// CAFile PEM CA bundle to verify Hermes server certificate, in addition to system CAs
+	CAFile string
+	// CertFile PEM client certificate for mTLS; requires KeyFile
+	CertFile string
+	// KeyFile PEM client certificate key for mTLS; requires CertFile
+	KeyFile string
+	// Proxy proxy URL; empty - use HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables
+	Proxy string
+	// MaxIdleConns max number of idle connections (0 - unlimited)
+	MaxIdleConns int
+	// MaxIdleConnsPerHost max number of idle connections to Hermes (0 - Go default, 2)
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost max number of connections to Hermes (0 - unlimited)
+	MaxConnsPerHost int
+	// IdleConnTimeout how long idle connection is kept open (0 - forever)
+	IdleConnTimeout time.Duration
+}
+
+// TLS true, if client certificate or custom CA is configured
+func (o ClientOptions) TLS() bool {
+	return o.CAFile != "" || o.CertFile != ""
+}
+
+// NewHTTPClient create HTTP client for Hermes API from options
+func NewHTTPClient(opts ClientOptions) (*http.Client, error) {
+	tlsConfig, err := newTLSConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	proxy := http.ProxyFromEnvironment
+	if opts.Proxy != "" {
+		u, err := url.Parse(opts.Proxy)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid Hermes proxy URL '%s'", opts.Proxy)
+		}
+		proxy = http.ProxyURL(u)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+	transport.DialContext = (&net.Dialer{
+		Timeout:   opts.ConnectTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.TLSHandshakeTimeout = opts.ConnectTimeout
+	transport.TLSClientConfig = tlsConfig
+	transport.MaxIdleConns = opts.MaxIdleConns
+	transport.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	transport.MaxConnsPerHost = opts.MaxConnsPerHost
+	transport.IdleConnTimeout = opts.IdleConnTimeout
+	return &http.Client{Transport: transport, Timeout: opts.RequestTimeout}, nil
+}
+
+// newTLSConfig TLS config with custom CA bundle and client certificate
+func newTLSConfig(opts ClientOptions) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if opts.CAFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		pem, err := ioutil.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Hermes CA file: %v", err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in Hermes CA file '%s'", opts.CAFile)
+		}
+		config.RootCAs = pool
+	}
+	if (opts.CertFile == "") != (opts.KeyFile == "") {
+		return nil, errors.New("both Hermes client certificate and key files are required")
+	}
+	if opts.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load Hermes client certificate: %v", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// NewHermesEndpointWithOptions create new Hermes API endpoint from url, API token provider and HTTP client options
+func NewHermesEndpointWithOptions(url string, creds Credentials, opts ClientOptions) (Service, error) {
+	client, err := NewHTTPClient(opts)
+	if err != nil {
+		return nil, err
+	}
+	log.WithFields(log.Fields{
+		"connect timeout": opts.ConnectTimeout,
+		"request timeout": opts.RequestTimeout,
+		"mtls":            opts.CertFile != "",
+	}).Debug("Hermes HTTP client options")
+	api := NewHermesEndpointWithCredentials(url, creds).(*APIEndpoint)
+	api.endpoint = api.endpoint.Client(client)
+	return api, nil
+}
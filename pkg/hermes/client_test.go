@@ -0,0 +1,177 @@
+package hermes
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeClientCert generate self-signed client certificate; returns certificate, cert file and key file
+func writeClientCert(t *testing.T, dir string) (*x509.Certificate, string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "cronus"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDer)
+	return cert, certFile, keyFile
+}
+
+func writePEM(t *testing.T, file, blockType string, der []byte) {
+	if err := ioutil.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func pingHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/slow/ping" {
+		time.Sleep(500 * time.Millisecond)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestNewHermesEndpointWithOptions_TLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cronus-hermes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// TLS server requiring client certificate
+	clientCert, certFile, keyFile := writeClientCert(t, dir)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(pingHandler))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+	caFile := filepath.Join(dir, "ca.pem")
+	writePEM(t, caFile, "CERTIFICATE", server.Certificate().Raw)
+	badCAFile := filepath.Join(dir, "bad.pem")
+	if err = ioutil.WriteFile(badCAFile, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		opts        ClientOptions
+		wantErr     string
+		wantPingErr bool
+	}{
+		{
+			name: "custom CA and client certificate",
+			opts: ClientOptions{CAFile: caFile, CertFile: certFile, KeyFile: keyFile},
+		},
+		{
+			name:        "unknown server certificate authority",
+			opts:        ClientOptions{CertFile: certFile, KeyFile: keyFile},
+			wantPingErr: true,
+		},
+		{
+			name:        "missing client certificate",
+			opts:        ClientOptions{CAFile: caFile},
+			wantPingErr: true,
+		},
+		{
+			name:        "request timeout",
+			path:        "/slow/",
+			opts:        ClientOptions{CAFile: caFile, CertFile: certFile, KeyFile: keyFile, RequestTimeout: 50 * time.Millisecond},
+			wantPingErr: true,
+		},
+		{
+			name:    "certificate without key",
+			opts:    ClientOptions{CertFile: certFile},
+			wantErr: "both Hermes client certificate and key files are required",
+		},
+		{
+			name:    "invalid CA file",
+			opts:    ClientOptions{CAFile: badCAFile},
+			wantErr: "no certificates found in Hermes CA file '" + badCAFile + "'",
+		},
+		{
+			name:    "invalid proxy",
+			opts:    ClientOptions{Proxy: "proxy:3128"},
+			wantErr: "invalid Hermes proxy URL 'proxy:3128'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := tt.path
+			if path == "" {
+				path = "/"
+			}
+			api, err := NewHermesEndpointWithOptions(server.URL+path, NewStaticCredentials("token"), tt.opts)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			err = api.Ping(context.Background())
+			if tt.wantPingErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNewHermesEndpointWithOptions_Proxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+
+	api, err := NewHermesEndpointWithOptions("http://hermes.invalid/", NewStaticCredentials("token"), ClientOptions{
+		Proxy:          proxy.URL,
+		ConnectTimeout: time.Second,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, api.Ping(context.Background()))
+	assert.Equal(t, "http://hermes.invalid/ping", proxied)
+}
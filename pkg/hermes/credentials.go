@@ -0,0 +1,162 @@
+package hermes
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// Credentials Hermes API token provider
+	Credentials interface {
+		// Token current API token
+		Token() (string, error)
+		// Refresh reload token from its source; returns true, if token changed
+		Refresh() (bool, error)
+		// Source token source description, like "file:/etc/hermes/token"
+		Source() string
+	}
+
+	// StaticCredentials fixed API token
+	StaticCredentials struct {
+		token string
+	}
+
+	// FileCredentials API token read from file; file is re-read when it changes
+	FileCredentials struct {
+		file    string
+		mu      sync.Mutex
+		token   string
+		modTime time.Time
+		size    int64
+	}
+
+	// EnvCredentials API token read from environment variable on every call
+	EnvCredentials struct {
+		name string
+		mu   sync.Mutex
+		last string
+	}
+)
+
+// NewStaticCredentials create fixed API token provider
+func NewStaticCredentials(token string) *StaticCredentials {
+	return &StaticCredentials{token: token}
+}
+
+// Token API token
+func (c *StaticCredentials) Token() (string, error) {
+	return c.token, nil
+}
+
+// Refresh static token never changes
+func (c *StaticCredentials) Refresh() (bool, error) {
+	return false, nil
+}
+
+// Source token source description
+func (c *StaticCredentials) Source() string {
+	return "static"
+}
+
+// NewFileCredentials create API token provider reading token from file; file should exist and be not empty
+func NewFileCredentials(file string) (*FileCredentials, error) {
+	c := &FileCredentials{file: file}
+	if _, err := c.Refresh(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Token API token; file is re-read, if its modification time or size changed
+func (c *FileCredentials) Token() (string, error) {
+	info, err := os.Stat(c.file)
+	c.mu.Lock()
+	changed := err == nil && (!info.ModTime().Equal(c.modTime) || info.Size() != c.size)
+	token := c.token
+	c.mu.Unlock()
+	if changed {
+		if _, err = c.Refresh(); err != nil {
+			// keep using last good token
+			return token, nil
+		}
+		c.mu.Lock()
+		token = c.token
+		c.mu.Unlock()
+	}
+	return token, nil
+}
+
+// Refresh re-read token file; empty file is rejected and the last token is kept
+func (c *FileCredentials) Refresh() (bool, error) {
+	info, err := os.Stat(c.file)
+	if err != nil {
+		return false, fmt.Errorf("failed to read Hermes token file: %v", err)
+	}
+	data, err := ioutil.ReadFile(c.file)
+	if err != nil {
+		return false, fmt.Errorf("failed to read Hermes token file: %v", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return false, fmt.Errorf("hermes token file '%s' is empty", c.file)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	changed := token != c.token
+	c.token, c.modTime, c.size = token, info.ModTime(), info.Size()
+	return changed, nil
+}
+
+// Source token source description
+func (c *FileCredentials) Source() string {
+	return "file:" + c.file
+}
+
+// NewEnvCredentials create API token provider reading token from environment variable
+func NewEnvCredentials(name string) *EnvCredentials {
+	return &EnvCredentials{name: name}
+}
+
+// Token API token
+func (c *EnvCredentials) Token() (string, error) {
+	token := os.Getenv(c.name)
+	if token == "" {
+		return "", fmt.Errorf("hermes token environment variable '%s' is not set", c.name)
+	}
+	c.mu.Lock()
+	c.last = token
+	c.mu.Unlock()
+	return token, nil
+}
+
+// Refresh re-read environment variable; returns true, if token changed since last call
+func (c *EnvCredentials) Refresh() (bool, error) {
+	c.mu.Lock()
+	last := c.last
+	c.mu.Unlock()
+	token, err := c.Token()
+	if err != nil {
+		return false, err
+	}
+	return token != last, nil
+}
+
+// Source token source description
+func (c *EnvCredentials) Source() string {
+	return "env:" + c.name
+}
+
+// Fingerprint short token fingerprint, safe to log and expose: first 8 hex digits of token SHA-256
+func Fingerprint(token string) string {
+	if token == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(token))
+	return "sha256:" + hex.EncodeToString(sum[:4])
+}
@@ -0,0 +1,143 @@
+package hermes
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeToken(t *testing.T, file, token string) {
+	if err := ioutil.WriteFile(file, []byte(token), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cronus-hermes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "token")
+
+	_, err = NewFileCredentials(file)
+	assert.Error(t, err, "missing file")
+	writeToken(t, file, "\n")
+	_, err = NewFileCredentials(file)
+	assert.EqualError(t, err, "hermes token file '"+file+"' is empty")
+
+	writeToken(t, file, "token-1\n")
+	creds, err := NewFileCredentials(file)
+	if !assert.NoError(t, err) {
+		return
+	}
+	token, err := creds.Token()
+	assert.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+	assert.Equal(t, "file:"+file, creds.Source())
+
+	// file is re-read when changed
+	writeToken(t, file, "token-two")
+	token, _ = creds.Token()
+	assert.Equal(t, "token-two", token)
+	// empty file keeps last token
+	writeToken(t, file, "")
+	token, _ = creds.Token()
+	assert.Equal(t, "token-two", token)
+	changed, err := creds.Refresh()
+	assert.Error(t, err)
+	assert.False(t, changed)
+}
+
+func TestEnvCredentials(t *testing.T) {
+	const name = "CRONUS_TEST_HERMES_TOKEN"
+	defer os.Unsetenv(name)
+	creds := NewEnvCredentials(name)
+	_, err := creds.Token()
+	assert.EqualError(t, err, "hermes token environment variable '"+name+"' is not set")
+
+	os.Setenv(name, "token-1")
+	token, err := creds.Token()
+	assert.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+	changed, _ := creds.Refresh()
+	assert.False(t, changed)
+	os.Setenv(name, "token-2")
+	changed, _ = creds.Refresh()
+	assert.True(t, changed)
+}
+
+func TestFingerprint(t *testing.T) {
+	assert.Equal(t, "", Fingerprint(""))
+	assert.Equal(t, "sha256:2c26b46b", Fingerprint("foo"))
+}
+
+func TestAPIEndpoint_RetryUnauthorized(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cronus-hermes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "token")
+	writeToken(t, file, "old")
+
+	var tokens []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get("Authorization")
+		tokens = append(tokens, token)
+		if token != "new" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	creds, err := NewFileCredentials(file)
+	if !assert.NoError(t, err) {
+		return
+	}
+	api := NewHermesEndpointWithCredentials(server.URL+"/", creds).(*APIEndpoint)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// token is not changed: no retry
+	assert.Error(t, api.TriggerEvent(ctx, "cron:codefresh:0 0 * * * *:test", NewNormalizedEvent()))
+	assert.Equal(t, []string{"old"}, tokens)
+	assert.Equal(t, Fingerprint("old"), api.TokenFingerprint())
+
+	// token rotated: file is re-read on 401 and the request is retried once
+	tokens = nil
+	writeToken(t, file, "new")
+	api.mu.Lock()
+	api.creds = &staleCredentials{FileCredentials: creds, token: "old"}
+	api.mu.Unlock()
+	assert.NoError(t, api.TriggerEvent(ctx, "cron:codefresh:0 0 * * * *:test", NewNormalizedEvent()))
+	assert.Equal(t, []string{"old", "new"}, tokens)
+	assert.Equal(t, Fingerprint("new"), api.TokenFingerprint())
+}
+
+// staleCredentials returns stale token until refreshed, like file credentials that missed a file change
+type staleCredentials struct {
+	*FileCredentials
+	token string
+}
+
+func (c *staleCredentials) Token() (string, error) {
+	if c.token != "" {
+		return c.token, nil
+	}
+	return c.FileCredentials.Token()
+}
+
+func (c *staleCredentials) Refresh() (bool, error) {
+	c.token = ""
+	return c.FileCredentials.Refresh()
+}
@@ -1,10 +1,13 @@
 package hermes
 
 import (
+	"context"
+	"expvar"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"sync"
 
 	"github.com/dghubble/sling"
 	log "github.com/sirupsen/logrus"
@@ -13,12 +16,16 @@ import (
 type (
 	// Service Codefresh Service
 	Service interface {
-		TriggerEvent(eventURI string, event *NormalizedEvent) error
+		TriggerEvent(ctx context.Context, eventURI string, event *NormalizedEvent) error
+		Ping(ctx context.Context) error
 	}
 
 	// APIEndpoint Hermes API endpoint
 	APIEndpoint struct {
 		endpoint *sling.Sling
+		mu       sync.RWMutex
+		creds    Credentials
+		token    string
 	}
 
 	// NormalizedEvent normalized event: {event-uri, original-payload, secret, variables-map}
@@ -29,6 +36,22 @@ type (
 	}
 )
 
+// Hermes client metrics, exposed through expvar
+var (
+	metrics                = expvar.NewMap("hermes")
+	metricTokenSource      = new(expvar.String)
+	metricTokenFingerprint = new(expvar.String)
+	metricTokenRefresh     = new(expvar.Int)
+	metricUnauthorized     = new(expvar.Int)
+)
+
+func init() {
+	metrics.Set("token_source", metricTokenSource)
+	metrics.Set("token_fingerprint", metricTokenFingerprint)
+	metrics.Set("token_refresh_total", metricTokenRefresh)
+	metrics.Set("unauthorized_total", metricUnauthorized)
+}
+
 // NewNormalizedEvent init NormalizedEvent struct
 func NewNormalizedEvent() *NormalizedEvent {
 	var event NormalizedEvent
@@ -38,13 +61,93 @@ func NewNormalizedEvent() *NormalizedEvent {
 
 // NewHermesEndpoint create new Hermes API endpoint from url and API token
 func NewHermesEndpoint(url, token string) Service {
-	log.WithField("hermes url", url).Debug("binding to Hermes service")
-	endpoint := sling.New().Base(url).Set("Authorization", token)
-	return &APIEndpoint{endpoint}
+	return NewHermesEndpointWithCredentials(url, NewStaticCredentials(token))
+}
+
+// NewHermesEndpointWithCredentials create new Hermes API endpoint from url and API token provider
+func NewHermesEndpointWithCredentials(url string, creds Credentials) Service {
+	log.WithField("hermes url", url).WithField("token source", creds.Source()).Debug("binding to Hermes service")
+	metricTokenSource.Set(creds.Source())
+	return &APIEndpoint{endpoint: sling.New().Base(url), creds: creds}
+}
+
+// SetToken replace Hermes API token provider with fixed token; used by following requests
+func (api *APIEndpoint) SetToken(token string) {
+	api.SetCredentials(NewStaticCredentials(token))
+}
+
+// SetCredentials replace Hermes API token provider; used by following requests
+func (api *APIEndpoint) SetCredentials(creds Credentials) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.creds = creds
+	metricTokenSource.Set(creds.Source())
+}
+
+// TokenFingerprint fingerprint of the last used API token
+func (api *APIEndpoint) TokenFingerprint() string {
+	api.mu.RLock()
+	defer api.mu.RUnlock()
+	return Fingerprint(api.token)
+}
+
+// credentials current API token provider
+func (api *APIEndpoint) credentials() Credentials {
+	api.mu.RLock()
+	defer api.mu.RUnlock()
+	return api.creds
 }
 
-// TriggerEvent send normalized event to Hermes trigger-manager server
-func (api *APIEndpoint) TriggerEvent(eventURI string, event *NormalizedEvent) error {
+// request new request builder with current API token
+func (api *APIEndpoint) request() (*sling.Sling, error) {
+	token, err := api.credentials().Token()
+	if err != nil {
+		return nil, err
+	}
+	api.mu.Lock()
+	if token != api.token {
+		api.token = token
+		fingerprint := Fingerprint(token)
+		metricTokenFingerprint.Set(fingerprint)
+		log.WithField("fingerprint", fingerprint).Info("using new Hermes API token")
+	}
+	api.mu.Unlock()
+	return api.endpoint.New().Set("Authorization", token), nil
+}
+
+// do send Hermes API request; on 401 Unauthorized the token is refreshed and the request is retried once,
+// if the token changed
+func (api *APIEndpoint) do(ctx context.Context, build func(sl *sling.Sling) *sling.Sling, successV, failureV interface{}) (*http.Response, error) {
+	for retry := false; ; retry = true {
+		sl, err := api.request()
+		if err != nil {
+			return nil, err
+		}
+		sl = build(sl)
+		req, err := sl.Request()
+		if err != nil {
+			return nil, err
+		}
+		resp, err := sl.Do(req.WithContext(ctx), successV, failureV)
+		if resp == nil || resp.StatusCode != http.StatusUnauthorized || retry {
+			return resp, err
+		}
+		metricUnauthorized.Add(1)
+		changed, rerr := api.credentials().Refresh()
+		if rerr != nil {
+			log.WithError(rerr).Error("failed to refresh Hermes API token")
+			return resp, err
+		}
+		if !changed {
+			return resp, err
+		}
+		metricTokenRefresh.Add(1)
+		log.Info("Hermes API token refreshed after 401 response, retrying request")
+	}
+}
+
+// TriggerEvent send normalized event to Hermes trigger-manager server; canceling context aborts the call
+func (api *APIEndpoint) TriggerEvent(ctx context.Context, eventURI string, event *NormalizedEvent) error {
 	log.WithField("event-uri", eventURI).Debug("Triggering event")
 	// runs response
 	type PipelineRun struct {
@@ -64,11 +167,12 @@ func (api *APIEndpoint) TriggerEvent(eventURI string, event *NormalizedEvent) er
 
 	// invoke hermes trigger
 	log.WithFields(log.Fields{
-		"secret":   event.Secret,
 		"vars":     event.Variables,
 		"original": event.Original,
 	}).Debug("sending normalized event payload")
-	resp, err := api.endpoint.New().Post(fmt.Sprint("run/", url.PathEscape(eventURI))).BodyJSON(event).Receive(&runs, &hermesErr)
+	resp, err := api.do(ctx, func(sl *sling.Sling) *sling.Sling {
+		return sl.Post(fmt.Sprint("run/", url.PathEscape(eventURI))).BodyJSON(event)
+	}, &runs, &hermesErr)
 	// ignore EOF JSON parsing error
 	if err != nil && err != io.EOF {
 		log.WithError(err).WithField("api", "POST /run/").Error("failed to invoke Hermes REST API")
@@ -87,3 +191,17 @@ func (api *APIEndpoint) TriggerEvent(eventURI string, event *NormalizedEvent) er
 	}
 	return nil
 }
+
+// Ping lightweight Hermes availability probe
+func (api *APIEndpoint) Ping(ctx context.Context) error {
+	resp, err := api.do(ctx, func(sl *sling.Sling) *sling.Sling {
+		return sl.Get("ping")
+	}, nil, nil)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: hermes ping failed", resp.Status)
+	}
+	return nil
+}
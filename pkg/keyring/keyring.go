@@ -0,0 +1,231 @@
+package keyring
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+type (
+	// Keyring set of key encryption keys (KEK), by key ID; primary key encrypts new values,
+	// all keys can decrypt
+	Keyring struct {
+		primary string
+		keys    map[string][]byte
+	}
+)
+
+// encrypted value prefixes: {prefix}{key-id}:{wrapped data key}:{ciphertext}; v2 ciphertext is bound to
+// associated data (event URI), v1 (no associated data) is only decrypted
+const (
+	reservedPrefix = "enc:"
+	prefixV1       = "enc:v1:"
+	prefix         = "enc:v2:"
+)
+
+// KeySize key encryption key size (AES-256)
+const KeySize = 32
+
+// ErrUnknownKey value encrypted with key, missing from keyring
+var ErrUnknownKey = errors.New("unknown encryption key")
+
+// ErrReservedPrefix plain value looks like encrypted value
+var ErrReservedPrefix = fmt.Errorf("value should not start with '%s'", reservedPrefix)
+
+// New create keyring from key list; each key is 'key-id:base64-key', first key is primary
+func New(keys []string) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string][]byte)}
+	for _, key := range keys {
+		key = strings.TrimSpace(key)
+		if key == "" || strings.HasPrefix(key, "#") {
+			continue
+		}
+		parts := strings.SplitN(key, ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, errors.New("bad encryption key: should be 'key-id:base64-key'")
+		}
+		kid := parts[0]
+		if _, ok := k.keys[kid]; ok {
+			return nil, fmt.Errorf("duplicate encryption key '%s'", kid)
+		}
+		v, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("bad encryption key '%s': %v", kid, err)
+		}
+		if len(v) != KeySize {
+			return nil, fmt.Errorf("bad encryption key '%s': should be %d bytes", kid, KeySize)
+		}
+		k.keys[kid] = v
+		if k.primary == "" {
+			k.primary = kid
+		}
+	}
+	if k.primary == "" {
+		return nil, errors.New("no encryption keys")
+	}
+	return k, nil
+}
+
+// Load load keyring from key file (one key per line) and/or comma separated key list;
+// returns nil keyring if both are empty
+func Load(file, list string) (*Keyring, error) {
+	var keys []string
+	if list != "" {
+		keys = strings.Split(list, ",")
+	}
+	if file != "" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key file: %v", err)
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			keys = append(keys, scanner.Text())
+		}
+		if err = scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read key file: %v", err)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	return New(keys)
+}
+
+// Generate generate new random key, in 'key-id:base64-key' format
+func Generate(kid string) (string, error) {
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", err
+	}
+	return kid + ":" + base64.StdEncoding.EncodeToString(key), nil
+}
+
+// Primary primary key ID
+func (k *Keyring) Primary() string {
+	return k.primary
+}
+
+// IsEncrypted check if value is encrypted
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix) || strings.HasPrefix(value, prefixV1)
+}
+
+// trimPrefix encrypted value without prefix
+func trimPrefix(value string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(value, prefix), prefixV1)
+}
+
+// KeyID get ID of key used to encrypt value; empty for plain value
+func KeyID(value string) string {
+	if !IsEncrypted(value) {
+		return ""
+	}
+	parts := strings.SplitN(trimPrefix(value), ":", 2)
+	return parts[0]
+}
+
+// NeedsRotation check if value is not empty and is not encrypted with primary key in current format
+func (k *Keyring) NeedsRotation(value string) bool {
+	return value != "" && (!strings.HasPrefix(value, prefix) || KeyID(value) != k.primary)
+}
+
+// Encrypt encrypt value, bound to associated data (like event URI), with new random data key; data key is
+// encrypted (wrapped) with primary key; values with reserved 'enc:' prefix are rejected, since they cannot be
+// told apart from encrypted values
+func (k *Keyring) Encrypt(value, aad string) (string, error) {
+	if strings.HasPrefix(value, reservedPrefix) {
+		return "", ErrReservedPrefix
+	}
+	dek := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, dek); err != nil {
+		return "", err
+	}
+	wrapped, err := seal(k.keys[k.primary], dek, nil)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(dek, []byte(value), []byte(aad))
+	if err != nil {
+		return "", err
+	}
+	return prefix + k.primary + ":" + base64.RawURLEncoding.EncodeToString(wrapped) + ":" +
+		base64.RawURLEncoding.EncodeToString(ciphertext), nil
+}
+
+// Decrypt decrypt value, encrypted with the same associated data; plain (not encrypted) value is returned as is
+func (k *Keyring) Decrypt(value, aad string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	var additional []byte
+	if strings.HasPrefix(value, prefix) {
+		additional = []byte(aad)
+	}
+	parts := strings.Split(trimPrefix(value), ":")
+	if len(parts) != 3 {
+		return "", errors.New("bad encrypted value")
+	}
+	kek, ok := k.keys[parts[0]]
+	if !ok {
+		return "", fmt.Errorf("%v '%s'", ErrUnknownKey, parts[0])
+	}
+	wrapped, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", errors.New("bad encrypted value")
+	}
+	ciphertext, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", errors.New("bad encrypted value")
+	}
+	dek, err := open(kek, wrapped, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt data key: %v", err)
+	}
+	plain, err := open(dek, ciphertext, additional)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %v", err)
+	}
+	return string(plain), nil
+}
+
+// seal AES-GCM encrypt with additional data: random nonce followed by ciphertext
+func seal(key, plain, additional []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plain, additional), nil
+}
+
+// open AES-GCM decrypt sealed value with additional data
+func open(key, sealed, additional []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext is too short")
+	}
+	return gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], additional)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,153 @@
+package keyring
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mustGenerate(t *testing.T, kid string) string {
+	key, err := Generate(kid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name        string
+		keys        []string
+		wantPrimary string
+		wantErr     bool
+	}{
+		{
+			name:        "first key is primary",
+			keys:        []string{mustGenerate(t, "k2"), "# old key", mustGenerate(t, "k1")},
+			wantPrimary: "k2",
+		},
+		{
+			name:    "no keys",
+			keys:    []string{"", "# comment"},
+			wantErr: true,
+		},
+		{
+			name:    "missing key ID",
+			keys:    []string{"c2VjcmV0"},
+			wantErr: true,
+		},
+		{
+			name:    "short key",
+			keys:    []string{"k1:c2VjcmV0"},
+			wantErr: true,
+		},
+		{
+			name:    "duplicate key ID",
+			keys:    []string{mustGenerate(t, "k1"), mustGenerate(t, "k1")},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(tt.keys)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && got.Primary() != tt.wantPrimary {
+				t.Errorf("New() primary = %v, want %v", got.Primary(), tt.wantPrimary)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	f, err := ioutil.TempFile("", "keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString(mustGenerate(t, "file-key") + "\n")
+	f.Close()
+	// list keys first
+	k, err := Load(f.Name(), mustGenerate(t, "env-key"))
+	assert.NoError(t, err)
+	assert.Equal(t, "env-key", k.Primary())
+	assert.Len(t, k.keys, 2)
+	// nothing configured
+	k, err = Load("", "")
+	assert.NoError(t, err)
+	assert.Nil(t, k)
+	// missing file
+	_, err = Load(f.Name()+".missing", "")
+	assert.Error(t, err)
+}
+
+func TestKeyring_EncryptDecrypt(t *testing.T) {
+	const uri = "cron:codefresh:0 0 4 * * *:test-message-1:"
+	old, err := New([]string{mustGenerate(t, "k1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err := New([]string{mustGenerate(t, "k2")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := old.Encrypt("1234", uri)
+	assert.NoError(t, err)
+	assert.True(t, IsEncrypted(encrypted))
+	assert.Equal(t, "k1", KeyID(encrypted))
+	assert.NotContains(t, encrypted, "1234")
+	// random data key and nonce: same value is encrypted differently
+	again, _ := old.Encrypt("1234", uri)
+	assert.NotEqual(t, encrypted, again)
+	// decrypt
+	plain, err := old.Decrypt(encrypted, uri)
+	assert.NoError(t, err)
+	assert.Equal(t, "1234", plain)
+	// plain value
+	plain, err = old.Decrypt("1234", uri)
+	assert.NoError(t, err)
+	assert.Equal(t, "1234", plain)
+	assert.Equal(t, "", KeyID("1234"))
+	// unknown key
+	_, err = current.Decrypt(encrypted, uri)
+	assert.Error(t, err)
+	// tampered value
+	_, err = old.Decrypt(encrypted[:len(encrypted)-2]+"AA", uri)
+	assert.Error(t, err)
+	_, err = old.Decrypt("enc:v2:k1:bad", uri)
+	assert.Error(t, err)
+	// value is bound to associated data: cannot be moved to other event
+	_, err = old.Decrypt(encrypted, "cron:codefresh:0 0 5 * * *:other:")
+	assert.Error(t, err)
+	// plain value looking like encrypted value is rejected
+	_, err = old.Encrypt("enc:v1:k1:a:b", uri)
+	assert.Equal(t, ErrReservedPrefix, err)
+}
+
+func TestKeyring_DecryptV1(t *testing.T) {
+	k, err := New([]string{mustGenerate(t, "k1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// value encrypted before associated data binding
+	dek := make([]byte, KeySize)
+	wrapped, _ := seal(k.keys["k1"], dek, nil)
+	ciphertext, _ := seal(dek, []byte("1234"), nil)
+	v1 := prefixV1 + "k1:" + base64.RawURLEncoding.EncodeToString(wrapped) + ":" + base64.RawURLEncoding.EncodeToString(ciphertext)
+	assert.True(t, IsEncrypted(v1))
+	assert.Equal(t, "k1", KeyID(v1))
+	plain, err := k.Decrypt(v1, "any")
+	assert.NoError(t, err)
+	assert.Equal(t, "1234", plain)
+	// v1 values are re-encrypted on rotation
+	assert.True(t, k.NeedsRotation(v1))
+	v2, _ := k.Encrypt("1234", "any")
+	assert.False(t, k.NeedsRotation(v2))
+	assert.True(t, k.NeedsRotation("1234"))
+	assert.False(t, k.NeedsRotation(""))
+}
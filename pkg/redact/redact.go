@@ -0,0 +1,102 @@
+package redact
+
+import (
+	"strings"
+
+	"github.com/codefresh-io/cronus/pkg/types"
+	log "github.com/sirupsen/logrus"
+)
+
+type (
+	// Hook logrus hook that masks values of known secret fields in every log entry
+	Hook struct {
+		fields map[string]bool
+	}
+)
+
+// Mask replacement for secret values
+const Mask = "********"
+
+// Fields default secret log field names (case insensitive)
+var Fields = []string{"secret", "token", "authorization", "password", "key", "creds"}
+
+// Secret mask non-empty secret
+func Secret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return Mask
+}
+
+// Event copy of event with masked secret
+func Event(e types.Event) types.Event {
+	e.Secret = Secret(e.Secret)
+	return e
+}
+
+// Path mask URL path segments at positions of secret route parameters: ':name' parameter masks single
+// segment, '*name' catch-all parameter masks all remaining segments; route is gin route, like
+// '/event/:uri/:secret/*creds'
+func Path(path, route string, params ...string) string {
+	secret := make(map[string]bool)
+	for _, p := range params {
+		secret[p] = true
+	}
+	segments := strings.Split(path, "/")
+	for i, r := range strings.Split(route, "/") {
+		if i >= len(segments) || r == "" || !secret[r[1:]] {
+			continue
+		}
+		switch r[0] {
+		case ':':
+			segments[i] = Mask
+		case '*':
+			for j := i; j < len(segments); j++ {
+				if segments[j] != "" {
+					segments[j] = Mask
+				}
+			}
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// NewHook create log hook for secret fields (default fields, if not set)
+func NewHook(fields ...string) *Hook {
+	if len(fields) == 0 {
+		fields = Fields
+	}
+	h := &Hook{fields: make(map[string]bool)}
+	for _, f := range fields {
+		h.fields[strings.ToLower(f)] = true
+	}
+	return h
+}
+
+// Levels all log levels
+func (h *Hook) Levels() []log.Level {
+	return []log.Level{log.PanicLevel, log.FatalLevel, log.ErrorLevel, log.WarnLevel, log.InfoLevel, log.DebugLevel}
+}
+
+// Fire mask secret fields and secrets of logged events
+func (h *Hook) Fire(entry *log.Entry) error {
+	for k, v := range entry.Data {
+		if h.fields[strings.ToLower(k)] {
+			if s, ok := v.(string); ok && s == "" {
+				continue
+			}
+			entry.Data[k] = Mask
+			continue
+		}
+		switch e := v.(type) {
+		case types.Event:
+			entry.Data[k] = Event(e)
+		case *types.Event:
+			if e != nil {
+				masked := Event(*e)
+				entry.Data[k] = &masked
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package redact
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/codefresh-io/cronus/pkg/types"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHook_Fire(t *testing.T) {
+	event := types.Event{Expression: "5 4 * * *", Message: "test-message", Secret: "1234"}
+	tests := []struct {
+		name   string
+		fields log.Fields
+		want   log.Fields
+	}{
+		{
+			name:   "secret fields",
+			fields: log.Fields{"secret": "1234", "Authorization": "Bearer abcd", "uri": "cron:codefresh"},
+			want:   log.Fields{"secret": Mask, "Authorization": Mask, "uri": "cron:codefresh"},
+		},
+		{
+			name:   "empty secret",
+			fields: log.Fields{"token": ""},
+			want:   log.Fields{"token": ""},
+		},
+		{
+			name:   "event secret",
+			fields: log.Fields{"event": event},
+			want:   log.Fields{"event": Event(event)},
+		},
+		{
+			name:   "event pointer secret",
+			fields: log.Fields{"event": &event},
+			want:   log.Fields{"event": func() *types.Event { e := Event(event); return &e }()},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := log.NewEntry(log.New()).WithFields(tt.fields)
+			assert.NoError(t, NewHook().Fire(entry))
+			assert.Equal(t, tt.want, entry.Data)
+		})
+	}
+	// logged event is not changed
+	assert.Equal(t, "1234", event.Secret)
+}
+
+func TestHook_Logger(t *testing.T) {
+	var out bytes.Buffer
+	logger := log.New()
+	logger.Out = &out
+	logger.Hooks.Add(NewHook())
+	logger.WithField("secret", "supersecret").Error("test")
+	assert.NotContains(t, out.String(), "supersecret")
+	assert.Contains(t, out.String(), Mask)
+}
+
+func TestPath(t *testing.T) {
+	tests := []struct {
+		name  string
+		path  string
+		route string
+		want  string
+	}{
+		{
+			name:  "secret and credentials",
+			path:  "/event/cron%3Acodefresh%3As/s/user/pass",
+			route: "/event/:uri/:secret/*creds",
+			want:  "/event/cron%3Acodefresh%3As/" + Mask + "/" + Mask + "/" + Mask,
+		},
+		{
+			name:  "secret equal to route segment",
+			path:  "/cronus/event/cron%3Acodefresh%3Aevent/event",
+			route: "/cronus/event/:uri/:secret",
+			want:  "/cronus/event/cron%3Acodefresh%3Aevent/" + Mask,
+		},
+		{
+			name:  "empty credentials",
+			path:  "/event/cron%3Acodefresh%3As/",
+			route: "/event/:uri/*creds",
+			want:  "/event/cron%3Acodefresh%3As/",
+		},
+		{
+			name:  "no secret parameters",
+			path:  "/pause/cron%3Acodefresh%3Asecret",
+			route: "/pause/:uri",
+			want:  "/pause/cron%3Acodefresh%3Asecret",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Path(tt.path, tt.route, "secret", "creds"))
+		})
+	}
+}
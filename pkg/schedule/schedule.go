@@ -0,0 +1,169 @@
+package schedule
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// field cron expression field bounds
+	field struct {
+		name     string
+		min, max int
+	}
+)
+
+// hashed field bounds, in 6-field (seconds first) order;
+// day of month is limited to 1-28 to fire in every month
+var fields = []field{
+	{"second", 0, 59},
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"dom", 1, 28},
+	{"month", 1, 12},
+	{"dow", 0, 6},
+}
+
+// HashToken Jenkins-style hash token
+const HashToken = "H"
+
+var (
+	rnd   = rand.New(rand.NewSource(time.Now().UnixNano()))
+	rndMu sync.Mutex
+)
+
+// IsHashed check if cron expression contains H token
+func IsHashed(expression string) bool {
+	for _, f := range strings.Fields(expression) {
+		if strings.HasPrefix(f, HashToken) {
+			return true
+		}
+	}
+	return false
+}
+
+// Expand replace H tokens in cron expression with values derived from seed hash (event URI)
+// Supported forms: H, H(min-max), H/step and H(min-max)/step
+// The same seed always produces the same expression, so fire times are stable across restarts
+func Expand(expression, seed string) (string, error) {
+	if !IsHashed(expression) {
+		return expression, nil
+	}
+	// keep TZ= prefix as is
+	var prefix string
+	if strings.HasPrefix(expression, "TZ=") {
+		i := strings.Index(expression, " ")
+		if i < 0 {
+			return "", errors.New("missing cron expression after time zone")
+		}
+		prefix, expression = expression[:i+1], strings.TrimSpace(expression[i:])
+	}
+	if strings.HasPrefix(expression, "@") {
+		return "", errors.New("H token cannot be used with predefined schedules")
+	}
+	tokens := strings.Fields(expression)
+	// 5-field expression has no seconds field
+	offset := 0
+	switch len(tokens) {
+	case 5:
+		offset = 1
+	case 6:
+	default:
+		return "", fmt.Errorf("expected 5 or 6 fields, found %d", len(tokens))
+	}
+	for i, t := range tokens {
+		if !strings.HasPrefix(t, HashToken) {
+			continue
+		}
+		f := fields[i+offset]
+		v, err := expandField(t, f, hash(seed, f.name))
+		if err != nil {
+			return "", fmt.Errorf("%s field: %v", f.name, err)
+		}
+		tokens[i] = v
+	}
+	return prefix + strings.Join(tokens, " "), nil
+}
+
+// expandField expand single H field token
+func expandField(token string, f field, h uint32) (string, error) {
+	min, max := f.min, f.max
+	rest := token[len(HashToken):]
+	// optional range: H(min-max)
+	if strings.HasPrefix(rest, "(") {
+		end := strings.Index(rest, ")")
+		if end < 0 {
+			return "", fmt.Errorf("unclosed range in '%s'", token)
+		}
+		bounds := strings.Split(rest[1:end], "-")
+		if len(bounds) != 2 {
+			return "", fmt.Errorf("bad range in '%s'", token)
+		}
+		var err error
+		if min, err = strconv.Atoi(bounds[0]); err != nil {
+			return "", fmt.Errorf("bad range in '%s'", token)
+		}
+		if max, err = strconv.Atoi(bounds[1]); err != nil {
+			return "", fmt.Errorf("bad range in '%s'", token)
+		}
+		if min < f.min || max > f.max || min > max {
+			return "", fmt.Errorf("range in '%s' is out of bounds %d-%d", token, f.min, f.max)
+		}
+		rest = rest[end+1:]
+	}
+	// no step: single hashed value
+	if rest == "" {
+		return strconv.Itoa(min + int(h%uint32(max-min+1))), nil
+	}
+	// optional step: H/step
+	if !strings.HasPrefix(rest, "/") {
+		return "", fmt.Errorf("unexpected '%s' in '%s'", rest, token)
+	}
+	step, err := strconv.Atoi(rest[1:])
+	if err != nil || step <= 0 {
+		return "", fmt.Errorf("bad step in '%s'", token)
+	}
+	if step > max-min+1 {
+		step = max - min + 1
+	}
+	start := min + int(h%uint32(step))
+	return fmt.Sprintf("%d-%d/%d", start, max, step), nil
+}
+
+// hash stable (SHA-256 based) hash of seed and field name
+func hash(seed, name string) uint32 {
+	sum := sha256.Sum256([]byte(seed + "\x00" + name))
+	return binary.BigEndian.Uint32(sum[:4])
+}
+
+// ParseJitter parse jitter window duration; empty string means no jitter
+func ParseJitter(jitter string) (time.Duration, error) {
+	if jitter == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(jitter)
+	if err != nil {
+		return 0, fmt.Errorf("bad jitter window: %v", err)
+	}
+	if d < 0 {
+		return 0, errors.New("jitter window cannot be negative")
+	}
+	return d, nil
+}
+
+// Jitter random delay within [0, window) jitter window
+func Jitter(window time.Duration) time.Duration {
+	if window <= 0 {
+		return 0
+	}
+	rndMu.Lock()
+	defer rndMu.Unlock()
+	return time.Duration(rnd.Int63n(int64(window)))
+}
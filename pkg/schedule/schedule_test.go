@@ -0,0 +1,167 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	cron "gopkg.in/robfig/cron.v2"
+)
+
+func TestExpand(t *testing.T) {
+	const seed = "cron:codefresh:H H 0 * * *:nightly:cb1e73c5215b"
+	tests := []struct {
+		name       string
+		expression string
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:       "no H tokens",
+			expression: "0 0 0 * * *",
+			want:       "0 0 0 * * *",
+		},
+		{
+			name:       "predefined schedule",
+			expression: "@daily",
+			want:       "@daily",
+		},
+		{
+			// pinned values: changing hash algorithm would move fire times of all stored events
+			name:       "hashed seconds and minutes",
+			expression: "H H 0 * * *",
+			want:       "47 42 0 * * *",
+		},
+		{
+			name:       "hashed range",
+			expression: "0 H(0-29) H(1-5) * * *",
+			want:       "0 12 2 * * *",
+		},
+		{
+			name:       "hashed step",
+			expression: "0 H/15 * * * *",
+			want:       "0 12-59/15 * * * *",
+		},
+		{
+			name:       "5-field expression",
+			expression: "H 2 * * *",
+			want:       "42 2 * * *",
+		},
+		{
+			name:       "keep time zone",
+			expression: "TZ=Asia/Tokyo H H 0 * * *",
+			want:       "TZ=Asia/Tokyo 47 42 0 * * *",
+		},
+		{
+			name:       "H with predefined schedule",
+			expression: "@every H",
+			wantErr:    true,
+		},
+		{
+			name:       "out of bounds range",
+			expression: "0 0 H(0-30) * * *",
+			wantErr:    true,
+		},
+		{
+			name:       "bad step",
+			expression: "0 H/x * * * *",
+			wantErr:    true,
+		},
+		{
+			name:       "wrong number of fields",
+			expression: "H * *",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Expand(tt.expression, seed)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expand() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Expand() = %v, want %v", got, tt.want)
+			}
+			if tt.wantErr {
+				return
+			}
+			if _, err := cron.Parse(got); err != nil {
+				t.Errorf("Expand() = %v, not a valid cron expression: %v", got, err)
+			}
+		})
+	}
+}
+
+func TestExpand_Stable(t *testing.T) {
+	const expression = "H H H * * *"
+	// same seed - same schedule (simulate restart by expanding again)
+	for i := 0; i < 10; i++ {
+		seed := "cron:codefresh:" + expression + ":msg:" + string(rune('a'+i))
+		first, err := Expand(expression, seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := Expand(expression, seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first != second {
+			t.Errorf("Expand() is not stable: %v != %v", first, second)
+		}
+	}
+}
+
+func TestExpand_Spread(t *testing.T) {
+	const expression = "0 H H * * *"
+	spread := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		seed := "cron:codefresh:" + expression + ":msg:" + string(rune('a'+i%26)) + string(rune('a'+i/26))
+		got, err := Expand(expression, seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		spread[got] = true
+	}
+	// 100 events over 1440 slots should rarely collide
+	if len(spread) < 90 {
+		t.Errorf("Expand() does not spread events: %d distinct schedules of 100", len(spread))
+	}
+}
+
+func TestParseJitter(t *testing.T) {
+	tests := []struct {
+		name    string
+		jitter  string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "no jitter", jitter: "", want: 0},
+		{name: "5 minutes", jitter: "5m", want: 5 * time.Minute},
+		{name: "negative", jitter: "-5m", wantErr: true},
+		{name: "bad duration", jitter: "soon", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseJitter(tt.jitter)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseJitter() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseJitter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJitter(t *testing.T) {
+	if got := Jitter(0); got != 0 {
+		t.Errorf("Jitter(0) = %v, want 0", got)
+	}
+	window := time.Minute
+	for i := 0; i < 100; i++ {
+		if got := Jitter(window); got < 0 || got >= window {
+			t.Errorf("Jitter() = %v, out of [0, %v) window", got, window)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/codefresh-io/cronus/pkg/types"
+)
+
+type (
+	// Document portable (storage independent) export document
+	Document struct {
+		// Version document format version
+		Version int `json:"version"`
+		// Time export time
+		Time time.Time `json:"time"`
+		// Account exported account; empty for all accounts
+		Account string `json:"account,omitempty"`
+		// Events exported events
+		Events []types.Event `json:"events"`
+	}
+
+	// header NDJSON document header line
+	header struct {
+		Version int       `json:"version"`
+		Time    time.Time `json:"time"`
+		Account string    `json:"account,omitempty"`
+	}
+
+	// Invalid event that cannot be imported
+	Invalid struct {
+		URI    string `json:"uri"`
+		Reason string `json:"reason"`
+	}
+
+	// Report import result (or diff, for dry run)
+	Report struct {
+		// Mode import mode (merge or replace)
+		Mode string `json:"mode"`
+		// DryRun changes were not applied
+		DryRun bool `json:"dryRun,omitempty"`
+		// Added new events
+		Added []string `json:"added,omitempty"`
+		// Updated existing events with changed secret or options
+		Updated []string `json:"updated,omitempty"`
+		// Removed existing events, missing from document (replace mode only)
+		Removed []string `json:"removed,omitempty"`
+		// Unchanged number of existing events, equal to imported ones
+		Unchanged int `json:"unchanged"`
+		// Invalid events that failed validation and were not imported
+		Invalid []Invalid `json:"invalid,omitempty"`
+	}
+)
+
+// Version current export document format version
+const Version = 1
+
+// export formats
+const (
+	// FormatJSON single JSON document
+	FormatJSON = "json"
+	// FormatNDJSON newline delimited JSON: document header line (without events), followed by one event per line
+	FormatNDJSON = "ndjson"
+)
+
+// import modes
+const (
+	// ModeMerge add new and update changed events; keep other existing events
+	ModeMerge = "merge"
+	// ModeReplace merge and remove existing events (of exported account), missing from document
+	ModeReplace = "replace"
+)
+
+// ContentType HTTP content type of export format
+func ContentType(format string) string {
+	if format == FormatNDJSON {
+		return "application/x-ndjson"
+	}
+	return "application/json"
+}
+
+// ValidFormat check export format
+func ValidFormat(format string) error {
+	if format != FormatJSON && format != FormatNDJSON {
+		return fmt.Errorf("unsupported format '%s': should be json or ndjson", format)
+	}
+	return nil
+}
+
+// ValidMode check import mode
+func ValidMode(mode string) error {
+	if mode != ModeMerge && mode != ModeReplace {
+		return fmt.Errorf("unsupported import mode '%s': should be merge or replace", mode)
+	}
+	return nil
+}
+
+// Portable event without runtime state (status, failures, description); paused status is kept
+func Portable(e types.Event) types.Event {
+	status := ""
+	if e.Status == types.StatusPaused {
+		status = types.StatusPaused
+	}
+	return types.Event{
+		Expression:        e.Expression,
+		Message:           e.Message,
+		Account:           e.Account,
+		Secret:            e.Secret,
+		Status:            status,
+		Jitter:            e.Jitter,
+		ConcurrencyPolicy: e.ConcurrencyPolicy,
+		Until:             e.Until,
+		Dialect:           e.Dialect,
+	}
+}
+
+// Encode write document in export format
+func Encode(w io.Writer, doc *Document, format string) error {
+	if err := ValidFormat(format); err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	if format == FormatJSON {
+		enc.SetIndent("", "  ")
+		return enc.Encode(doc)
+	}
+	if err := enc.Encode(header{Version: doc.Version, Time: doc.Time, Account: doc.Account}); err != nil {
+		return err
+	}
+	for _, e := range doc.Events {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Decode read document in export format and check its version
+func Decode(r io.Reader, format string) (*Document, error) {
+	if err := ValidFormat(format); err != nil {
+		return nil, err
+	}
+	var doc Document
+	if format == FormatJSON {
+		if err := json.NewDecoder(r).Decode(&doc); err != nil {
+			return nil, fmt.Errorf("bad export document: %v", err)
+		}
+	} else {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		line := 0
+		header := false
+		for scanner.Scan() {
+			line++
+			if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+				continue
+			}
+			// first not blank line is document header
+			if !header {
+				if err := json.Unmarshal(scanner.Bytes(), &doc); err != nil {
+					return nil, fmt.Errorf("bad export document header: %v", err)
+				}
+				header = true
+				continue
+			}
+			var e types.Event
+			if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+				return nil, fmt.Errorf("bad event at line %d: %v", line, err)
+			}
+			doc.Events = append(doc.Events, e)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+	if doc.Version == 0 {
+		return nil, errors.New("bad export document: missing version")
+	}
+	if doc.Version > Version {
+		return nil, fmt.Errorf("unsupported export document version %d", doc.Version)
+	}
+	return &doc, nil
+}
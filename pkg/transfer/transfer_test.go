@@ -0,0 +1,84 @@
+package transfer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codefresh-io/cronus/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	doc := &Document{
+		Version: Version,
+		Time:    time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC),
+		Account: "acc1",
+		Events: []types.Event{
+			{Expression: "0 0 4 * * *", Message: "test-message-1", Account: "acc1", Secret: "1234"},
+			{Expression: "0 0 5 * * *", Message: "test-message-2", Account: "acc1", Secret: "1234", Status: types.StatusPaused, Jitter: "5m"},
+		},
+	}
+	for _, format := range []string{FormatJSON, FormatNDJSON} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Encode(&buf, doc, format); err != nil {
+				t.Fatal(err)
+			}
+			if format == FormatNDJSON {
+				assert.Equal(t, 3, strings.Count(buf.String(), "\n"))
+			}
+			got, err := Decode(&buf, format)
+			assert.NoError(t, err)
+			assert.Equal(t, doc, got)
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		data    string
+		want    int
+		wantErr bool
+	}{
+		{name: "json", format: FormatJSON, data: `{"version":1,"events":[{"expression":"0 0 4 * * *","message":"m"}]}`, want: 1},
+		{name: "ndjson", format: FormatNDJSON, data: "{\"version\":1}\n{\"expression\":\"0 0 4 * * *\",\"message\":\"m\"}\n\n", want: 1},
+		{name: "missing version", format: FormatJSON, data: `{"events":[]}`, wantErr: true},
+		{name: "ndjson with leading blank lines", format: FormatNDJSON, data: "\n \n{\"version\":1}\n{\"expression\":\"0 0 4 * * *\",\"message\":\"m\"}\n", want: 1},
+		{name: "newer version", format: FormatNDJSON, data: `{"version":2}`, wantErr: true},
+		{name: "bad event", format: FormatNDJSON, data: "{\"version\":1}\n{bad", wantErr: true},
+		{name: "bad format", format: "yaml", data: `{"version":1}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode(strings.NewReader(tt.data), tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil {
+				assert.Equal(t, tt.want, len(got.Events))
+			}
+		})
+	}
+}
+
+func TestPortable(t *testing.T) {
+	e := types.Event{
+		Expression:   "0 0 4 * * *",
+		Message:      "test-message-1",
+		Secret:       "1234",
+		Description:  "At 04:00 AM",
+		Status:       types.StatusFailing,
+		Help:         "help",
+		FailureCount: 2,
+		LastError:    "error",
+		Until:        "2030-01-01T00:00:00Z",
+	}
+	assert.Equal(t, types.Event{Expression: "0 0 4 * * *", Message: "test-message-1", Secret: "1234", Until: "2030-01-01T00:00:00Z"}, Portable(e))
+	e.Status = types.StatusPaused
+	assert.Equal(t, types.StatusPaused, Portable(e).Status)
+}
@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/codefresh-io/cronus/pkg/cronexp"
+	"github.com/codefresh-io/cronus/pkg/schedule"
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/robfig/cron.v2"
 )
@@ -24,26 +26,199 @@ type (
 		Secret string `json:"secret"`
 		// Description human readable text
 		Description string `json:"description,omitempty"`
-		// Status current event handler status (active, error, not active)
+		// Status current event status (pending, active, paused, failing, expired, completed, invalid)
 		Status string `json:"status,omitempty"`
 		// Help test
 		Help string `json:"help,omitempty"`
+		// Jitter optional random delay window (duration), to spread popular schedules
+		Jitter string `json:"jitter,omitempty"`
+		// ConcurrencyPolicy how to treat overlapping triggers (Allow, Forbid, Replace); default Allow
+		ConcurrencyPolicy string `json:"concurrencyPolicy,omitempty"`
+		// Until optional event end time (RFC3339)
+		Until string `json:"until,omitempty"`
+		// Dialect cron expression dialect (crontab or seconds); default - 6 fields or 5 fields crontab expression
+		Dialect string `json:"dialect,omitempty"`
+		// FailureCount number of consecutive trigger failures
+		FailureCount int `json:"failureCount,omitempty"`
+		// LastError last scheduling or trigger error
+		LastError string `json:"lastError,omitempty"`
+	}
+
+	// AccountEvents events removed with account (or to be removed, in dry run mode)
+	AccountEvents struct {
+		// Account removed account
+		Account string `json:"account"`
+		// DryRun events were not removed
+		DryRun bool `json:"dryRun,omitempty"`
+		// Events account events, sorted by URI
+		Events []Event `json:"events"`
+	}
+
+	// HistoryRecord single cron event fire record
+	HistoryRecord struct {
+		// URI cron event URI
+		URI string `json:"uri"`
+		// Time fire time
+		Time time.Time `json:"time"`
+		// Duration trigger duration
+		Duration time.Duration `json:"duration,omitempty"`
+		// Result fire result (triggered, failed, skipped, canceled)
+		Result string `json:"result"`
+		// Reason skip reason or trigger error
+		Reason string `json:"reason,omitempty"`
 	}
 
 	// EventStore job manager interface to add/remove running jobs
 	EventStore interface {
 		StoreEvent(event Event) error
 		DeleteEvent(uri string) error
+		DeleteAccountEvents(account string) ([]Event, error)
 		GetEvent(uri string) (*Event, error)
 		GetAllEvents() ([]Event, error)
+		GetAccountEvents(account string) ([]Event, error)
+		GetStatusEvents(status string) ([]Event, error)
+		GetDueEvents(now, until time.Time) ([]Event, error)
 		GetDBStats() (int, error)
 		BackupDB(w io.Writer) (int, error)
+		AddHistory(records []HistoryRecord) error
+		GetHistory(uri string, limit int) ([]HistoryRecord, error)
+		Close() error
+	}
+)
+
+// event statuses
+const (
+	// StatusPending event is stored, but not scheduled yet
+	StatusPending = "pending"
+	// StatusActive event is scheduled and last trigger (if any) succeeded
+	StatusActive = "active"
+	// StatusPaused event is paused by user and not scheduled
+	StatusPaused = "paused"
+	// StatusFailing event is scheduled, but last trigger(s) failed
+	StatusFailing = "failing"
+	// StatusExpired event end time has passed
+	StatusExpired = "expired"
+	// StatusCompleted event fired for the last time before its end time
+	StatusCompleted = "completed"
+	// StatusInvalid event cannot be scheduled
+	StatusInvalid = "invalid"
+)
+
+// allowed status transitions
+var transitions = map[string][]string{
+	StatusPending:   {StatusActive, StatusPaused, StatusExpired, StatusInvalid},
+	StatusActive:    {StatusFailing, StatusPaused, StatusExpired, StatusCompleted, StatusInvalid},
+	StatusFailing:   {StatusActive, StatusPaused, StatusExpired, StatusCompleted, StatusInvalid},
+	StatusPaused:    {StatusActive, StatusExpired, StatusInvalid},
+	StatusInvalid:   {StatusActive, StatusPaused, StatusExpired},
+	StatusExpired:   {},
+	StatusCompleted: {},
+}
+
+// EventSpec get cron engine spec for event: expression of event dialect is normalized to 6 fields and
+// H tokens are expanded by event URI hash
+func EventSpec(e Event) (string, error) {
+	spec, err := cronexp.Normalize(e.Expression, e.Dialect)
+	if err != nil {
+		return "", err
+	}
+	return schedule.Expand(spec, GetURI(e))
+}
+
+// NextFire get event next fire time after now; false for not schedulable (paused or finished) event,
+// invalid expression or when next fire time is after event end time
+func NextFire(e Event, now time.Time) (time.Time, bool) {
+	if !Schedulable(e.Status) {
+		return time.Time{}, false
+	}
+	spec, err := EventSpec(e)
+	if err != nil {
+		return time.Time{}, false
+	}
+	sch, err := cron.Parse(spec)
+	if err != nil {
+		return time.Time{}, false
+	}
+	next := sch.Next(now)
+	if next.IsZero() {
+		return time.Time{}, false
+	}
+	if e.Until != "" {
+		until, err := time.Parse(time.RFC3339, e.Until)
+		if err != nil || next.After(until) {
+			return time.Time{}, false
+		}
+	}
+	return next, true
+}
+
+// ValidStatus check if status is known event status
+func ValidStatus(status string) bool {
+	_, ok := transitions[status]
+	return ok
+}
+
+// CanTransition check if event status can change from one status to another;
+// unknown (or empty) status can change to any status
+func CanTransition(from, to string) bool {
+	if from == to {
+		return true
+	}
+	next, ok := transitions[from]
+	if !ok {
+		return true
+	}
+	for _, s := range next {
+		if s == to {
+			return true
+		}
 	}
+	return false
+}
+
+// Schedulable check if event with this status should be scheduled
+func Schedulable(status string) bool {
+	switch status {
+	case StatusPaused, StatusExpired, StatusCompleted:
+		return false
+	}
+	return true
+}
+
+// concurrency policies: similar to Kubernetes CronJob
+const (
+	// ConcurrencyAllow allow concurrent triggers
+	ConcurrencyAllow = "Allow"
+	// ConcurrencyForbid skip new trigger, if previous one is still running
+	ConcurrencyForbid = "Forbid"
+	// ConcurrencyReplace cancel running trigger and replace it with a new one
+	ConcurrencyReplace = "Replace"
+)
+
+// history record results
+const (
+	// ResultTriggered event triggered successfully
+	ResultTriggered = "triggered"
+	// ResultFailed failed to trigger event
+	ResultFailed = "failed"
+	// ResultSkipped trigger skipped
+	ResultSkipped = "skipped"
+	// ResultCanceled running trigger canceled
+	ResultCanceled = "canceled"
 )
 
 // ErrEventNotFound error when cron event not found
 var ErrEventNotFound = errors.New("cron event not found")
 
+// ValidConcurrencyPolicy check concurrency policy name; empty policy is Allow
+func ValidConcurrencyPolicy(policy string) bool {
+	switch policy {
+	case "", ConcurrencyAllow, ConcurrencyForbid, ConcurrencyReplace:
+		return true
+	}
+	return false
+}
+
 var commonHelp = `Cronus cron event provider triggers Codefresh pipeline execution, following cron expression.
 Supported cron expression syntax:
 https://github.com/codefresh-io/cronus/blob/master/docs/expression.md`
@@ -65,24 +240,32 @@ func ConstructEvent(uri string, secret string, cronguru cronexp.Service) (*Event
 		log.Error("bad cron event uri: wrong type or kind")
 		return nil, errors.New("bad cron event uri: wrong type or kind")
 	}
-	// validate expression
+	// validate expression (normalize and expand H tokens first); dialect option is validated on subscribe
 	expression := s[2]
-	if _, err := cron.Parse(expression); err != nil {
+	spec, err := cronexp.Normalize(expression, cronexp.DialectAuto)
+	if err == nil {
+		spec, err = schedule.Expand(spec, uri)
+	}
+	if err != nil {
+		log.WithError(err).Error("error expanding cron expression")
+		return nil, err
+	}
+	if err := cronexp.LintExpression(spec, expression, cronexp.DialectAuto); err != nil {
+		log.WithError(err).Error("invalid cron expression")
+		return nil, err
+	}
+	if _, err := cron.Parse(spec); err != nil {
 		log.WithError(err).Error("error parcing cron expression")
 		return nil, err
 	}
 	// get message
 	message := s[3]
 	// get cron expression descriptor
-	description, err := cronguru.DescribeCronExpression(expression)
-	if err != nil {
-		log.WithError(err).Warn("failed to get cron expression description")
-		description = "failed to get cron description"
-	}
+	description := describe(spec, cronguru)
 	// get account
 	account := s[4]
-	// set status to active
-	status := "active"
+	// set status to pending: event is not scheduled yet
+	status := StatusPending
 	// set help string
 	help := commonHelp
 	return &Event{
@@ -95,3 +278,25 @@ func ConstructEvent(uri string, secret string, cronguru cronexp.Service) (*Event
 		Help:        help,
 	}, nil
 }
+
+// describe cron spec description; placeholder text, if spec cannot be described
+func describe(spec string, cronguru cronexp.Service) string {
+	description, err := cronguru.DescribeCronExpression(spec)
+	if err != nil {
+		log.WithError(err).Warn("failed to get cron expression description")
+		return "failed to get cron description"
+	}
+	return description
+}
+
+// Describe set event description and help text, the same way as on event subscription
+func Describe(e *Event, cronguru cronexp.Service) {
+	spec, err := EventSpec(*e)
+	if err != nil {
+		log.WithError(err).Warn("failed to get cron expression description")
+		e.Description = "failed to get cron description"
+	} else {
+		e.Description = describe(spec, cronguru)
+	}
+	e.Help = commonHelp
+}
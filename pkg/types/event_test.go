@@ -36,7 +36,7 @@ func TestConstructEvent(t *testing.T) {
 			args: args{
 				uri:         "cron:codefresh:5 0 * 8 *:test-message:abcdef1234",
 				secret:      "1234",
-				expression:  "5 0 * 8 *",
+				expression:  "0 5 0 * 8 *", // 5 fields expression is described normalized
 				description: "At 00:05 in August",
 			},
 			want: &Event{
@@ -45,7 +45,7 @@ func TestConstructEvent(t *testing.T) {
 				Account:     "abcdef1234",
 				Secret:      "1234",
 				Description: "At 00:05 in August",
-				Status:      "active",
+				Status:      StatusPending,
 				Help:        commonHelp,
 			},
 		},
@@ -54,7 +54,7 @@ func TestConstructEvent(t *testing.T) {
 			args: args{
 				uri:         "cron:codefresh:5 0 * 8 *:test-message:abcdef1234",
 				secret:      "1234",
-				expression:  "5 0 * 8 *",
+				expression:  "0 5 0 * 8 *",
 				description: "",
 			},
 			want: &Event{
@@ -63,11 +63,36 @@ func TestConstructEvent(t *testing.T) {
 				Message:     "test-message",
 				Secret:      "1234",
 				Description: "failed to get cron description",
-				Status:      "active",
+				Status:      StatusPending,
 				Help:        commonHelp,
 			},
 			failDescribe: true,
 		},
+		{
+			name: "construct hashed event",
+			args: args{
+				uri:         "cron:codefresh:H 0 * 8 *:test-message:abcdef1234",
+				secret:      "1234",
+				expression:  "0 52 0 * 8 *",
+				description: "At 00:52 in August",
+			},
+			want: &Event{
+				Expression:  "H 0 * 8 *",
+				Message:     "test-message",
+				Account:     "abcdef1234",
+				Secret:      "1234",
+				Description: "At 00:52 in August",
+				Status:      StatusPending,
+				Help:        commonHelp,
+			},
+		},
+		{
+			name: "invalid hashed expression",
+			args: args{
+				uri: "cron:codefresh:H(0-99) 0 * 8 *:test-message:abcdef1234",
+			},
+			wantErr: true,
+		},
 		{
 			name: "invalid cron expression",
 			args: args{
@@ -120,3 +145,29 @@ func TestConstructEvent(t *testing.T) {
 		})
 	}
 }
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from string
+		to   string
+		want bool
+	}{
+		{from: StatusPending, to: StatusActive, want: true},
+		{from: StatusActive, to: StatusFailing, want: true},
+		{from: StatusFailing, to: StatusFailing, want: true},
+		{from: StatusFailing, to: StatusActive, want: true},
+		{from: StatusPaused, to: StatusActive, want: true},
+		{from: StatusPaused, to: StatusFailing, want: false},
+		{from: StatusInvalid, to: StatusActive, want: true},
+		{from: StatusExpired, to: StatusActive, want: false},
+		{from: StatusCompleted, to: StatusPaused, want: false},
+		{from: "", to: StatusActive, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+"->"+tt.to, func(t *testing.T) {
+			if got := CanTransition(tt.from, tt.to); got != tt.want {
+				t.Errorf("CanTransition() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}